@@ -0,0 +1,201 @@
+package pdf
+
+// defaultAppearance is the default appearance string used by form
+// fields, selecting automatically sized black text in the Helvetica
+// font that SetAcroForm adds to the form's resources.
+const defaultAppearance = "/Helv 0 Tf 0 g"
+
+// TextField returns a text form field named name, containing value,
+// combined with the widget annotation that displays it in rect, in
+// page coordinates. Like other annotations, it should be added to a
+// page's Annots, after which SetAcroForm makes it part of the
+// document's form.
+func TextField(rect Rectangle, name, value string) Dict {
+	field := widget(rect)
+	field["FT"] = Name("Tx")
+	field["T"] = TextString(name)
+	field["V"] = TextString(value)
+	field["DA"] = LiteralString(defaultAppearance)
+	return field
+}
+
+// Checkbox returns a checkbox form field named name combined with the
+// widget annotation that displays it in rect, in page coordinates. It
+// is added to a document in the same way as a TextField.
+func Checkbox(rect Rectangle, name string, checked bool) Dict {
+	state := Name("Off")
+	if checked {
+		state = checkboxOn
+	}
+
+	field := widget(rect)
+	field["FT"] = Name("Btn")
+	field["T"] = TextString(name)
+	field["V"] = state
+	field["AS"] = state
+	field["AP"] = Dict{
+		"N": Dict{
+			checkboxOn: buttonAppearance(rect, checkMark),
+			"Off":      buttonAppearance(rect, nil),
+		},
+	}
+	return field
+}
+
+// checkboxOn is the name of the on state of a checkbox.
+const checkboxOn Name = "Yes"
+
+// RadioButton is a single button of a radio button group.
+type RadioButton struct {
+	// Rect is the area of the button, in page coordinates.
+	Rect Rectangle
+
+	// Value is the value that the group takes when the button is
+	// selected. It must be unique within the group.
+	Value Name
+}
+
+// Button field flags.
+const (
+	fieldNoToggleToOff = 1 << 14
+	fieldRadio         = 1 << 15
+)
+
+// AddRadioGroup adds a radio button group named name to the document,
+// with value selecting the button with that value, or none if it is
+// empty. It returns references to the widget annotations of each of
+// the buttons, which should be added to the Annots of the pages that
+// they appear on.
+func (d *Document) AddRadioGroup(name string, value Name, buttons []RadioButton) []Reference {
+	if value == "" {
+		value = "Off"
+	}
+
+	kids := make(Array, 0, len(buttons))
+	group := Dict{
+		"FT": Name("Btn"),
+		"Ff": Integer(fieldRadio | fieldNoToggleToOff),
+		"T":  TextString(name),
+		"V":  value,
+	}
+	ref := d.addDistinct(group)
+
+	refs := make([]Reference, 0, len(buttons))
+	for _, b := range buttons {
+		state := Name("Off")
+		if b.Value == value {
+			state = b.Value
+		}
+
+		kid := widget(b.Rect)
+		kid["Parent"] = ref
+		kid["AS"] = state
+		kid["AP"] = Dict{
+			"N": Dict{
+				b.Value: buttonAppearance(b.Rect, radioDot),
+				"Off":   buttonAppearance(b.Rect, nil),
+			},
+		}
+		d.addAppearances(kid)
+
+		kidRef := d.addDistinct(kid)
+		kids = append(kids, kidRef)
+		refs = append(refs, kidRef)
+	}
+	group["Kids"] = kids
+
+	return refs
+}
+
+func widget(rect Rectangle) Dict {
+	return Dict{
+		"Type":    Name("Annot"),
+		"Subtype": Name("Widget"),
+		"Rect":    rect,
+		"F":       Integer(annotPrint),
+	}
+}
+
+// buttonAppearance returns an appearance stream the size of rect
+// containing whatever mark draws into a box of width w and height h.
+// A nil mark produces an empty appearance.
+func buttonAppearance(rect Rectangle, mark func(c *Content, w, h float64)) FormXObject {
+	rect = rect.Normalize()
+	w, h := rect.URX-rect.LLX, rect.URY-rect.LLY
+
+	var c Content
+	if mark != nil {
+		mark(&c, w, h)
+	}
+	return FormXObject{
+		BBox:    Rectangle{0, 0, w, h},
+		Content: c.Stream(),
+	}
+}
+
+func checkMark(c *Content, w, h float64) {
+	c.SetLineWidth(min(w, h) / 10)
+	c.MoveTo(0.2*w, 0.5*h)
+	c.LineTo(0.4*w, 0.25*h)
+	c.LineTo(0.8*w, 0.8*h)
+	c.Stroke()
+}
+
+func radioDot(c *Content, w, h float64) {
+	// Four Bézier curves approximate a circle closely enough for a
+	// small dot.
+	const k = 0.5523
+	x, y, r := w/2, h/2, min(w, h)/4
+	c.MoveTo(x+r, y)
+	c.CurveTo(x+r, y+k*r, x+k*r, y+r, x, y+r)
+	c.CurveTo(x-k*r, y+r, x-r, y+k*r, x-r, y)
+	c.CurveTo(x-r, y-k*r, x-k*r, y-r, x, y-r)
+	c.CurveTo(x+k*r, y-r, x+r, y-k*r, x+r, y)
+	c.Fill()
+}
+
+// SetAcroForm adds an interactive form to the catalog containing every
+// top-level form field that has been added to the document so far.
+// d.Root must already be set to the catalog, such as one returned by
+// AddPages.
+func (d *Document) SetAcroForm() error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	var fields Array
+	var signed bool
+	for _, obj := range d.Body {
+		dict, ok := obj.Object.(Dict)
+		if !ok {
+			continue
+		}
+		if dict["FT"] == Name("Sig") {
+			signed = true
+		}
+		if _, ok := dict["FT"]; !ok {
+			continue
+		}
+		if _, ok := dict["Parent"]; ok {
+			continue
+		}
+		fields = append(fields, Reference(obj.Name))
+	}
+
+	form := Dict{
+		"Fields": fields,
+		"DA":     LiteralString(defaultAppearance),
+		"DR": Dict{
+			"Font": Dict{"Helv": Helvetica.Dict()},
+		},
+		"NeedAppearances": Boolean(true),
+	}
+	if signed {
+		// SignaturesExist and AppendOnly, so that readers don't
+		// invalidate the signatures by rewriting the file.
+		form["SigFlags"] = Integer(3)
+	}
+	catalog["AcroForm"] = form
+	return nil
+}
@@ -0,0 +1,90 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTextField(t *testing.T) {
+	var d Document
+	f := TextField(Rectangle{50, 700, 250, 720}, "name", "Bob")
+	catalog, pages, err := d.AddPages([]Page{{MediaBox: A4, Annots: []Object{f, LinkURI(Rectangle{}, "x")}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	if err := d.SetAcroForm(); err != nil {
+		t.Fatal(err)
+	}
+	af := lookup(&d, catalog).(Dict)["AcroForm"].(Dict)
+	fields := af["Fields"].(Array)
+	if len(fields) != 1 {
+		t.Fatal(fields)
+	}
+	w := lookup(&d, fields[0].(Reference)).(Dict)
+	if w["FT"] != Name("Tx") || w["Subtype"] != Name("Widget") || w["T"] != LiteralString("name") || w["V"] != LiteralString("Bob") || w["P"] != pages[0] {
+		t.Fatal(w)
+	}
+	if lookup(&d, pages[0]).(Dict)["Annots"].(Array)[0] != fields[0] {
+		t.Fatal("annots")
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+}
+
+func TestButtons(t *testing.T) {
+	var d Document
+	radios := d.AddRadioGroup("size", "M", []RadioButton{
+		{Rect: Rectangle{10, 10, 30, 30}, Value: "S"},
+		{Rect: Rectangle{40, 10, 60, 30}, Value: "M"},
+	})
+	cb := Checkbox(Rectangle{100, 100, 120, 120}, "agree", false)
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Annots: []Object{cb, radios[0], radios[1]}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	if err := d.SetAcroForm(); err != nil {
+		t.Fatal(err)
+	}
+	if cb["AS"] != Name("Off") || cb["FT"] != Name("Btn") {
+		t.Fatal(cb)
+	}
+	n := cb["AP"].(Dict)["N"].(Dict)
+	if len(n) != 2 {
+		t.Fatal(n)
+	}
+	for _, k := range []Name{"Yes", "Off"} {
+		ref, ok := n[k].(Reference)
+		if !ok {
+			t.Fatal(n)
+		}
+		if _, ok := lookup(&d, ref).(FormXObject); !ok {
+			t.Fatal("not form")
+		}
+	}
+	r0 := lookup(&d, radios[0]).(Dict)
+	r1 := lookup(&d, radios[1]).(Dict)
+	if r0["AS"] != Name("Off") || r1["AS"] != Name("M") {
+		t.Fatal(r0["AS"], r1["AS"])
+	}
+	if _, ok := r1["AP"].(Dict)["N"].(Dict)["M"].(Reference); !ok {
+		t.Fatal("radio ap")
+	}
+	fields := lookup(&d, catalog).(Dict)["AcroForm"].(Dict)["Fields"].(Array)
+	if len(fields) != 2 {
+		t.Fatal(fields)
+	}
+	group := lookup(&d, r0["Parent"].(Reference)).(Dict)
+	if group["V"] != Name("M") || len(group["Kids"].(Array)) != 2 {
+		t.Fatal(group)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+}
@@ -0,0 +1,162 @@
+package pdf
+
+import "errors"
+
+// annotPrint is the annotation flag that allows an annotation to be
+// printed.
+const annotPrint = 1 << 2
+
+// addAppearances adds the appearance streams in the AP entry of annot
+// to d as indirect objects, replacing them with references.
+func (d *Document) addAppearances(annot Dict) {
+	ap, ok := annot["AP"].(Dict)
+	if !ok {
+		return
+	}
+
+	for k, v := range ap {
+		switch v := v.(type) {
+		case Stream, FormXObject:
+			ap[k] = d.Add(v)
+		case Dict:
+			// Appearances with several states are dictionaries of
+			// streams keyed by the state names.
+			for state, stream := range v {
+				switch stream.(type) {
+				case Stream, FormXObject:
+					v[state] = d.Add(stream)
+				}
+			}
+		}
+	}
+}
+
+// LinkURI returns a link annotation covering rect, in page
+// coordinates, that opens uri when it is activated.
+func LinkURI(rect Rectangle, uri string) Dict {
+	return link(rect, uriAction(uri))
+}
+
+// uriAction returns an action that opens uri.
+func uriAction(uri string) Dict {
+	return Dict{
+		"S":   Name("URI"),
+		"URI": LiteralString(uri),
+	}
+}
+
+// LinkGoTo returns a link annotation covering rect, in page
+// coordinates, that jumps to the page that page refers to, scrolled so
+// that top is at the top of the window.
+func LinkGoTo(rect Rectangle, page Reference, top float64) Dict {
+	return link(rect, Dict{
+		"S": Name("GoTo"),
+		"D": DestXYZ(page, top),
+	})
+}
+
+// LinkNamed returns a link annotation covering rect, in page
+// coordinates, that jumps to the named destination name; see
+// Document.Dests.
+func LinkNamed(rect Rectangle, name string) Dict {
+	return link(rect, Dict{
+		"S": Name("GoTo"),
+		"D": LiteralString(name),
+	})
+}
+
+func link(rect Rectangle, action Dict) Dict {
+	return Dict{
+		"Type":    Name("Annot"),
+		"Subtype": Name("Link"),
+		"Rect":    rect,
+		"Border":  Array{Integer(0), Integer(0), Integer(0)},
+		"A":       action,
+	}
+}
+
+// TextNote returns a text annotation at rect, in page coordinates,
+// which viewers show as a note icon that pops up contents when it is
+// opened.
+func TextNote(rect Rectangle, contents string) Dict {
+	return Dict{
+		"Type":     Name("Annot"),
+		"Subtype":  Name("Text"),
+		"Rect":     rect,
+		"Contents": TextString(contents),
+		"F":        Integer(annotPrint),
+	}
+}
+
+// SetAppearance sets the normal appearance of annot, such as one
+// returned by TextNote, LinkURI, or TextField, to a form containing
+// content, with the resources that it needs. The content is drawn in a
+// box the size of the annotation's Rect, with its origin at the box's
+// lower-left corner, so that the annotation looks the same in every
+// viewer. Like other appearances, the form is added to the document
+// along with the annotation by AddPages.
+func SetAppearance(annot Dict, content Stream, resources Dict) error {
+	rect, ok := annot["Rect"].(Rectangle)
+	if !ok {
+		return errors.New("pdf: annotation has no Rect")
+	}
+	rect = rect.Normalize()
+
+	annot["AP"] = Dict{
+		"N": FormXObject{
+			BBox:      Rectangle{0, 0, rect.URX - rect.LLX, rect.URY - rect.LLY},
+			Resources: resources,
+			Content:   content,
+		},
+	}
+	return nil
+}
+
+// FreeText returns a free text annotation that displays contents
+// directly on the page in rect, in page coordinates, using Helvetica
+// at the given size. Unlike a TextNote, it has no pop-up window.
+func FreeText(rect Rectangle, contents string, size float64) Dict {
+	return Dict{
+		"Type":     Name("Annot"),
+		"Subtype":  Name("FreeText"),
+		"Rect":     rect,
+		"Contents": TextString(contents),
+		"DA":       LiteralString("/Helv " + formatReal(size) + " Tf 0 g"),
+		"F":        Integer(annotPrint),
+	}
+}
+
+// Square returns a square annotation that draws a rectangle just
+// inside rect, in page coordinates, with a border of the given width.
+// The border and interior colors have one, three, or four components
+// for gray, RGB, or CMYK. If either is nil, that part of the rectangle
+// is transparent.
+func Square(rect Rectangle, width float64, border, interior []float64) Dict {
+	return shape("Square", rect, width, border, interior)
+}
+
+// Circle is like Square, but draws an ellipse that fits rect.
+func Circle(rect Rectangle, width float64, border, interior []float64) Dict {
+	return shape("Circle", rect, width, border, interior)
+}
+
+func shape(subtype Name, rect Rectangle, width float64, border, interior []float64) Dict {
+	annot := Dict{
+		"Type":    Name("Annot"),
+		"Subtype": subtype,
+		"Rect":    rect,
+		"BS": Dict{
+			"Type": Name("Border"),
+			"W":    Real(width),
+			"S":    Name("S"),
+		},
+		"F": Integer(annotPrint),
+	}
+	if border != nil {
+		annot["C"] = realArray(border...)
+	}
+	if interior != nil {
+		annot["IC"] = realArray(interior...)
+	}
+	return annot
+}
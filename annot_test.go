@@ -0,0 +1,153 @@
+package pdf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	var d Document
+	uri := LinkURI(Rectangle{10, 20, 110, 40}, "https://example.com/?a=(b)")
+	catalog, pages, err := d.AddPages([]Page{{MediaBox: A4, Annots: []Object{uri}}, {MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	gt := LinkGoTo(Rectangle{0, 0, 50, 50}, pages[1], 700)
+	if _, _, err := d.AddPages([]Page{{MediaBox: A4, Annots: []Object{gt}}}); err != nil {
+		t.Fatal(err)
+	}
+	p := lookup(&d, pages[0]).(Dict)
+	ann := p["Annots"].(Array)
+	u := lookup(&d, ann[0].(Reference)).(Dict)
+	if u["P"] != pages[0] || u["Subtype"] != Name("Link") {
+		t.Fatal(u)
+	}
+	var buf bytes.Buffer
+	EncodeObject(&buf, u)
+	if buf.String() != "<</A <</S /URI /URI (https://example.com/?a=\\(b\\)) >> /Border [0 0 0] /P 1 0 R /Rect [10 20 110 40] /Subtype /Link /Type /Annot >>" {
+		t.Fatal("uri")
+	}
+	buf.Reset()
+	EncodeObject(&buf, gt["A"])
+	if buf.String() != "<</D [1 0 R /XYZ null 700 null] /S /GoTo >>" {
+		t.Fatal("goto")
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+}
+
+func TestAppearance(t *testing.T) {
+	rect := Rectangle{100, 700, 130, 720}
+	note := TextNote(rect, "Hello")
+	var c Content
+	c.SetRGBFill(1, 1, 0)
+	c.Rectangle(0, 0, 30, 20)
+	c.Fill()
+	if err := SetAppearance(note, c.Stream(), nil); err != nil {
+		t.Fatal(err)
+	}
+	link := LinkURI(Rectangle{10, 10, 50, 30}, "https://example.com")
+	if err := SetAppearance(link, c.Stream(), Dict{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAppearance(Dict{}, c.Stream(), nil); err == nil {
+		t.Fatal("no rect")
+	}
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Annots: []Object{note, link}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, obj := range p.Body {
+		dict, ok := obj.Object.(Dict)
+		if !ok || dict["Subtype"] != Name("Text") {
+			continue
+		}
+		ref := dict["AP"].(Dict)["N"].(Reference)
+		for _, o := range p.Body {
+			if o.Name == string(ref) {
+				st := o.Object.(Stream)
+				if st.Dict["Subtype"] != Name("Form") {
+					t.Fatal(st.Dict)
+				}
+				bbox := st.Dict["BBox"].(Array)
+				if bbox[2] != Integer(30) || bbox[3] != Integer(20) {
+					t.Fatal(bbox)
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatal("no appearance")
+	}
+	validate(t, data)
+}
+
+func TestMarkupAnnots(t *testing.T) {
+	sq := Square(Rectangle{100, 100, 200, 150}, 2.5, []float64{1, 0, 0}, nil)
+	circ := Circle(Rectangle{300, 100, 400, 150}, 1, []float64{0}, []float64{0, 0, 1})
+	ft := FreeText(Rectangle{100, 300, 300, 330}, "Comment", 12)
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Annots: []Object{sq, circ, ft}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for _, obj := range p.Body {
+		dict, ok := obj.Object.(Dict)
+		if !ok {
+			continue
+		}
+		switch dict["Subtype"] {
+		case Name("Square"):
+			n++
+			if dict["BS"].(Dict)["W"] != Real(2.5) {
+				t.Fatal(dict["BS"])
+			}
+			if !reflect.DeepEqual(dict["C"], Array{Integer(1), Integer(0), Integer(0)}) || dict["IC"] != nil {
+				t.Fatal(dict)
+			}
+		case Name("Circle"):
+			n++
+			if !reflect.DeepEqual(dict["IC"], Array{Integer(0), Integer(0), Integer(1)}) {
+				t.Fatal(dict)
+			}
+		case Name("FreeText"):
+			n++
+			if dict["DA"] != LiteralString("/Helv 12 Tf 0 g") {
+				t.Fatal(dict)
+			}
+		}
+	}
+	if n != 3 {
+		t.Fatal(n)
+	}
+	validate(t, data)
+}
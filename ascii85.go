@@ -0,0 +1,64 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/ascii85"
+	"io"
+)
+
+// ASCII85Filter is the ASCII85Decode filter, which encodes data in
+// base-85 followed by a ~> marking the end of the data. Groups of four
+// zero bytes are abbreviated as z.
+type ASCII85Filter struct{}
+
+func (ASCII85Filter) Name() Name {
+	return "ASCII85Decode"
+}
+
+func (ASCII85Filter) Encode(w io.Writer) (io.WriteCloser, error) {
+	return &ascii85Writer{w: w, enc: ascii85.NewEncoder(w)}, nil
+}
+
+func (ASCII85Filter) Decode(r io.Reader) (io.Reader, error) {
+	return ascii85.NewDecoder(&ascii85EODReader{r: bufio.NewReader(r)}), nil
+}
+
+type ascii85Writer struct {
+	w   io.Writer
+	enc io.WriteCloser
+}
+
+func (w *ascii85Writer) Write(data []byte) (int, error) {
+	return w.enc.Write(data)
+}
+
+func (w *ascii85Writer) Close() error {
+	err := w.enc.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w.w, "~>")
+	return err
+}
+
+// ascii85EODReader reads from r until the ~> end-of-data marker, which
+// encoding/ascii85 doesn't understand.
+type ascii85EODReader struct {
+	r   *bufio.Reader
+	eod bool
+}
+
+func (r *ascii85EODReader) Read(data []byte) (int, error) {
+	if r.eod {
+		return 0, io.EOF
+	}
+
+	n, err := r.r.Read(data)
+	if i := bytes.IndexByte(data[:n], '~'); i >= 0 {
+		r.eod = true
+		return i, nil
+	}
+	return n, err
+}
@@ -0,0 +1,17 @@
+package pdf
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestASCII85(t *testing.T) {
+	in := make([]byte, 1001)
+	rand.Read(in)
+	copy(in[100:], make([]byte, 40))
+	body := roundTrip(t, ASCII85Filter{}, in)
+	if !bytes.HasSuffix(body, []byte("~>")) || !bytes.Contains(body, []byte("zzzz")) {
+		t.Fatal(string(body))
+	}
+}
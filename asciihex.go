@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ASCIIHexFilter is the ASCIIHexDecode filter, which encodes data as
+// pairs of hexadecimal digits followed by a > marking the end of the
+// data.
+type ASCIIHexFilter struct{}
+
+func (ASCIIHexFilter) Name() Name {
+	return "ASCIIHexDecode"
+}
+
+func (ASCIIHexFilter) Encode(w io.Writer) (io.WriteCloser, error) {
+	return &asciiHexWriter{w: w}, nil
+}
+
+func (ASCIIHexFilter) Decode(r io.Reader) (io.Reader, error) {
+	return &asciiHexReader{r: bufio.NewReader(r)}, nil
+}
+
+// asciiHexLine is the number of hex digits written per line by
+// asciiHexWriter.
+const asciiHexLine = 64
+
+type asciiHexWriter struct {
+	w    io.Writer
+	line int
+	buf  []byte
+}
+
+func (w *asciiHexWriter) Write(data []byte) (int, error) {
+	w.buf = w.buf[:0]
+	for _, c := range data {
+		if w.line == asciiHexLine {
+			w.buf = append(w.buf, '\n')
+			w.line = 0
+		}
+		w.buf = append(w.buf, hexDigits[c>>4], hexDigits[c&0xF])
+		w.line += 2
+	}
+
+	_, err := w.w.Write(w.buf)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *asciiHexWriter) Close() error {
+	_, err := io.WriteString(w.w, ">")
+	return err
+}
+
+type asciiHexReader struct {
+	r   *bufio.Reader
+	eod bool
+}
+
+func (r *asciiHexReader) Read(data []byte) (n int, err error) {
+	for (n < len(data)) && !r.eod {
+		hi, ok, err := r.digit()
+		if err != nil {
+			return n, err
+		}
+		if !ok {
+			break
+		}
+
+		lo, ok, err := r.digit()
+		if err != nil {
+			return n, err
+		}
+		if !ok {
+			// A final odd digit is treated as though it were followed
+			// by a zero.
+			lo = 0
+		}
+
+		data[n] = hi<<4 | lo
+		n++
+	}
+
+	if (n == 0) && r.eod {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// digit reads the next hex digit, skipping whitespace. It returns
+// false when the end of the data has been reached.
+func (r *asciiHexReader) digit() (byte, bool, error) {
+	for {
+		c, err := r.r.ReadByte()
+		if err == io.EOF {
+			// Tolerate a missing end-of-data marker.
+			r.eod = true
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+
+		switch {
+		case isWhitespace(c):
+			continue
+		case c == '>':
+			r.eod = true
+			return 0, false, nil
+		case (c >= '0') && (c <= '9'):
+			return c - '0', true, nil
+		case (c >= 'A') && (c <= 'F'):
+			return c - 'A' + 10, true, nil
+		case (c >= 'a') && (c <= 'f'):
+			return c - 'a' + 10, true, nil
+		default:
+			return 0, false, fmt.Errorf("pdf: invalid character in ASCIIHexDecode data: %q", c)
+		}
+	}
+}
+
+// isWhitespace returns true if c is one of the PDF whitespace
+// characters.
+func isWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	default:
+		return false
+	}
+}
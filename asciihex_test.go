@@ -0,0 +1,46 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func roundTrip(t *testing.T, f Decoder, in []byte) []byte {
+	var buf bytes.Buffer
+	if err := EncodeObject(&buf, Stream{Filters: []Filter{f}, Data: bytes.NewReader(in)}); err != nil {
+		t.Fatal(err)
+	}
+	dict, body := streamBody(t, buf.Bytes())
+	if !strings.Contains(dict, "/Filter /"+string(f.Name())) {
+		t.Fatal(dict)
+	}
+	r, err := f.Decode(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, in) {
+		t.Fatalf("round trip mismatch: %d vs %d", len(out), len(in))
+	}
+	return body
+}
+
+func TestASCIIHex(t *testing.T) {
+	in := make([]byte, 1000)
+	rand.Read(in)
+	body := roundTrip(t, ASCIIHexFilter{}, in)
+	if body[len(body)-1] != '>' {
+		t.Fatal("no EOD")
+	}
+	r, _ := ASCIIHexFilter{}.Decode(strings.NewReader(" 4 1\n42 c>ignored"))
+	out, _ := io.ReadAll(r)
+	if string(out) != "AB\xc0" {
+		t.Fatalf("%q", out)
+	}
+}
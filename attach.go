@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"crypto/md5"
+	"time"
+)
+
+// AttachFile embeds data in the document as a file named name, which
+// viewers list among the document's attachments, and returns a
+// reference to its file specification, which can also be passed to
+// FileAttachment to show it on a page. Attaching another file with the
+// same name replaces it in the list.
+func (d *Document) AttachFile(name string, data []byte) Reference {
+	sum := md5.Sum(data)
+	st := FlateBytes(data)
+	st.Dict = Dict{
+		"Type": Name("EmbeddedFile"),
+		"Params": Dict{
+			"Size":         Integer(len(data)),
+			"CreationDate": Date(time.Now()),
+			"CheckSum":     HexString(sum[:]),
+		},
+	}
+
+	spec := d.addDistinct(Dict{
+		"Type": Name("Filespec"),
+		"F":    LiteralString(name),
+		"UF":   TextString(name),
+		"EF":   Dict{"F": d.Add(st)},
+	})
+	if d.files == nil {
+		d.files = make(map[string]Reference)
+	}
+	d.files[name] = spec
+	return spec
+}
+
+// FileAttachment returns a file attachment annotation at rect, in page
+// coordinates, which viewers show as an icon that opens the file that
+// file, such as one returned by AttachFile, refers to. The description
+// is shown when the icon is hovered over.
+func FileAttachment(rect Rectangle, file Reference, description string) Dict {
+	return Dict{
+		"Type":     Name("Annot"),
+		"Subtype":  Name("FileAttachment"),
+		"Rect":     rect,
+		"FS":       file,
+		"Contents": TextString(description),
+		"Name":     Name("PushPin"),
+		"F":        Integer(annotPrint),
+	}
+}
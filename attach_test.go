@@ -0,0 +1,53 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestAttachFile(t *testing.T) {
+	var d Document
+	text := []byte("hello, attached world\n")
+	spec := d.AttachFile("notes.txt", text)
+	d.AttachFile("a.txt", []byte("x"))
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Annots: []Object{FileAttachment(Rectangle{10, 10, 30, 30}, spec, "Notes")}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]Object{}
+	for _, o := range p.Body {
+		byName[o.Name] = o.Object
+	}
+	cat := byName[string(p.Root)].(Dict)
+	tree := byName[string(cat["Names"].(Dict)["EmbeddedFiles"].(Reference))].(Dict)
+	leaf := byName[string(tree["Kids"].(Array)[0].(Reference))].(Dict)
+	names := leaf["Names"].(Array)
+	if len(names) != 4 || names[0] != LiteralString("a.txt") || names[2] != LiteralString("notes.txt") {
+		t.Fatal(names)
+	}
+	fs := byName[string(names[3].(Reference))].(Dict)
+	st := byName[string(fs["EF"].(Dict)["F"].(Reference))].(Stream)
+	if st.Dict["Type"] != Name("EmbeddedFile") || st.Dict["Params"].(Dict)["Size"] != Integer(len(text)) {
+		t.Fatal(st.Dict)
+	}
+	r, err := decodeStream(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	if !bytes.Equal(got, text) {
+		t.Fatal(string(got))
+	}
+	validate(t, data)
+}
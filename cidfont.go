@@ -0,0 +1,169 @@
+package pdf
+
+import (
+	"bytes"
+	"slices"
+)
+
+// EmbeddedFont is a TrueType font that has been embedded into a
+// document as a Type0 font. Text shown using it has to be encoded with
+// Encode first.
+type EmbeddedFont struct {
+	// Ref refers to the font's dictionary, for use in the Font entry
+	// of a resource dictionary.
+	Ref Reference
+
+	font *TrueType
+}
+
+// Encode returns s encoded as character codes for f, suitable for
+// passing to Content.ShowText. Each rune is encoded as the two-byte
+// index of its glyph in the embedded subset.
+func (f *EmbeddedFont) Encode(s string) string {
+	buf := make([]byte, 0, 2*len(s))
+	for _, r := range s {
+		gid := f.font.GlyphIndex(r)
+		buf = append(buf, byte(gid>>8), byte(gid))
+	}
+	return string(buf)
+}
+
+// Width returns the width of s when shown in f at the given size.
+func (f *EmbeddedFont) Width(s string, size float64) float64 {
+	var total int
+	for _, r := range s {
+		total += f.font.advance(f.font.GlyphIndex(r))
+	}
+	return float64(total) * size / 1000
+}
+
+// Font descriptor flags.
+const (
+	fontFixedPitch = 1 << 0
+	fontSerif      = 1 << 1
+	fontSymbolic   = 1 << 2
+	fontItalic     = 1 << 6
+)
+
+// EmbedTrueType parses the TrueType font in data and embeds it into d
+// as a Type0 font with a CIDFontType2 descendant. Only the glyphs used
+// by runes are embedded, so runes should cover all of the text that
+// will be shown with the font; anything else will be shown using the
+// missing glyph.
+func (d *Document) EmbedTrueType(data []byte, runes []rune) (*EmbeddedFont, error) {
+	font, err := ParseTrueType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	runes = slices.Clone(runes)
+	slices.Sort(runes)
+	runes = slices.Compact(runes)
+
+	font, err = font.Subset(runes)
+	if err != nil {
+		return nil, err
+	}
+
+	gids := []uint16{0}
+	text := make(map[uint16]string, len(runes))
+	for _, r := range runes {
+		gid := font.GlyphIndex(r)
+		gids = append(gids, gid)
+		if _, ok := text[gid]; !ok && (gid != 0) {
+			text[gid] = string(r)
+		}
+	}
+	slices.Sort(gids)
+	gids = slices.Compact(gids)
+
+	file := d.Add(Stream{
+		Dict:    Dict{"Length1": Integer(len(font.data))},
+		Filters: []Filter{FlateFilter{}},
+		Data:    bytes.NewReader(font.data),
+	})
+
+	name := Name(subsetTag(runes) + "+" + font.PostScriptName())
+	descriptor := d.Add(font.descriptor(name, file))
+	cidFont := d.Add(Dict{
+		"Type":     Name("Font"),
+		"Subtype":  Name("CIDFontType2"),
+		"BaseFont": name,
+		"CIDSystemInfo": Dict{
+			"Registry":   LiteralString("Adobe"),
+			"Ordering":   LiteralString("Identity"),
+			"Supplement": Integer(0),
+		},
+		"FontDescriptor": descriptor,
+		"DW":             Integer(font.advance(0)),
+		"W":              font.widths(gids),
+		"CIDToGIDMap":    Name("Identity"),
+	})
+
+	ref := d.Add(Dict{
+		"Type":            Name("Font"),
+		"Subtype":         Name("Type0"),
+		"BaseFont":        name,
+		"Encoding":        Name("Identity-H"),
+		"DescendantFonts": Array{cidFont},
+		"ToUnicode":       d.Add(ToUnicode(text)),
+	})
+
+	return &EmbeddedFont{Ref: ref, font: font}, nil
+}
+
+// descriptor returns a font descriptor for f.
+func (f *TrueType) descriptor(name Name, file Reference) Dict {
+	flags := fontSymbolic
+	if f.fixedPitch {
+		flags |= fontFixedPitch
+	}
+	if ((f.familyClass >= 1) && (f.familyClass <= 5)) || (f.familyClass == 7) {
+		flags |= fontSerif
+	}
+	if (f.italicAngle != 0) || (f.macStyle&2 != 0) {
+		flags |= fontItalic
+	}
+
+	return Dict{
+		"Type":     Name("FontDescriptor"),
+		"FontName": name,
+		"Flags":    Integer(flags),
+		"FontBBox": Array{
+			Integer(f.scale(f.bbox[0])),
+			Integer(f.scale(f.bbox[1])),
+			Integer(f.scale(f.bbox[2])),
+			Integer(f.scale(f.bbox[3])),
+		},
+		"ItalicAngle": Real(f.italicAngle),
+		"Ascent":      Integer(f.scale(f.ascent)),
+		"Descent":     Integer(f.scale(f.descent)),
+		"CapHeight":   Integer(f.scale(f.capHeight)),
+		"StemV":       Integer(f.stemV()),
+		"FontFile2":   file,
+	}
+}
+
+// stemV estimates the vertical stem width of f from its weight, as
+// TrueType fonts don't record it.
+func (f *TrueType) stemV() int {
+	return 10 + 220*max(f.weight-50, 0)/900
+}
+
+// widths returns a W array giving the widths of the glyphs in gids,
+// which must be sorted, grouping consecutive glyphs together.
+func (f *TrueType) widths(gids []uint16) Array {
+	var w Array
+	for i := 0; i < len(gids); {
+		run := Array{Integer(f.advance(gids[i]))}
+		j := i + 1
+		for (j < len(gids)) && (gids[j] == gids[j-1]+1) {
+			run = append(run, Integer(f.advance(gids[j])))
+			j++
+		}
+
+		w = append(w, Integer(gids[i]), run)
+		i = j
+	}
+	return w
+}
@@ -0,0 +1,60 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"unicode/utf16"
+)
+
+// maxBFChars is the maximum number of entries allowed in a single
+// bfchar block of a CMap.
+const maxBFChars = 100
+
+// ToUnicode returns a ToUnicode CMap stream mapping the two-byte
+// character codes in m to the text that they represent. A code may map
+// to more than one rune, as is the case for ligatures.
+func ToUnicode(m map[uint16]string) Stream {
+	codes := make([]uint16, 0, len(m))
+	for code := range m {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	var buf bytes.Buffer
+	buf.WriteString(`/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+`)
+
+	for len(codes) > 0 {
+		n := min(len(codes), maxBFChars)
+		fmt.Fprintf(&buf, "%v beginbfchar\n", n)
+		for _, code := range codes[:n] {
+			fmt.Fprintf(&buf, "<%04X> <", code)
+			for _, u := range utf16.Encode([]rune(m[code])) {
+				fmt.Fprintf(&buf, "%04X", u)
+			}
+			buf.WriteString(">\n")
+		}
+		buf.WriteString("endbfchar\n")
+		codes = codes[n:]
+	}
+
+	buf.WriteString(`endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`)
+
+	return Stream{
+		Length: int64(buf.Len()),
+		Data:   &buf,
+	}
+}
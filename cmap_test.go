@@ -0,0 +1,29 @@
+package pdf
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestToUnicode(t *testing.T) {
+	m := map[uint16]string{1: "A", 0x2C: "ffi", 0x300: "😀"}
+	for i := 0; i < 150; i++ {
+		m[uint16(0x1000+i)] = "x"
+	}
+	st := ToUnicode(m)
+	data, _ := io.ReadAll(st.Data)
+	s := string(data)
+	if strings.Count(s, "beginbfchar") != 2 || !strings.Contains(s, "100 beginbfchar") || !strings.Contains(s, "53 beginbfchar") {
+		t.Fatal(s)
+	}
+	re := regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>`)
+	got := map[string]string{}
+	for _, m := range re.FindAllStringSubmatch(s, -1) {
+		got[m[1]] = m[2]
+	}
+	if got["0001"] != "0041" || got["002C"] != "006600660069" || got["0300"] != "D83DDE00" {
+		t.Fatal(got["0001"], got["002C"], got["0300"])
+	}
+}
@@ -0,0 +1,145 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// SeparationColorSpace returns a Separation color space for the single
+// colorant name, such as a spot color. Its tint, from 0 for none to 1
+// for full, is converted to the color space alternate by tint, a
+// function such as a PostScriptFunction with one input and an output
+// for each component of alternate.
+//
+// Like other color spaces, the result is named in a resource
+// dictionary's ColorSpace entry and selected with
+// Content.SetFillColorSpace or Content.SetStrokeColorSpace, after
+// which Content.SetFillColor and Content.SetStrokeColor take the
+// tint.
+func SeparationColorSpace(name Name, alternate, tint Object) Array {
+	return Array{Name("Separation"), name, alternate, tint}
+}
+
+// DeviceNColorSpace returns a DeviceN color space for the colorants
+// names. It is like SeparationColorSpace, except that colors are given
+// by a tint for each colorant, so tint must take an input for each of
+// them.
+func DeviceNColorSpace(names []Name, alternate, tint Object) Array {
+	colorants := make(Array, 0, len(names))
+	for _, name := range names {
+		colorants = append(colorants, name)
+	}
+	return Array{Name("DeviceN"), colorants, alternate, tint}
+}
+
+// ICCColorSpace returns a stream containing profile, an ICC color
+// profile for a color space with n components, which must be 1, 3, or
+// 4. The stream must be added to a document as an indirect object,
+// after which ICCBased returns the color space that uses it.
+func ICCColorSpace(profile []byte, n int) (Object, error) {
+	alternates := map[int]Name{1: "DeviceGray", 3: "DeviceRGB", 4: "DeviceCMYK"}
+	alternate, ok := alternates[n]
+	if !ok {
+		return nil, fmt.Errorf("pdf: ICC profiles must have 1, 3, or 4 components, not %v", n)
+	}
+
+	if !isICCProfile(profile) {
+		return nil, errors.New("pdf: not an ICC profile")
+	}
+
+	st := FlateBytes(profile)
+	st.Dict = Dict{
+		"N":         Integer(n),
+		"Alternate": alternate,
+	}
+	return st, nil
+}
+
+// isICCProfile reports whether profile looks like an ICC profile.
+func isICCProfile(profile []byte) bool {
+	// Every profile starts with a 128 byte header that has a
+	// signature at offset 36.
+	return (len(profile) >= 128) && bytes.Equal(profile[36:40], []byte("acsp"))
+}
+
+// iccComponents returns the number of components in the color space
+// that profile describes, according to its header, or 0 if it isn't
+// an ICC profile for a gray, RGB, or CMYK color space.
+func iccComponents(profile []byte) int {
+	if !isICCProfile(profile) {
+		return 0
+	}
+	switch string(profile[16:20]) {
+	case "GRAY":
+		return 1
+	case "RGB ":
+		return 3
+	case "CMYK":
+		return 4
+	default:
+		return 0
+	}
+}
+
+// ICCBased returns an ICCBased color space using the profile stream
+// that profile refers to, such as one returned by ICCColorSpace.
+func ICCBased(profile Reference) Array {
+	return Array{Name("ICCBased"), profile}
+}
+
+// WhitePointD65 is the CIE 1931 XYZ tristimulus value of the D65
+// standard illuminant, as used by sRGB, for use with calibrated color
+// spaces.
+var WhitePointD65 = [3]float64{0.9505, 1, 1.089}
+
+// CalGrayColorSpace returns a CalGray color space with the diffuse
+// white point white, in CIE 1931 XYZ, and the given gamma. A gamma of
+// 0 is left out, which readers treat as 1.
+func CalGrayColorSpace(white [3]float64, gamma float64) (Array, error) {
+	err := checkWhitePoint(white)
+	if err != nil {
+		return nil, err
+	}
+
+	dict := Dict{"WhitePoint": realArray(white[:]...)}
+	if gamma != 0 {
+		dict["Gamma"] = Real(gamma)
+	}
+	return Array{Name("CalGray"), dict}, nil
+}
+
+// CalRGBColorSpace returns a CalRGB color space with the diffuse white
+// point white, in CIE 1931 XYZ, the gamma of each component, and
+// matrix, which holds the nine values of the linear transformation
+// from the gamma-corrected components to XYZ, in column order. A zero
+// gamma or a nil matrix is left out, which readers treat as 1 for
+// every component and the identity, respectively.
+func CalRGBColorSpace(white, gamma [3]float64, matrix []float64) (Array, error) {
+	err := checkWhitePoint(white)
+	if err != nil {
+		return nil, err
+	}
+
+	dict := Dict{"WhitePoint": realArray(white[:]...)}
+	if gamma != [3]float64{} {
+		dict["Gamma"] = realArray(gamma[:]...)
+	}
+	if matrix != nil {
+		if len(matrix) != 9 {
+			return nil, fmt.Errorf("pdf: CalRGB matrix must have 9 values, not %v", len(matrix))
+		}
+		dict["Matrix"] = realArray(matrix...)
+	}
+	return Array{Name("CalRGB"), dict}, nil
+}
+
+// checkWhitePoint returns an error if white isn't a valid white point
+// for a calibrated color space, which requires that every component be
+// positive and Y be 1.
+func checkWhitePoint(white [3]float64) error {
+	if (white[0] <= 0) || (white[1] != 1) || (white[2] <= 0) {
+		return fmt.Errorf("pdf: invalid white point %v", white)
+	}
+	return nil
+}
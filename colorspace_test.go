@@ -0,0 +1,144 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSeparation(t *testing.T) {
+	var d Document
+	tint := d.Add(PostScriptFunction{Domain: []float64{0, 1}, Range: []float64{0, 1, 0, 1, 0, 1, 0, 1}, Code: "{ dup 0.8 mul exch 0 exch dup 0.3 mul }"})
+	sep := SeparationColorSpace("PANTONE 300 C", Name("DeviceCMYK"), tint)
+	dn := DeviceNColorSpace([]Name{"Cyan", "Spot"}, Name("DeviceCMYK"), d.Add(PostScriptFunction{Domain: []float64{0, 1, 0, 1}, Range: []float64{0, 1, 0, 1, 0, 1, 0, 1}, Code: "{ 0 0 }"}))
+	var buf bytes.Buffer
+	EncodeObject(&buf, sep)
+	if !strings.HasPrefix(buf.String(), "[/Separation /PANTONE#20300#20C /DeviceCMYK ") {
+		t.Fatal(buf.String())
+	}
+	var c Content
+	c.SetFillColorSpace("CS0")
+	c.SetFillColor(0.5)
+	c.SetStrokeColorSpace("CS1")
+	c.SetStrokeColor(1, 0.25)
+	c.Rectangle(10, 10, 100, 100)
+	c.FillStroke()
+	if !strings.Contains(string(c.Bytes()), "/CS0 cs\n0.5 scn\n/CS1 CS\n1 0.25 SCN\n") {
+		t.Fatal(string(c.Bytes()))
+	}
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"ColorSpace": Dict{"CS0": sep, "CS1": dn}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("[/DeviceN [/Cyan /Spot] /DeviceCMYK")) {
+		t.Fatal("devicen")
+	}
+	validate(t, out.Bytes())
+}
+
+func testProfile() []byte {
+	p := make([]byte, 132)
+	binary.BigEndian.PutUint32(p, 132)
+	copy(p[4:], "none")
+	binary.BigEndian.PutUint32(p[8:], 0x02100000)
+	copy(p[12:], "mntr")
+	copy(p[16:], "RGB ")
+	copy(p[20:], "XYZ ")
+	copy(p[36:], "acsp")
+	return p
+}
+
+func TestICC(t *testing.T) {
+	if _, err := ICCColorSpace(testProfile(), 2); err == nil {
+		t.Fatal("n")
+	}
+	if _, err := ICCColorSpace(make([]byte, 200), 3); err == nil {
+		t.Fatal("header")
+	}
+	st, err := ICCColorSpace(testProfile(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d Document
+	ref := d.Add(st)
+	var c Content
+	c.SetFillColorSpace("CS0")
+	c.SetFillColor(1, 0, 0)
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"ColorSpace": Dict{"CS0": ICCBased(ref)}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if !bytes.Contains(data, []byte("/Alternate /DeviceRGB /Filter /FlateDecode /Length")) || !bytes.Contains(data, []byte("/N 3 >>")) || !bytes.Contains(data, []byte("[/ICCBased 1 0 R]")) {
+		t.Fatal(string(data))
+	}
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, _ := zlib.NewReader(p.Body[0].Object.(Stream).Data)
+	got, _ := io.ReadAll(zr)
+	if !bytes.Equal(got, testProfile()) {
+		t.Fatal("round trip")
+	}
+}
+
+func TestCalibrated(t *testing.T) {
+	m := []float64{0.4124, 0.2126, 0.0193, 0.3576, 0.7152, 0.1192, 0.1805, 0.0722, 0.9505}
+	cs, err := CalRGBColorSpace(WhitePointD65, [3]float64{2.2, 2.2, 2.2}, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := cs[1].(Dict)
+	if cs[0] != Name("CalRGB") || !reflect.DeepEqual(dict["WhitePoint"], Array{Real(0.9505), Real(1), Real(1.089)}) || len(dict["Matrix"].(Array)) != 9 || dict["Gamma"].(Array)[2] != Real(2.2) {
+		t.Fatal(cs)
+	}
+	if _, err := CalRGBColorSpace(WhitePointD65, [3]float64{}, m[:8]); err == nil {
+		t.Fatal("matrix")
+	}
+	if _, err := CalRGBColorSpace([3]float64{0.95, 1, -1}, [3]float64{}, nil); err == nil {
+		t.Fatal("white")
+	}
+	gray, err := CalGrayColorSpace(WhitePointD65, 1.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CalGrayColorSpace([3]float64{}, 1); err == nil {
+		t.Fatal("gray white")
+	}
+	var c Content
+	c.SetFillColorSpace("A")
+	c.SetFillColor(1, 0, 0)
+	c.Rectangle(0, 0, 10, 10)
+	c.Fill()
+	c.SetFillColorSpace("B")
+	c.SetFillColor(0.5)
+	c.Rectangle(20, 0, 10, 10)
+	c.Fill()
+	var d Document
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"ColorSpace": Dict{"A": cs, "B": gray}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("[/CalGray <</Gamma 1.8 /WhitePoint [0.9505 1 1.089] >>]")) {
+		t.Fatal("gray")
+	}
+	validate(t, out.Bytes())
+}
@@ -0,0 +1,278 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Content builds a content stream one operator at a time. The zero
+// value is an empty content stream ready to use.
+//
+// Methods don't return errors. Instead, the first error encountered,
+// such as from an attempt to use a non-finite number, is remembered
+// and reported by Err, and causes encoding of the stream returned by
+// Stream to fail.
+type Content struct {
+	buf bytes.Buffer
+	err error
+}
+
+// op writes an operator along with its numeric operands.
+func (c *Content) op(operator string, operands ...float64) {
+	objs := make([]Object, 0, len(operands))
+	for _, v := range operands {
+		objs = append(objs, Real(v))
+	}
+	c.opObjects(operator, objs...)
+}
+
+// opObjects writes an operator along with its operands.
+func (c *Content) opObjects(operator string, operands ...Object) {
+	for _, obj := range operands {
+		err := EncodeObject(&c.buf, obj)
+		if (err != nil) && (c.err == nil) {
+			c.err = fmt.Errorf("pdf: operand to %v: %w", operator, err)
+		}
+		c.buf.WriteByte(' ')
+	}
+	c.buf.WriteString(operator)
+	c.buf.WriteByte('\n')
+}
+
+// Err returns the first error encountered while building c, if any.
+func (c *Content) Err() error {
+	return c.err
+}
+
+// Bytes returns the content stream built so far.
+func (c *Content) Bytes() []byte {
+	return c.buf.Bytes()
+}
+
+// Stream returns a stream containing the content built so far.
+func (c *Content) Stream() Stream {
+	if c.err != nil {
+		return Stream{Data: errReader{c.err}}
+	}
+
+	return Stream{
+		Length: int64(c.buf.Len()),
+		Data:   bytes.NewReader(c.buf.Bytes()),
+	}
+}
+
+// Save saves the graphics state (q).
+func (c *Content) Save() {
+	c.op("q")
+}
+
+// Restore restores the most recently saved graphics state (Q).
+func (c *Content) Restore() {
+	c.op("Q")
+}
+
+// Concat modifies the current transformation matrix by applying m to
+// coordinates before they're transformed by the existing matrix (cm).
+func (c *Content) Concat(m Matrix) {
+	c.op("cm", m[:]...)
+}
+
+// SetLineWidth sets the line width (w).
+func (c *Content) SetLineWidth(w float64) {
+	c.op("w", w)
+}
+
+// SetExtGState applies the graphics state parameter dictionary named
+// name in the ExtGState resources, such as one returned by ExtGState
+// (gs).
+func (c *Content) SetExtGState(name Name) {
+	c.opObjects("gs", name)
+}
+
+// SetRGBFill sets the fill color in the DeviceRGB color space (rg).
+// Components range from 0 to 1.
+func (c *Content) SetRGBFill(r, g, b float64) {
+	c.op("rg", r, g, b)
+}
+
+// SetRGBStroke sets the stroke color in the DeviceRGB color space
+// (RG). Components range from 0 to 1.
+func (c *Content) SetRGBStroke(r, g, b float64) {
+	c.op("RG", r, g, b)
+}
+
+// SetFillColorSpace sets the color space used for filling to name,
+// which is a device color space such as DeviceRGB, the Pattern color
+// space, or the name of a color space in the ColorSpace resources
+// (cs).
+func (c *Content) SetFillColorSpace(name Name) {
+	c.opObjects("cs", name)
+}
+
+// SetStrokeColorSpace sets the color space used for stroking, like
+// SetFillColorSpace (CS).
+func (c *Content) SetStrokeColorSpace(name Name) {
+	c.opObjects("CS", name)
+}
+
+// SetFillColor sets the fill color to components in the current fill
+// color space, such as the tint of a SeparationColorSpace (scn).
+func (c *Content) SetFillColor(components ...float64) {
+	c.op("scn", components...)
+}
+
+// SetStrokeColor sets the stroke color, like SetFillColor (SCN).
+func (c *Content) SetStrokeColor(components ...float64) {
+	c.op("SCN", components...)
+}
+
+// SetFillPattern sets the fill color to the pattern named name in the
+// Pattern resources (scn). The fill color space must be Pattern, or,
+// for an uncolored pattern, a Pattern color space with an underlying
+// color space in which components gives the color to paint it with.
+func (c *Content) SetFillPattern(name Name, components ...float64) {
+	c.opObjects("scn", patternOperands(name, components)...)
+}
+
+// SetStrokePattern sets the stroke color to a pattern, like
+// SetFillPattern (SCN).
+func (c *Content) SetStrokePattern(name Name, components ...float64) {
+	c.opObjects("SCN", patternOperands(name, components)...)
+}
+
+func patternOperands(name Name, components []float64) []Object {
+	operands := make([]Object, 0, len(components)+1)
+	for _, v := range components {
+		operands = append(operands, Real(v))
+	}
+	return append(operands, name)
+}
+
+// MoveTo begins a new subpath at (x, y) (m).
+func (c *Content) MoveTo(x, y float64) {
+	c.op("m", x, y)
+}
+
+// LineTo adds a straight line to (x, y) to the current path (l).
+func (c *Content) LineTo(x, y float64) {
+	c.op("l", x, y)
+}
+
+// CurveTo adds a cubic Bézier curve to (x3, y3) to the current path,
+// using (x1, y1) and (x2, y2) as control points (c).
+func (c *Content) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
+	c.op("c", x1, y1, x2, y2, x3, y3)
+}
+
+// ClosePath closes the current subpath (h).
+func (c *Content) ClosePath() {
+	c.op("h")
+}
+
+// Rectangle adds a rectangle with its lower left corner at (x, y) to
+// the current path as a complete subpath (re).
+func (c *Content) Rectangle(x, y, w, h float64) {
+	c.op("re", x, y, w, h)
+}
+
+// Fill fills the current path using the nonzero winding number rule
+// (f).
+func (c *Content) Fill() {
+	c.op("f")
+}
+
+// Stroke strokes the current path (S).
+func (c *Content) Stroke() {
+	c.op("S")
+}
+
+// FillStroke fills and then strokes the current path (B).
+func (c *Content) FillStroke() {
+	c.op("B")
+}
+
+// BeginText begins a text object (BT).
+func (c *Content) BeginText() {
+	c.op("BT")
+}
+
+// EndText ends a text object (ET).
+func (c *Content) EndText() {
+	c.op("ET")
+}
+
+// SetFont sets the text font to the font named name in the font
+// resources and the text size to size (Tf).
+func (c *Content) SetFont(name Name, size float64) {
+	c.opObjects("Tf", name, Real(size))
+}
+
+// SetLeading sets the text leading, the distance between baselines of
+// successive lines (TL).
+func (c *Content) SetLeading(l float64) {
+	c.op("TL", l)
+}
+
+// SetTextPosition moves to the start of the next line, offset from the
+// start of the current one by (x, y) (Td).
+func (c *Content) SetTextPosition(x, y float64) {
+	c.op("Td", x, y)
+}
+
+// NewLine moves to the start of the next line using the current
+// leading (T*).
+func (c *Content) NewLine() {
+	c.op("T*")
+}
+
+// ShowText shows a string of text (Tj).
+func (c *Content) ShowText(s string) {
+	c.opObjects("Tj", LiteralString(s))
+}
+
+// DrawXObject draws the external object, such as an image or a form,
+// named name in the XObject resources (Do).
+func (c *Content) DrawXObject(name Name) {
+	c.opObjects("Do", name)
+}
+
+// Shade paints the shading named name in the Shading resources over
+// the current clipping path (sh).
+func (c *Content) Shade(name Name) {
+	c.opObjects("sh", name)
+}
+
+// BeginLayer begins a section of content that is only visible when
+// the optional content group named name in the Properties resources is
+// turned on (BDC). The section must be ended with EndLayer.
+func (c *Content) BeginLayer(name Name) {
+	c.opObjects("BDC", Name("OC"), name)
+}
+
+// EndLayer ends the section begun by the most recent BeginLayer (EMC).
+func (c *Content) EndLayer() {
+	c.op("EMC")
+}
+
+// BeginMarkedContent begins a marked-content sequence tagged with
+// tag, such as P, and identified on its page by mcid, so that an
+// element of the structure tree can refer to it; see MarkedContent
+// (BDC). The sequence must be ended with EndMarkedContent.
+func (c *Content) BeginMarkedContent(tag Name, mcid int) {
+	c.opObjects("BDC", tag, Dict{"MCID": Integer(mcid)})
+}
+
+// EndMarkedContent ends the sequence begun by the most recent
+// BeginMarkedContent (EMC).
+func (c *Content) EndMarkedContent() {
+	c.op("EMC")
+}
+
+// errReader is a reader that always fails.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,79 @@
+package pdf
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestContent(t *testing.T) {
+	var c Content
+	c.Save()
+	c.SetRGBFill(1, 0.5, 0)
+	c.Rectangle(10, 20, 100.25, 50)
+	c.Fill()
+	c.Restore()
+	want := "q\n1 0.5 0 rg\n10 20 100.25 50 re\nf\nQ\n"
+	if string(c.Bytes()) != want {
+		t.Fatalf("%q", c.Bytes())
+	}
+	var buf bytes.Buffer
+	if err := EncodeObject(&buf, c.Stream()); err != nil {
+		t.Fatal(err)
+	}
+	_, body := streamBody(t, buf.Bytes())
+	if string(body) != want {
+		t.Fatal(string(body))
+	}
+
+	var bad Content
+	bad.MoveTo(math.NaN(), 0)
+	if bad.Err() == nil || EncodeObject(&buf, bad.Stream()) == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestContentText(t *testing.T) {
+	var c Content
+	c.BeginText()
+	c.SetFont("F1", 12)
+	c.SetLeading(14)
+	c.SetTextPosition(72, 720)
+	c.ShowText("Hello (world)\\")
+	c.NewLine()
+	c.EndText()
+	want := "BT\n/F1 12 Tf\n14 TL\n72 720 Td\n(Hello \\(world\\)\\\\) Tj\nT*\nET\n"
+	if string(c.Bytes()) != want {
+		t.Fatalf("%q", c.Bytes())
+	}
+	var bad Content
+	bad.SetFont("", 1)
+	if bad.Err() == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestValidateBasic(t *testing.T) {
+	var d Document
+	var c Content
+	c.Save()
+	c.SetRGBFill(1, 0, 0)
+	c.Rectangle(10, 10, 100, 100)
+	c.Fill()
+	c.Restore()
+	c.BeginText()
+	c.SetFont("F1", 12)
+	c.SetTextPosition(72, 700)
+	c.ShowText("Hello (world)")
+	c.EndText()
+	cat, _, err := d.AddPages([]Page{{MediaBox: Letter, Contents: c.Stream(), Resources: Dict{"Font": Dict{"F1": d.Add(Helvetica.Dict())}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = cat
+	var buf bytes.Buffer
+	if _, err := d.Finish(&buf); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, buf.Bytes())
+}
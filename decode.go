@@ -0,0 +1,777 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Decode reads a complete PDF file of the given size from r. The
+// objects in the file's body are named as described by ObjectName,
+// and are returned in order of their object numbers. Incremental
+// updates are applied, so that only the latest version of each object
+// is returned.
+func Decode(r io.ReaderAt, size int64) (*PDF, error) {
+	d := newDecoder(r, size)
+	trailer, err := d.readXrefs()
+	if err != nil {
+		return nil, err
+	}
+
+	nums := make([]int, 0, len(d.entries))
+	for num, e := range d.entries {
+		if !e.free {
+			nums = append(nums, num)
+		}
+	}
+	slices.Sort(nums)
+
+	p := &PDF{Body: make([]Indirect, 0, len(nums))}
+	offsets := make([]int64, 0, len(nums))
+	var hint Integer
+	for _, num := range nums {
+		e := d.entries[num]
+		var obj Indirect
+		var err error
+		if e.stream > 0 {
+			obj, err = d.readCompressed(num, e.stream, e.index)
+		} else {
+			obj, err = d.readObject(objID{num: num, gen: e.gen}, e.offset)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Cross-reference and object streams are part of the file's
+		// structure rather than its content, and would be out of date
+		// if the PDF was encoded again. The same goes for the parameter
+		// dictionary and hint stream of a linearized file.
+		if st, ok := obj.Object.(Stream); ok {
+			if t := st.Dict["Type"]; (t == Name("XRef")) || (t == Name("ObjStm")) {
+				continue
+			}
+		}
+		if dict, ok := obj.Object.(Dict); ok && (dict["Linearized"] != nil) {
+			if h, ok := dict["H"].(Array); ok && (len(h) > 0) {
+				hint, _ = h[0].(Integer)
+			}
+			continue
+		}
+		p.Body = append(p.Body, obj)
+		offsets = append(offsets, e.offset)
+	}
+	if hint > 0 {
+		i := slices.Index(offsets, int64(hint))
+		if i >= 0 {
+			p.Body = slices.Delete(p.Body, i, i+1)
+		}
+	}
+
+	p.Root, _ = trailer["Root"].(Reference)
+	p.Info, _ = trailer["Info"].(Reference)
+	if id, ok := trailer["ID"].(Array); ok && (len(id) == len(p.ID)) {
+		for i := range p.ID {
+			p.ID[i] = stringBytes(id[i])
+		}
+	}
+	if p.Root == "" {
+		return nil, errors.New("pdf: trailer has no Root")
+	}
+	return p, nil
+}
+
+// stringBytes returns the contents of obj if it is a string, or nil if
+// it isn't.
+func stringBytes(obj Object) []byte {
+	switch obj := obj.(type) {
+	case LiteralString:
+		return []byte(obj)
+	case HexString:
+		return obj
+	default:
+		return nil
+	}
+}
+
+// findStartXref returns the offset given by the startxref line at the
+// end of a file.
+func findStartXref(r io.ReaderAt, size int64) (int64, error) {
+	// The end of the file is supposed to be within the last 1024 bytes,
+	// but some writers add a bit of junk after it.
+	tail := make([]byte, min(size, 2048))
+	_, err := r.ReadAt(tail, size-int64(len(tail)))
+	if (err != nil) && (err != io.EOF) {
+		return 0, err
+	}
+
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i < 0 {
+		return 0, errors.New("pdf: cannot find startxref")
+	}
+
+	p := newParser(bytes.NewReader(tail[i+len("startxref"):]))
+	tok, err := p.next()
+	if (err != nil) || (tok.Kind != TokenInteger) {
+		return 0, errors.New("pdf: startxref is not followed by an offset")
+	}
+	off, err := strconv.ParseInt(tok.Value, 10, 64)
+	if (err != nil) || (off < 0) || (off >= size) {
+		return 0, fmt.Errorf("pdf: invalid startxref offset: %v", tok.Value)
+	}
+	return off, nil
+}
+
+// decoder reads the parts of a PDF file.
+type decoder struct {
+	r    io.ReaderAt
+	size int64
+
+	entries map[int]xrefEntry
+	objStms map[int]*objStm
+
+	// startxref is the offset of the newest cross-reference section.
+	startxref int64
+
+	// resolving holds the numbers of the objects being read to find
+	// the lengths of streams, so that loops can be detected.
+	resolving map[int]bool
+}
+
+func newDecoder(r io.ReaderAt, size int64) *decoder {
+	return &decoder{
+		r:         r,
+		size:      size,
+		entries:   make(map[int]xrefEntry),
+		objStms:   make(map[int]*objStm),
+		resolving: make(map[int]bool),
+	}
+}
+
+// readXrefs reads every cross-reference section in the file, starting
+// with the one that startxref points to, into d.entries. It returns the
+// trailer of the newest section.
+func (d *decoder) readXrefs() (trailer Dict, err error) {
+	xref, err := findStartXref(d.r, d.size)
+	if err != nil {
+		return nil, err
+	}
+	d.startxref = xref
+
+	seen := make(map[int64]bool)
+	for {
+		if seen[xref] {
+			return nil, fmt.Errorf("pdf: cross-reference sections form a loop at offset %v", xref)
+		}
+		seen[xref] = true
+
+		t, err := d.readXref(xref, d.entries)
+		if err != nil {
+			return nil, err
+		}
+		if trailer == nil {
+			trailer = t
+		}
+
+		// Hybrid files keep the entries for objects that older readers
+		// don't need to know about in a separate stream.
+		if stm, ok := t["XRefStm"].(Integer); ok {
+			_, err := d.readXref(int64(stm), d.entries)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		prev, ok := t["Prev"].(Integer)
+		if !ok {
+			return trailer, nil
+		}
+		xref = int64(prev)
+	}
+}
+
+// parserAt returns a parser that starts reading at off, resolving
+// indirect stream lengths using the entries read so far.
+func (d *decoder) parserAt(off int64) *parser {
+	p := newParser(io.NewSectionReader(d.r, off, d.size-off))
+	p.s.off = off
+	p.length = d.resolveLength
+	return p
+}
+
+// resolveLength returns the value of the integer object that ref
+// refers to, for a stream whose Length is an indirect reference. It
+// returns false if the object can't be read or isn't an integer.
+func (d *decoder) resolveLength(ref Reference) (Integer, bool) {
+	n, g, ok := strings.Cut(string(ref), " ")
+	if !ok {
+		return 0, false
+	}
+	num, err := strconv.Atoi(n)
+	if err != nil {
+		return 0, false
+	}
+	gen, err := strconv.Atoi(g)
+	if err != nil {
+		return 0, false
+	}
+	e, ok := d.entries[num]
+	if !ok || e.free || (e.gen != gen) || d.resolving[num] {
+		return 0, false
+	}
+
+	d.resolving[num] = true
+	defer delete(d.resolving, num)
+
+	var obj Indirect
+	if e.stream > 0 {
+		obj, err = d.readCompressed(num, e.stream, e.index)
+	} else {
+		obj, err = d.readObject(objID{num: num, gen: gen}, e.offset)
+	}
+	if err != nil {
+		return 0, false
+	}
+	length, ok := obj.Object.(Integer)
+	return length, ok
+}
+
+// xrefEntry is an entry in a cross-reference section.
+type xrefEntry struct {
+	offset int64
+	gen    int
+	free   bool
+
+	// stream is the number of the object stream that contains the
+	// object, if any, and index is the object's index within it.
+	stream int
+	index  int
+}
+
+// readXref reads the cross-reference section at off, adding any
+// entries in it for objects that aren't already in entries, and
+// returns the trailer that follows it. Sections must be read from
+// newest to oldest.
+func (d *decoder) readXref(off int64, entries map[int]xrefEntry) (Dict, error) {
+	if (off < 0) || (off >= d.size) {
+		return nil, fmt.Errorf("pdf: invalid cross-reference offset: %v", off)
+	}
+
+	p := d.parserAt(off)
+	tok, err := p.nextMust()
+	if err != nil {
+		return nil, err
+	}
+	if tok.Kind == TokenInteger {
+		p.unread(tok)
+		return d.readXrefStream(p, entries)
+	}
+	if (tok.Kind != TokenKeyword) || (tok.Value != "xref") {
+		return nil, p.s.errorf(tok.Offset, "expected xref")
+	}
+
+	for {
+		tok, err := p.nextMust()
+		if err != nil {
+			return nil, err
+		}
+		if (tok.Kind == TokenKeyword) && (tok.Value == "trailer") {
+			break
+		}
+
+		start, err := p.xrefInt(tok)
+		if err != nil {
+			return nil, err
+		}
+		tok, err = p.nextMust()
+		if err != nil {
+			return nil, err
+		}
+		count, err := p.xrefInt(tok)
+		if err != nil {
+			return nil, err
+		}
+
+		for num := start; num < start+count; num++ {
+			var fields [3]Token
+			for i := range fields {
+				fields[i], err = p.nextMust()
+				if err != nil {
+					return nil, err
+				}
+			}
+			entry, err := p.xrefInt(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			gen, err := p.xrefInt(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			if (fields[2].Kind != TokenKeyword) || ((fields[2].Value != "n") && (fields[2].Value != "f")) {
+				return nil, p.s.errorf(fields[2].Offset, "invalid cross-reference entry type")
+			}
+
+			// Free entries are kept too, as they hide older versions
+			// of deleted objects.
+			if _, ok := entries[num]; !ok {
+				entries[num] = xrefEntry{
+					offset: int64(entry),
+					gen:    gen,
+					free:   fields[2].Value == "f",
+				}
+			}
+		}
+	}
+
+	trailer, err := p.parseObject()
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := trailer.(Dict)
+	if !ok {
+		return nil, p.s.errorf(tok.Offset, "trailer is not a dictionary")
+	}
+	return dict, nil
+}
+
+// xrefInt returns the value of an integer in a cross-reference table.
+func (p *parser) xrefInt(tok Token) (int, error) {
+	if tok.Kind != TokenInteger {
+		return 0, p.s.errorf(tok.Offset, "expected integer in cross-reference table")
+	}
+	v, err := strconv.Atoi(tok.Value)
+	if (err != nil) || (v < 0) {
+		return 0, p.s.errorf(tok.Offset, "invalid integer in cross-reference table: %v", tok.Value)
+	}
+	return v, nil
+}
+
+// readObject reads the indirect object id from off.
+func (d *decoder) readObject(id objID, off int64) (Indirect, error) {
+	if (off < 0) || (off >= d.size) {
+		return Indirect{}, fmt.Errorf("pdf: object %v %v has invalid offset %v", id.num, id.gen, off)
+	}
+
+	p := d.parserAt(off)
+	obj, err := p.parseObject()
+	if err == io.EOF {
+		err = p.s.errorf(off, "unexpected EOF")
+	}
+	if err != nil {
+		return Indirect{}, err
+	}
+
+	ind, ok := obj.(Indirect)
+	if !ok || (ind.Name != ObjectName(id.num, id.gen)) {
+		return Indirect{}, p.s.errorf(off, "expected object %v %v", id.num, id.gen)
+	}
+	return ind, nil
+}
+
+// DecodeObject reads a single object from r. Indirect object
+// definitions are returned as an Indirect, and references to indirect
+// objects as a Reference, both named as described by ObjectName.
+//
+// Streams are returned with their data read fully into memory. Their
+// Filter and DecodeParms entries are left in the stream's Dict, with
+// the data still encoded, so that the stream can be encoded again as
+// is. As there is no file to look them up in, indirect stream lengths
+// aren't resolved, and the data is found by looking for the endstream
+// keyword instead, as it is for any stream whose Length is wrong.
+func DecodeObject(r io.Reader) (Object, error) {
+	p := newParser(r)
+	obj, err := p.parseObject()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	return obj, err
+}
+
+// ObjectName returns the name given to the indirect object with the
+// given object and generation numbers when a PDF is decoded.
+func ObjectName(num, gen int) string {
+	return strconv.Itoa(num) + " " + strconv.Itoa(gen)
+}
+
+// parser builds objects from the tokens produced by a Scanner.
+type parser struct {
+	s      *Scanner
+	peeked []Token
+
+	// length, if not nil, returns the value of an indirect stream
+	// Length.
+	length func(Reference) (Integer, bool)
+}
+
+func newParser(r io.Reader) *parser {
+	return &parser{s: NewScanner(r)}
+}
+
+// next returns the next token, skipping comments.
+func (p *parser) next() (Token, error) {
+	if len(p.peeked) > 0 {
+		tok := p.peeked[len(p.peeked)-1]
+		p.peeked = p.peeked[:len(p.peeked)-1]
+		return tok, nil
+	}
+
+	for {
+		tok, err := p.s.Next()
+		if (err != nil) || (tok.Kind != TokenComment) {
+			return tok, err
+		}
+	}
+}
+
+// unread returns tok to the parser, to be returned again by next.
+// Tokens are returned in the reverse of the order that they are
+// unread in.
+func (p *parser) unread(tok Token) {
+	p.peeked = append(p.peeked, tok)
+}
+
+// nextMust is like next but treats EOF as a syntax error.
+func (p *parser) nextMust() (Token, error) {
+	tok, err := p.next()
+	if err == io.EOF {
+		return tok, p.s.errorf(p.s.Offset(), "unexpected EOF")
+	}
+	return tok, err
+}
+
+// parseObject parses an object, returning io.EOF if there are no more
+// tokens.
+func (p *parser) parseObject() (Object, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseFrom(tok)
+}
+
+// parseFrom parses an object starting with tok.
+func (p *parser) parseFrom(tok Token) (Object, error) {
+	switch tok.Kind {
+	case TokenInteger:
+		return p.parseNumber(tok)
+
+	case TokenReal:
+		f, err := strconv.ParseFloat(tok.Value, 64)
+		if err != nil {
+			return nil, p.s.errorf(tok.Offset, "invalid real: %q", tok.Value)
+		}
+		return Real(f), nil
+
+	case TokenName:
+		return Name(tok.Value), nil
+
+	case TokenString:
+		return LiteralString(tok.Value), nil
+
+	case TokenHexString:
+		return HexString(tok.Value), nil
+
+	case TokenArrayStart:
+		return p.parseArray()
+
+	case TokenDictStart:
+		dict, err := p.parseDict()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseStream(dict)
+
+	case TokenKeyword:
+		switch tok.Value {
+		case "true":
+			return Boolean(true), nil
+		case "false":
+			return Boolean(false), nil
+		case "null":
+			return Null{}, nil
+		}
+	}
+
+	if tok.Value == "" {
+		return nil, p.s.errorf(tok.Offset, "unexpected %v", tok.Kind)
+	}
+	return nil, p.s.errorf(tok.Offset, "unexpected %v %q", tok.Kind, tok.Value)
+}
+
+// parseNumber parses an integer, which may turn out to be the start of
+// a reference or of an indirect object definition.
+func (p *parser) parseNumber(tok Token) (Object, error) {
+	i, err := strconv.ParseInt(tok.Value, 10, 64)
+	if err != nil {
+		return nil, p.s.errorf(tok.Offset, "invalid integer: %q", tok.Value)
+	}
+
+	gen, err := p.next()
+	if (err != nil) || (gen.Kind != TokenInteger) {
+		if err == nil {
+			p.unread(gen)
+		}
+		return Integer(i), nil
+	}
+	kw, err := p.next()
+	if (err != nil) || (kw.Kind != TokenKeyword) || ((kw.Value != "R") && (kw.Value != "obj")) {
+		if err == nil {
+			p.unread(kw)
+		}
+		p.unread(gen)
+		return Integer(i), nil
+	}
+
+	g, err := strconv.Atoi(gen.Value)
+	if (err != nil) || (i < 0) || (g < 0) {
+		return nil, p.s.errorf(tok.Offset, "invalid object number: %v %v", tok.Value, gen.Value)
+	}
+	name := ObjectName(int(i), g)
+	if kw.Value == "R" {
+		return Reference(name), nil
+	}
+
+	obj, err := p.parseObject()
+	if err != nil {
+		if err == io.EOF {
+			err = p.s.errorf(p.s.Offset(), "unexpected EOF")
+		}
+		return nil, err
+	}
+	end, err := p.nextMust()
+	if err != nil {
+		return nil, err
+	}
+	if (end.Kind != TokenKeyword) || (end.Value != "endobj") {
+		return nil, p.s.errorf(end.Offset, "expected endobj")
+	}
+	return Indirect{Name: name, Generation: g, Object: obj}, nil
+}
+
+func (p *parser) parseArray() (Object, error) {
+	a := Array{}
+	for {
+		tok, err := p.nextMust()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Kind == TokenArrayEnd {
+			return a, nil
+		}
+
+		obj, err := p.parseFrom(tok)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, obj)
+	}
+}
+
+func (p *parser) parseDict() (Dict, error) {
+	d := Dict{}
+	for {
+		tok, err := p.nextMust()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Kind == TokenDictEnd {
+			return d, nil
+		}
+		if tok.Kind != TokenName {
+			return nil, p.s.errorf(tok.Offset, "dictionary key must be a name, not %v", tok.Kind)
+		}
+
+		val, err := p.nextMust()
+		if err != nil {
+			return nil, err
+		}
+		obj, err := p.parseFrom(val)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := obj.(Null); ok {
+			// A null value is the same as a missing entry.
+			continue
+		}
+		d[Name(tok.Value)] = obj
+	}
+}
+
+// parseStream checks to see if dict is followed by the stream keyword,
+// returning a Stream if it is or dict otherwise.
+func (p *parser) parseStream(dict Dict) (Object, error) {
+	tok, err := p.next()
+	if err == io.EOF {
+		return dict, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if (tok.Kind != TokenKeyword) || (tok.Value != "stream") {
+		p.unread(tok)
+		return dict, nil
+	}
+	if len(p.peeked) > 0 {
+		return nil, p.s.errorf(tok.Offset, "unexpected stream")
+	}
+
+	// The keyword is followed by a line ending, which is either CRLF or
+	// just LF, but be lenient about a lone CR.
+	c, err := p.s.readByte()
+	if (err == nil) && (c == '\r') {
+		c, err = p.s.readByte()
+	}
+	if (err == nil) && (c != '\n') {
+		p.s.unreadByte()
+	}
+
+	// The data is read according to Length if possible, but it is
+	// often wrong in damaged files, so the data is found by looking for
+	// the endstream keyword instead if it doesn't end up where Length
+	// says.
+	start := p.s.Offset()
+	var data []byte
+	length, ok := p.streamLength(dict)
+	if ok {
+		var buf bytes.Buffer
+		n, err := io.CopyN(&buf, p.s.r, int64(length))
+		p.s.off += n
+		if (err != nil) && (err != io.EOF) {
+			return nil, err
+		}
+		data = buf.Bytes()
+		ok = (err == nil) && p.atEndstream()
+	}
+	if !ok {
+		var err error
+		data, err = p.scanStreamData(start, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	end, err := p.nextMust()
+	if err != nil {
+		return nil, err
+	}
+	if (end.Kind != TokenKeyword) || (end.Value != "endstream") {
+		return nil, p.s.errorf(end.Offset, "expected endstream")
+	}
+
+	delete(dict, "Length")
+	return Stream{
+		Dict:   dict,
+		Length: int64(len(data)),
+		Data:   bytes.NewReader(data),
+	}, nil
+}
+
+// whitespace contains the PDF whitespace characters.
+const whitespace = "\x00\t\n\f\r "
+
+// streamLength returns the Length of the stream whose dictionary is
+// dict, resolving it if it is an indirect reference.
+func (p *parser) streamLength(dict Dict) (Integer, bool) {
+	var length Integer
+	var ok bool
+	switch v := dict["Length"].(type) {
+	case Integer:
+		length, ok = v, true
+	case Reference:
+		if p.length != nil {
+			length, ok = p.length(v)
+		}
+	}
+	return length, ok && (length >= 0)
+}
+
+// atEndstream reports whether the next token is the endstream keyword,
+// without consuming any input.
+func (p *parser) atEndstream() bool {
+	const keyword = "endstream"
+	for n := len(keyword); ; n *= 2 {
+		next, err := p.s.r.Peek(n)
+		rest := bytes.TrimLeft(next, whitespace)
+		if (len(rest) >= len(keyword)) || (err != nil) {
+			return bytes.HasPrefix(rest, []byte(keyword))
+		}
+	}
+}
+
+// scanStreamData reads the data of a stream that starts at start up to
+// the endstream keyword, beginning with data that has already been
+// read. As the keyword may also appear in binary data, the first one
+// followed by endobj is used, or the first one of all if the input
+// ends without one. The keyword itself is left to be read next.
+func (p *parser) scanStreamData(start int64, data []byte) ([]byte, error) {
+	keyword := []byte("endstream")
+	all := data
+	first := -1
+	var searched int
+	var eof bool
+	for {
+		for {
+			i := bytes.Index(all[searched:], keyword)
+			if i < 0 {
+				searched = max(searched, len(all)-len(keyword)+1)
+				break
+			}
+			i += searched
+
+			rest := bytes.TrimLeft(all[i+len(keyword):], whitespace)
+			if (len(rest) < len("endobj")) && !eof {
+				searched = i
+				break
+			}
+			if first < 0 {
+				first = i
+			}
+			if bytes.HasPrefix(rest, []byte("endobj")) {
+				return p.endStreamData(start, all, i), nil
+			}
+			searched = i + 1
+		}
+		if eof {
+			break
+		}
+
+		var chunk [4096]byte
+		n, err := p.s.r.Read(chunk[:])
+		all = append(all, chunk[:n]...)
+		p.s.off += int64(n)
+		if err == io.EOF {
+			eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if first < 0 {
+		return nil, p.s.errorf(start, "stream has no endstream")
+	}
+	return p.endStreamData(start, all, first), nil
+}
+
+// endStreamData returns the stream data in all, which was read
+// starting at start, up to the end of line before the endstream
+// keyword at i, and returns everything from the keyword on to the
+// scanner to be read again.
+func (p *parser) endStreamData(start int64, all []byte, i int) []byte {
+	rest := all[i:]
+	p.s.r = bufio.NewReader(io.MultiReader(bytes.NewReader(rest), p.s.r))
+	p.s.off = start + int64(i)
+
+	data := all[:i]
+	switch {
+	case bytes.HasSuffix(data, []byte("\r\n")):
+		data = data[:len(data)-2]
+	case bytes.HasSuffix(data, []byte("\n")), bytes.HasSuffix(data, []byte("\r")):
+		data = data[:len(data)-1]
+	}
+	return data
+}
@@ -0,0 +1,242 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDecodeObject(t *testing.T) {
+	for _, obj := range []Object{
+		Boolean(true), Boolean(false), Null{}, Integer(-42), Integer(0), Real(3.25), Real(-0.001),
+		LiteralString("hi (there) \\ \n\r\t\b\f \x00\xff"), HexString("\x00\x01\xfe"), HexString(""),
+		Name("A B#/c"), Name("x"),
+		Array{}, Array{Integer(1), Integer(2), Integer(3)}, Array{Integer(1), Integer(2), Reference("3 0")},
+		Dict{}, Dict{"A": Array{Dict{"B": Real(1.5)}}, "R": Reference("12 0"), "N": Integer(5)},
+		Reference("7 0"),
+	} {
+		var buf bytes.Buffer
+		if err := EncodeObject(&buf, obj); err != nil {
+			t.Fatal(err)
+		}
+		// References encode as numbers assigned on the fly, so
+		// compare on the encoding for those.
+		got, err := DecodeObject(&buf)
+		if err != nil {
+			t.Fatalf("%v: %v", obj, err)
+		}
+		var b1, b2 bytes.Buffer
+		EncodeObject(&b1, obj)
+		EncodeObject(&b2, got)
+		if b1.String() != b2.String() {
+			t.Fatalf("%q != %q", b1.String(), b2.String())
+		}
+		if !strings.Contains(b1.String(), " R") && !reflect.DeepEqual(got, obj) {
+			t.Fatalf("%#v != %#v", got, obj)
+		}
+	}
+
+	st := Stream{Dict: Dict{"Type": Name("X")}, Data: strings.NewReader("abc\nendstream\n")}
+	var buf bytes.Buffer
+	EncodeObject(&buf, Indirect{Name: "x", Generation: 2, Object: st})
+	got, err := DecodeObject(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ind := got.(Indirect)
+	if ind.Name != "1 2" || ind.Generation != 2 {
+		t.Fatal(ind)
+	}
+	gs := ind.Object.(Stream)
+	data, _ := io.ReadAll(gs.Data)
+	if string(data) != "abc\nendstream\n" || gs.Dict["Type"] != Name("X") || gs.Length != 14 || len(gs.Dict) != 1 {
+		t.Fatalf("%q %v", data, gs)
+	}
+
+	for _, bad := range []string{"[1 2", "<</A>>", "<<1 2>>", "1 0 obj 5", "foo", "<</Length 10>>stream\nabc", ")"} {
+		_, err := DecodeObject(strings.NewReader(bad))
+		if _, ok := err.(*SyntaxError); !ok {
+			t.Fatalf("%q: %v", bad, err)
+		}
+	}
+	if _, err := DecodeObject(strings.NewReader("  % c\n")); err != io.EOF {
+		t.Fatal(err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var d Document
+	var c Content
+	c.Rectangle(1, 2, 3, 4)
+	c.Fill()
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream()}, {MediaBox: Letter}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	d.SetInfo(Info{Title: "T"})
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Body) != len(d.Body) {
+		t.Fatal(len(p.Body))
+	}
+	var root Dict
+	for _, obj := range p.Body {
+		if obj.Name == string(p.Root) {
+			root = obj.Object.(Dict)
+		}
+	}
+	if root["Type"] != Name("Catalog") || p.Info == "" {
+		t.Fatal(root, p.Info)
+	}
+	// Re-encode and compare.
+	var again bytes.Buffer
+	if _, err := Encode(&again, p); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), data) {
+		t.Fatal("not identical")
+	}
+
+	// Incremental update with Prev, overriding object 1 and freeing 2.
+	upd := append([]byte(nil), data...)
+	off1 := len(upd)
+	upd = append(upd, "1 0 obj\n(new)\nendobj\n"...)
+	xref := len(upd)
+	upd = append(upd, "xref\n1 2\n"...)
+	upd = append(upd, []byte(pad10(off1)+" 00000 n \n0000000000 00001 f \n")...)
+	upd = append(upd, []byte("trailer\n<</Size 8 /Root "+strings.TrimSpace(string(p.Root))+" R /Prev "+itoa(bytes.LastIndex(data, []byte("\nxref\n"))+1)+">>\nstartxref\n"+itoa(xref)+"\n%%EOF\n")...)
+	q, err := Decode(bytes.NewReader(upd), int64(len(upd)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Body) != len(p.Body)-1 || q.Body[0].Object != LiteralString("new") || q.Body[1].Name == "2 0" {
+		t.Fatal(q.Body[:2])
+	}
+}
+
+func pad10(n int) string {
+	return fmt.Sprintf("%010d", n)
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
+
+// buildFile assembles a classic PDF from object bodies numbered from 1.
+func buildFile(objs ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<</Size %d /Root 1 0 R>>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+func streamData(t *testing.T, p *PDF, name string) string {
+	for _, obj := range p.Body {
+		if obj.Name == name {
+			st := obj.Object.(Stream)
+			data, _ := io.ReadAll(st.Data)
+			if int64(len(data)) != st.Length {
+				t.Fatal(len(data), st.Length)
+			}
+			return string(data)
+		}
+	}
+	t.Fatal("missing", name)
+	return ""
+}
+
+func TestDecodeIndirectLength(t *testing.T) {
+	objs := make([]string, 12)
+	objs[0] = "<</Type /Catalog>>"
+	for i := 1; i < 11; i++ {
+		objs[i] = "null"
+	}
+	objs[2] = "<</Length 12 0 R>>\nstream\nhello endstream world\nendstream"
+	objs[11] = "21"
+	data := buildFile(objs...)
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := streamData(t, p, "3 0"); got != "hello endstream world" {
+		t.Fatalf("%q", got)
+	}
+
+	// Wrong, missing, and unresolvable lengths fall back to scanning,
+	// skipping endstream keywords in the data.
+	for _, length := range []string{"/Length 5", "", "/Length 99 0 R", "/Length 1000"} {
+		objs[2] = "<<" + length + ">>\nstream\r\nbin endstream\x00 data\r\nendstream"
+		objs[11] = "5"
+		data := buildFile(objs...)
+		p, err := Decode(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(length, err)
+		}
+		if got := streamData(t, p, "3 0"); got != "bin endstream\x00 data" {
+			t.Fatalf("%v: %q", length, got)
+		}
+	}
+
+	obj, err := DecodeObject(bytes.NewReader([]byte("<<>>\nstream\nabc\nendstream")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := io.ReadAll(obj.(Stream).Data); string(d) != "abc" {
+		t.Fatalf("%q", d)
+	}
+	if _, err := DecodeObject(bytes.NewReader([]byte("<<>>\nstream\nabc\n"))); err == nil {
+		t.Fatal("no endstream")
+	}
+
+	// A document written with indirect lengths reads back.
+	var d Document
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: FlateBytes(bytes.Repeat([]byte("0 0 m 1 1 l S\n"), 100))}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.StreamIndirectLength = true
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	q, err := Decode(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, obj := range q.Body {
+		if st, ok := obj.Object.(Stream); ok && st.Dict["Filter"] == Name("FlateDecode") {
+			r, err := decodeStream(st)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(r)
+			found = bytes.Equal(b, bytes.Repeat([]byte("0 0 m 1 1 l S\n"), 100))
+		}
+	}
+	if !found {
+		t.Fatal("content")
+	}
+}
@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"maps"
+	"slices"
+)
+
+// Destinations maps names to explicit destinations, such as those
+// returned by DestXYZ. Links and outline items can then refer to a
+// destination by its name.
+type Destinations map[string]Array
+
+// DestXYZ returns a destination that displays the page that page
+// refers to, scrolled so that top is at the top of the window.
+func DestXYZ(page Reference, top float64) Array {
+	return Array{page, Name("XYZ"), Null{}, Real(top), Null{}}
+}
+
+// DestFit returns a destination that displays the whole of the page
+// that page refers to.
+func DestFit(page Reference) Array {
+	return Array{page, Name("Fit")}
+}
+
+// OpenAtPage returns an open action that displays the page that page
+// refers to, scrolled so that top is at the top of the window; see
+// Document.SetOpenAction.
+func OpenAtPage(page Reference, top float64) Object {
+	return DestXYZ(page, top)
+}
+
+// OpenActionURI returns an open action that opens uri; see
+// Document.SetOpenAction.
+func OpenActionURI(uri string) Object {
+	return uriAction(uri)
+}
+
+// SetOpenAction sets the destination to display or the action to
+// perform when the document is opened, such as one returned by
+// OpenAtPage. d.Root must already be set to the catalog, such as one
+// returned by AddPages.
+func (d *Document) SetOpenAction(action Object) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	catalog["OpenAction"] = action
+	return nil
+}
+
+// withNames returns a copy of d with name trees containing d.Dests
+// and any embedded files added to its catalog's name dictionary,
+// leaving d itself unmodified.
+func (d *Document) withNames() (*Document, error) {
+	i, err := d.catalogIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Document{PDF: d.PDF}
+	c.Body = slices.Clone(d.Body)
+
+	catalog := maps.Clone(d.Body[i].Object.(Dict))
+	nameDict, _ := catalog["Names"].(Dict)
+	nameDict = maps.Clone(nameDict)
+	if nameDict == nil {
+		nameDict = make(Dict, 2)
+	}
+	if len(d.Dests) > 0 {
+		nameDict["Dests"] = c.addTree("Names", nameEntries(d.Dests))
+	}
+	if len(d.files) > 0 {
+		nameDict["EmbeddedFiles"] = c.addTree("Names", nameEntries(d.files))
+	}
+	catalog["Names"] = nameDict
+	c.Body[i].Object = catalog
+
+	return c, nil
+}
+
+// nameEntries returns the entries of m sorted by name for a name tree.
+func nameEntries[T Object](m map[string]T) []treeEntry {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	entries := make([]treeEntry, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, treeEntry{key: LiteralString(name), value: m[name]})
+	}
+	return entries
+}
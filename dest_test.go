@@ -0,0 +1,62 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestDests(t *testing.T) {
+	var d Document
+	catalog, pages, err := d.AddPages([]Page{{MediaBox: A4, Annots: []Object{LinkNamed(Rectangle{0, 0, 10, 10}, "zeta")}}, {MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	d.SetOutline([]Outline{{Title: "x", DestName: "alpha"}})
+	d.Dests = Destinations{"zeta": DestXYZ(pages[1], 500), "alpha": DestFit(pages[0])}
+	n := len(d.Body)
+	c, err := d.withNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Body) != n || lookup(&d, catalog).(Dict)["Names"] != nil {
+		t.Fatal("mutated")
+	}
+	root := lookup(c, lookup(c, catalog).(Dict)["Names"].(Dict)["Dests"].(Reference)).(Dict)
+	leaf := lookup(c, root["Kids"].(Array)[0].(Reference)).(Dict)
+	names := leaf["Names"].(Array)
+	if names[0] != LiteralString("alpha") || names[2] != LiteralString("zeta") {
+		t.Fatal(names)
+	}
+	lim := leaf["Limits"].(Array)
+	if lim[0] != LiteralString("alpha") || lim[1] != LiteralString("zeta") || root["Limits"] != nil {
+		t.Fatal(lim)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+
+	// Big tree.
+	d.Dests = Destinations{}
+	for i := range 5000 {
+		d.Dests[fmt.Sprintf("d%05d", i)] = DestFit(pages[0])
+	}
+	c, _ = d.withNames()
+	root = lookup(c, lookup(c, catalog).(Dict)["Names"].(Dict)["Dests"].(Reference)).(Dict)
+	kids := root["Kids"].(Array)
+	if len(kids) != 2 {
+		t.Fatal(len(kids))
+	}
+	k1 := lookup(c, kids[1].(Reference)).(Dict)
+	if k1["Limits"].(Array)[0] != LiteralString("d04096") || k1["Limits"].(Array)[1] != LiteralString("d04999") {
+		t.Fatal(k1["Limits"])
+	}
+	out.Reset()
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+}
@@ -0,0 +1,273 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Document builds up a PDF one object at a time, naming and numbering
+// indirect objects automatically. The embedded PDF may be used to set
+// the Root and Info references and any encoding options.
+type Document struct {
+	PDF
+
+	// Dests are the document's named destinations. They are added to
+	// the catalog's name dictionary when the document is finished.
+	Dests Destinations
+
+	// Dedup causes Add to return a reference to an existing object
+	// instead of adding a new one if both would be encoded exactly the
+	// same way, including the data of any streams, so that things like
+	// fonts and images that are added more than once are only written
+	// once. Objects must not be modified after being added while Dedup
+	// is set. Objects whose identity matters, such as pages, are always
+	// added separately.
+	Dedup bool
+
+	// PDFA causes Finish to write a PDF/A-1b file for archiving, with
+	// identification in the metadata written by SetMetadata, which
+	// must be called after PDFA is set. Finish fails if the document
+	// is encrypted, uses any fonts that aren't embedded, such as the
+	// standard fonts, or lacks an OutputIntentPDFA output intent,
+	// added with AddOutputIntent, giving the meaning of its device
+	// colors. File identifiers are generated if ID isn't set.
+	PDFA bool
+
+	added map[[sha256.Size]byte]Reference
+
+	// metadata is the XMP metadata added by SetMetadata while PDFA was
+	// set.
+	metadata Reference
+
+	// files are the file specifications of embedded files, by name;
+	// see AttachFile.
+	files map[string]Reference
+}
+
+// Add appends obj to the document's body as an indirect object and
+// returns a reference to it. Objects are numbered in the order that
+// they are added, starting at 1.
+func (d *Document) Add(obj Object) Reference {
+	if !d.Dedup {
+		return d.addDistinct(obj)
+	}
+
+	// Stream data is read into memory so that it can be encoded once
+	// to be hashed and again when the document is finished.
+	fresh := rereadable(obj)
+	key, ok := objectKey(fresh())
+	if !ok {
+		return d.addDistinct(fresh())
+	}
+	if ref, ok := d.added[key]; ok {
+		return ref
+	}
+
+	ref := d.addDistinct(fresh())
+	if d.added == nil {
+		d.added = make(map[[sha256.Size]byte]Reference)
+	}
+	d.added[key] = ref
+	return ref
+}
+
+// addDistinct is like Add, but always adds obj as a new object, even
+// if Dedup is set. It is used for objects that are modified after
+// being added or that need to be distinct from any others.
+func (d *Document) addDistinct(obj Object) Reference {
+	name := "#" + strconv.Itoa(len(d.Body)+1)
+	d.Body = append(d.Body, Indirect{Name: name, Object: obj})
+	return Reference(name)
+}
+
+// rereadable returns a function that returns copies of obj that can
+// each be encoded separately, with the data of any streams that obj is
+// or has as content read into memory once up front.
+func rereadable(obj Object) func() Object {
+	readAll := func(st Stream) func() Stream {
+		var data io.Reader = bytes.NewReader(nil)
+		if st.Data != nil {
+			data = st.Data
+		}
+		if st.Length > 0 {
+			data = io.LimitReader(data, st.Length)
+		}
+		buf, err := io.ReadAll(data)
+		return func() Stream {
+			st.Data = bytes.NewReader(buf)
+			if err != nil {
+				st.Data = errReader{err}
+			}
+			return st
+		}
+	}
+
+	switch obj := obj.(type) {
+	case Stream:
+		st := readAll(obj)
+		return func() Object { return st() }
+	case FormXObject:
+		content := rereadable(obj.Content)
+		return func() Object {
+			obj.Content = content()
+			return obj
+		}
+	case TilingPattern:
+		content := rereadable(obj.Content)
+		return func() Object {
+			obj.Content = content()
+			return obj
+		}
+	default:
+		return func() Object { return obj }
+	}
+}
+
+// objectKey returns a hash of obj that identifies it by both how it is
+// encoded and the names of the objects that it refers to. It returns
+// false if obj can't be encoded on its own.
+func objectKey(obj Object) (key [sha256.Size]byte, ok bool) {
+	h := sha256.New()
+
+	// Encoding numbers references in the order that they appear, so
+	// their names are hashed separately.
+	err := references(obj, func(ref Reference) {
+		fmt.Fprintf(h, "%v:%v,", len(ref), ref)
+	})
+	if err != nil {
+		return key, false
+	}
+	h.Write([]byte{'\n'})
+
+	err = EncodeObject(h, obj)
+	if err != nil {
+		return key, false
+	}
+
+	h.Sum(key[:0])
+	return key, true
+}
+
+// Finish writes the document to w as a complete PDF file. It fails
+// without writing anything if Check does.
+func (d *Document) Finish(w io.Writer) (EncodeResult, error) {
+	err := d.Check()
+	if err != nil {
+		return EncodeResult{}, err
+	}
+
+	if d.PDFA {
+		err := d.checkPDFA()
+		if err != nil {
+			return EncodeResult{}, err
+		}
+		if d.ID[0] == nil {
+			c := *d
+			id := newFileID()
+			c.ID = [2][]byte{id, id}
+			d = &c
+		}
+	}
+
+	if (len(d.Dests) > 0) || (len(d.files) > 0) {
+		c, err := d.withNames()
+		if err != nil {
+			return EncodeResult{}, err
+		}
+		d = c
+	}
+
+	return Encode(w, &d.PDF)
+}
+
+// WriteTo is like PDF.WriteTo, but finishes the document first, as
+// Finish does.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	_, err := d.Finish(cw)
+	return cw.n, err
+}
+
+// Check makes sure that every Reference in the document, including
+// Root and Info, refers to an object in its body, that no object
+// contains itself other than by way of a Reference, which would make
+// it impossible to encode, and that pages are only rotated by
+// multiples of 90 degrees.
+func (d *Document) Check() error {
+	names := make(map[string]bool, len(d.Body))
+	for _, obj := range d.Body {
+		names[obj.Name] = true
+	}
+
+	// missing returns the first reference in obj to an object that
+	// isn't in the body.
+	missing := func(obj Object) (ref Reference, err error) {
+		err = references(obj, func(r Reference) {
+			if !names[string(r)] && (ref == "") {
+				ref = r
+			}
+		})
+		return ref, err
+	}
+
+	if !names[string(d.Root)] {
+		return fmt.Errorf("pdf: Root refers to missing object %q", d.Root)
+	}
+	if (d.Info != "") && !names[string(d.Info)] {
+		return fmt.Errorf("pdf: Info refers to missing object %q", d.Info)
+	}
+	for name, dest := range d.Dests {
+		ref, err := missing(dest)
+		if (err == nil) && (ref != "") {
+			return fmt.Errorf("pdf: destination %q refers to missing object %q", name, ref)
+		}
+	}
+
+	for _, obj := range d.Body {
+		ref, err := missing(obj.Object)
+		if err == errContainsItself {
+			return fmt.Errorf("pdf: object %q contains itself", obj.Name)
+		}
+		if ref != "" {
+			return fmt.Errorf("pdf: object %q refers to missing object %q", obj.Name, ref)
+		}
+
+		if dict, ok := obj.Object.(Dict); ok {
+			switch dict["Type"] {
+			case Name("Page"), Name("Pages"):
+				if rotate, ok := dict["Rotate"].(Integer); ok && (rotate%90 != 0) {
+					return fmt.Errorf("pdf: page %q is rotated by %v degrees, which isn't a multiple of 90", obj.Name, rotate)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// catalogIndex returns the index in d.Body of the catalog that d.Root
+// refers to.
+func (d *Document) catalogIndex() (int, error) {
+	for i, obj := range d.Body {
+		if obj.Name != string(d.Root) {
+			continue
+		}
+		if _, ok := obj.Object.(Dict); ok {
+			return i, nil
+		}
+		break
+	}
+	return -1, errors.New("pdf: Document.Root does not refer to a catalog")
+}
+
+// catalog returns the catalog that d.Root refers to.
+func (d *Document) catalog() (Dict, error) {
+	i, err := d.catalogIndex()
+	if err != nil {
+		return nil, err
+	}
+	return d.Body[i].Object.(Dict), nil
+}
@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	var d Document
+	pages := d.Add(Dict{"Type": Name("Pages")})
+	info := d.Add(Dict{"Title": LiteralString("t")})
+	d.Root = d.Add(Dict{"Type": Name("Catalog"), "Pages": pages})
+	d.Info = info
+	var buf bytes.Buffer
+	if _, err := d.Finish(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"1 0 obj\n<</Type /Pages", "2 0 obj\n<</Title", "3 0 obj\n<</Pages 1 0 R /Type /Catalog", "/Info 2 0 R /Root 3 0 R /Size 4"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var d Document
+	cat, _, err := d.AddPages([]Page{{MediaBox: A4, Resources: Dict{"Font": Dict{"F1": Reference("missing-font")}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = cat
+	var out bytes.Buffer
+	_, err = d.Finish(&out)
+	if err == nil || !strings.Contains(err.Error(), `"missing-font"`) {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Fatal("wrote output")
+	}
+
+	var d2 Document
+	self := Dict{}
+	self["Me"] = Array{self}
+	d2.Root = d2.Add(Dict{"Type": Name("Catalog")})
+	name := string(d2.Add(self))
+	err = d2.Check()
+	if err == nil || !strings.Contains(err.Error(), name) || !strings.Contains(err.Error(), "contains itself") {
+		t.Fatal(err)
+	}
+	a := Array{nil}
+	a[0] = a
+	d2.Body[1].Object = a
+	if err := d2.Check(); err == nil {
+		t.Fatal("array cycle")
+	}
+	// Shared but acyclic is fine.
+	shared := Dict{"X": Integer(1)}
+	d2.Body[1].Object = Array{shared, shared, Dict{"Y": shared}, Reference(d2.Root)}
+	if err := d2.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	var d3 Document
+	d3.Root = "nope"
+	if err := d3.Check(); err == nil {
+		t.Fatal("root")
+	}
+}
+
+func TestDedup(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7)
+	}
+	newImg := func() image.Image {
+		c := image.NewNRGBA(img.Rect)
+		copy(c.Pix, img.Pix)
+		return c
+	}
+	for _, dedup := range []bool{false, true} {
+		var d Document
+		d.Dedup = dedup
+		a, err := d.AddImage(newImg())
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := d.AddImage(newImg())
+		other := image.NewGray(image.Rect(0, 0, 2, 2))
+		other.Set(0, 0, color.Gray{Y: 9})
+		c, _ := d.AddImage(other)
+		if dedup != (a == b) || a == c {
+			t.Fatal(dedup, a, b, c)
+		}
+		f1 := d.Add(Dict{"X": a})
+		f2 := d.Add(Dict{"X": c})
+		if f1 == f2 {
+			t.Fatal("different refs merged")
+		}
+		var content Content
+		content.DrawXObject("I0")
+		content.DrawXObject("I1")
+		d.Root, _, err = d.AddPages([]Page{
+			{MediaBox: A4, Contents: content.Stream(), Resources: Dict{"XObject": Dict{"I0": a, "I1": b}}},
+			{MediaBox: A4},
+			{MediaBox: A4},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if _, err := d.Finish(&out); err != nil {
+			t.Fatal(err)
+		}
+		n := bytes.Count(out.Bytes(), []byte("/Subtype /Image"))
+		if (dedup && n != 3) || (!dedup && n != 5) {
+			t.Fatal(dedup, n)
+		}
+		if bytes.Count(out.Bytes(), []byte("/Type /Page ")) != 3 {
+			t.Fatal("pages merged")
+		}
+		validate(t, out.Bytes())
+	}
+}
@@ -0,0 +1,418 @@
+package pdf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"slices"
+)
+
+// Encryption describes how to encrypt a document using the standard
+// security handler. Every string and stream in the document is
+// encrypted, with the exception of the cross-reference stream, if
+// there is one.
+type Encryption struct {
+	// UserPassword is the password needed to open the document. If it
+	// is empty, anyone can open the document, but readers are expected
+	// to restrict what they allow according to Permissions.
+	//
+	// With 256-bit AES, passwords may contain any Unicode characters
+	// and are used in UTF-8 form, though they are not normalized, so
+	// they should already be in NFKC form. Otherwise, they are used as
+	// is and should be limited to ASCII.
+	UserPassword string
+
+	// OwnerPassword is the password that grants full access to the
+	// document. If it is empty, UserPassword is used.
+	OwnerPassword string
+
+	// Permissions determines what may be done with the document when it
+	// is opened with the user password.
+	Permissions Permissions
+
+	// KeyLength is the length of the encryption key in bits, which
+	// must be either 40 or 128, or 256 with AES. If it is zero, 128 is
+	// used.
+	KeyLength int
+
+	// AES causes strings and streams to be encrypted with AES rather
+	// than RC4. The key must be 128 or 256 bits long.
+	AES bool
+}
+
+// Permissions is a set of flags that determine what may be done with
+// an encrypted document by someone without its owner password.
+type Permissions uint32
+
+// Permissions flags. Their values are the bits of the P entry of the
+// encryption dictionary.
+const (
+	// PermitPrint allows printing, though possibly only at low quality
+	// without PermitPrintHighRes.
+	PermitPrint Permissions = 1 << 2
+
+	// PermitModify allows changing the document in ways not covered by
+	// the other flags.
+	PermitModify Permissions = 1 << 3
+
+	// PermitCopy allows copying or otherwise extracting text and
+	// graphics.
+	PermitCopy Permissions = 1 << 4
+
+	// PermitAnnotate allows adding or modifying annotations and, with
+	// PermitModify, form fields.
+	PermitAnnotate Permissions = 1 << 5
+
+	// PermitFillForms allows filling in existing form fields, even
+	// without PermitAnnotate.
+	PermitFillForms Permissions = 1 << 8
+
+	// PermitExtract allows extracting text and graphics for the sake of
+	// accessibility.
+	PermitExtract Permissions = 1 << 9
+
+	// PermitAssemble allows inserting, rotating, and deleting pages and
+	// creating bookmarks and thumbnails, even without PermitModify.
+	PermitAssemble Permissions = 1 << 10
+
+	// PermitPrintHighRes allows printing at full quality.
+	PermitPrintHighRes Permissions = 1 << 11
+
+	// PermitAll allows everything.
+	PermitAll = PermitPrint | PermitModify | PermitCopy | PermitAnnotate |
+		PermitFillForms | PermitExtract | PermitAssemble | PermitPrintHighRes
+)
+
+// Value returns the value of the P entry of the encryption dictionary
+// for p. Any bits not defined by the flags above are cleared, except
+// for those that the specification reserves and requires to be set,
+// which makes the result negative.
+func (p Permissions) Value() int32 {
+	const reserved = 0xFFFFF0C0
+	return int32(uint32(p&PermitAll) | reserved)
+}
+
+// passwordPadding is used to pad passwords to 32 bytes.
+var passwordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41,
+	0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80,
+	0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// padPassword truncates or pads password to exactly 32 bytes.
+func padPassword(password string) []byte {
+	buf := []byte(password)[:min(len(password), 32)]
+	return append(buf, passwordPadding[:32-len(buf)]...)
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	c, err := rc4.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+// rc4Rounds encrypts data with key and then with nineteen variations
+// of key, as revision 3 of the standard security handler does.
+func rc4Rounds(key, data []byte) []byte {
+	data = rc4Crypt(key, data)
+	k := make([]byte, len(key))
+	for i := byte(1); i <= 19; i++ {
+		for j := range key {
+			k[j] = key[j] ^ i
+		}
+		data = rc4Crypt(k, data)
+	}
+	return data
+}
+
+// securityHandler holds the state needed to encrypt a document.
+type securityHandler struct {
+	key []byte
+	v   int
+
+	// dict is the encryption dictionary, which is written as the
+	// object numbered num.
+	dict Dict
+	num  int
+}
+
+// handler creates a securityHandler for a document with the first
+// file identifier id.
+func (e *Encryption) handler(id []byte) (*securityHandler, error) {
+	bits := e.KeyLength
+	if bits == 0 {
+		bits = 128
+	}
+	var v, r int
+	switch {
+	case e.AES && (bits == 128):
+		v, r = 4, 4
+	case e.AES && (bits == 256):
+		return e.handlerAES256()
+	case e.AES:
+		return nil, fmt.Errorf("pdf: unsupported AES key length: %v", bits)
+	case bits == 40:
+		v, r = 1, 2
+	case bits == 128:
+		v, r = 2, 3
+	default:
+		return nil, fmt.Errorf("pdf: unsupported encryption key length: %v", bits)
+	}
+	n := bits / 8
+
+	owner := e.OwnerPassword
+	if owner == "" {
+		owner = e.UserPassword
+	}
+
+	// The owner entry is the padded user password encrypted with a key
+	// derived from the owner password.
+	sum := md5.Sum(padPassword(owner))
+	if r >= 3 {
+		for range 50 {
+			sum = md5.Sum(sum[:])
+		}
+	}
+	var o []byte
+	if r == 2 {
+		o = rc4Crypt(sum[:n], padPassword(e.UserPassword))
+	} else {
+		o = rc4Rounds(sum[:n], padPassword(e.UserPassword))
+	}
+
+	h := md5.New()
+	h.Write(padPassword(e.UserPassword))
+	h.Write(o)
+	p := uint32(e.Permissions.Value())
+	h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	h.Write(id)
+	key := h.Sum(nil)
+	if r >= 3 {
+		for range 50 {
+			sum := md5.Sum(key[:n])
+			key = sum[:]
+		}
+	}
+	key = key[:n]
+
+	// The user entry lets readers check the user password by deriving
+	// the key from it and decrypting a known value.
+	var u []byte
+	if r == 2 {
+		u = rc4Crypt(key, passwordPadding)
+	} else {
+		h := md5.New()
+		h.Write(passwordPadding)
+		h.Write(id)
+		u = rc4Rounds(key, h.Sum(nil))
+		u = append(u, make([]byte, 16)...)
+	}
+
+	dict := Dict{
+		"Filter": Name("Standard"),
+		"V":      Integer(v),
+		"R":      Integer(r),
+		"O":      HexString(o),
+		"U":      HexString(u),
+		"P":      Integer(e.Permissions.Value()),
+	}
+	if v >= 2 {
+		dict["Length"] = Integer(bits)
+	}
+	if v == 4 {
+		// Version 4 routes everything through crypt filters, of which a
+		// single one is used for both strings and streams.
+		dict["CF"] = Dict{
+			"StdCF": Dict{
+				"CFM":       Name("AESV2"),
+				"AuthEvent": Name("DocOpen"),
+				"Length":    Integer(n),
+			},
+		}
+		dict["StmF"] = Name("StdCF")
+		dict["StrF"] = Name("StdCF")
+	}
+	return &securityHandler{key: key, v: v, dict: dict}, nil
+}
+
+// handlerAES256 creates a securityHandler for revision 6, which uses a
+// random key that is itself encrypted with keys derived from each of
+// the passwords.
+func (e *Encryption) handlerAES256() (*securityHandler, error) {
+	user := []byte(e.UserPassword)[:min(len(e.UserPassword), 127)]
+	owner := user
+	if e.OwnerPassword != "" {
+		owner = []byte(e.OwnerPassword)[:min(len(e.OwnerPassword), 127)]
+	}
+
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	// Each password gets a validation salt, for checking it, and a key
+	// salt, for decrypting the key with it.
+	salts := make([]byte, 32)
+	rand.Read(salts)
+
+	u := append(hashPassword(user, salts[0:8], nil), salts[0:16]...)
+	ue := aesCryptBlocks(hashPassword(user, salts[8:16], nil), key)
+	o := append(hashPassword(owner, salts[16:24], u), salts[16:32]...)
+	oe := aesCryptBlocks(hashPassword(owner, salts[24:32], u), key)
+
+	// Perms lets readers check that P hasn't been tampered with.
+	p := uint32(e.Permissions.Value())
+	perms := []byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24), 0xFF, 0xFF, 0xFF, 0xFF, 'T', 'a', 'd', 'b', 0, 0, 0, 0}
+	rand.Read(perms[12:])
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	c.Encrypt(perms, perms)
+
+	dict := Dict{
+		"Filter": Name("Standard"),
+		"V":      Integer(5),
+		"R":      Integer(6),
+		"Length": Integer(256),
+		"O":      HexString(o),
+		"U":      HexString(u),
+		"OE":     HexString(oe),
+		"UE":     HexString(ue),
+		"P":      Integer(e.Permissions.Value()),
+		"Perms":  HexString(perms),
+		"CF": Dict{
+			"StdCF": Dict{
+				"CFM":       Name("AESV3"),
+				"AuthEvent": Name("DocOpen"),
+				"Length":    Integer(32),
+			},
+		},
+		"StmF": Name("StdCF"),
+		"StrF": Name("StdCF"),
+	}
+	return &securityHandler{key: key, v: 5, dict: dict}, nil
+}
+
+// hashPassword computes the hash of a password used by revision 6 of
+// the standard security handler, given a salt and, for the owner
+// password, the user entry of the encryption dictionary.
+func hashPassword(password, salt, u []byte) []byte {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(u)
+	k := h.Sum(nil)
+
+	var k1 []byte
+	for round := 0; ; round++ {
+		k1 = k1[:0]
+		for range 64 {
+			k1 = append(k1, password...)
+			k1 = append(k1, k...)
+			k1 = append(k1, u...)
+		}
+
+		c, err := aes.NewCipher(k[:16])
+		if err != nil {
+			panic(err)
+		}
+		cipher.NewCBCEncrypter(c, k[16:32]).CryptBlocks(k1, k1)
+
+		// The first 16 bytes of the result, taken as a number modulo 3,
+		// pick the next hash function. As 256 is 1 modulo 3, that's the
+		// same as the sum of the bytes modulo 3.
+		var mod int
+		for _, c := range k1[:16] {
+			mod += int(c)
+		}
+		var sum []byte
+		switch mod % 3 {
+		case 0:
+			s := sha256.Sum256(k1)
+			sum = s[:]
+		case 1:
+			s := sha512.Sum384(k1)
+			sum = s[:]
+		case 2:
+			s := sha512.Sum512(k1)
+			sum = s[:]
+		}
+		k = sum
+
+		if (round >= 63) && (int(k1[len(k1)-1]) <= round-31) {
+			return k[:32]
+		}
+	}
+}
+
+// aesCryptBlocks encrypts data, which must be a multiple of the block
+// size in length, with AES in CBC mode using an initialization vector
+// of zeroes.
+func aesCryptBlocks(key, data []byte) []byte {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(c, make([]byte, aes.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+// encrypt encrypts data belonging to the object numbered objNum with
+// the generation gen.
+func (h *securityHandler) encrypt(objNum, gen int, data []byte) []byte {
+	if h.v == 5 {
+		// Everything is encrypted with the same key.
+		return aesCrypt(h.key, data)
+	}
+
+	key := append(slices.Clip(h.key), byte(objNum), byte(objNum>>8), byte(objNum>>16), byte(gen), byte(gen>>8))
+	if h.v == 4 {
+		key = append(key, "sAlT"...)
+	}
+	sum := md5.Sum(key)
+	key = sum[:min(len(h.key)+5, 16)]
+
+	if h.v == 4 {
+		return aesCrypt(key, data)
+	}
+	return rc4Crypt(key, data)
+}
+
+// aesCrypt encrypts data with AES in CBC mode, using a random
+// initialization vector that is prepended to the result. The data is
+// padded as described in RFC 8018.
+func aesCrypt(key, data []byte) []byte {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	out := make([]byte, aes.BlockSize+len(data)+pad)
+	iv := out[:aes.BlockSize]
+	rand.Read(iv)
+	copy(out[aes.BlockSize:], data)
+	for i := len(out) - pad; i < len(out); i++ {
+		out[i] = byte(pad)
+	}
+
+	cipher.NewCBCEncrypter(c, iv).CryptBlocks(out[aes.BlockSize:], out[aes.BlockSize:])
+	return out
+}
+
+// newFileID returns a random file identifier.
+func newFileID() []byte {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return id
+}
@@ -0,0 +1,351 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func encryptedDoc(t *testing.T, enc *Encryption, mod func(*Document)) []byte {
+	var d Document
+	var c Content
+	c.BeginText()
+	c.SetFont("F1", 12)
+	c.ShowText("Secret text")
+	c.EndText()
+	cat, _, err := d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"Font": Dict{"F1": d.Add(Helvetica.Dict())}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = cat
+	d.SetInfo(Info{Title: "Hidden title"})
+	d.Encryption = enc
+	if mod != nil {
+		mod(&d)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestEncryptRC4(t *testing.T) {
+	for _, bits := range []int{40, 128} {
+		for _, mode := range []string{"plain", "xrefstm", "objstm", "lin"} {
+			data := encryptedDoc(t, &Encryption{UserPassword: "user", OwnerPassword: "owner", Permissions: PermitAll, KeyLength: bits}, func(d *Document) {
+				switch mode {
+				case "xrefstm":
+					d.XrefStream = true
+				case "objstm":
+					d.ObjectStreams = true
+				case "lin":
+					d.Linearize = true
+				}
+			})
+			if bytes.Contains(data, []byte("Secret")) || bytes.Contains(data, []byte("Hidden")) {
+				t.Fatal("plaintext leaked")
+			}
+			conf := model.NewDefaultConfiguration()
+			conf.ValidationMode = model.ValidationRelaxed
+			conf.UserPW = "user"
+			if err := api.Validate(bytes.NewReader(data), conf); err != nil {
+				t.Fatalf("%v %v: %v", bits, mode, err)
+			}
+			conf = model.NewDefaultConfiguration()
+			conf.OwnerPW = "owner"
+			if err := api.Validate(bytes.NewReader(data), conf); err != nil {
+				t.Fatalf("owner %v %v: %v", bits, mode, err)
+			}
+			conf = model.NewDefaultConfiguration()
+			conf.UserPW = "wrong"
+			conf.OwnerPW = "nope"
+			if err := api.Validate(bytes.NewReader(data), conf); err == nil {
+				t.Fatalf("wrong password accepted")
+			}
+
+			if mode != "plain" {
+				continue
+			}
+			// Manual decryption of the content stream.
+			p, err := Decode(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dd := newDecoder(bytes.NewReader(data), int64(len(data)))
+			tr, _ := dd.readXrefs()
+			var ed Dict
+			for _, obj := range p.Body {
+				if obj.Name == string(tr["Encrypt"].(Reference)) {
+					ed = obj.Object.(Dict)
+				}
+			}
+			o := stringBytes(ed["O"])
+			pv := uint32(ed["P"].(Integer))
+			n := bits / 8
+			h := md5.New()
+			h.Write(padPassword("user"))
+			h.Write(o)
+			h.Write([]byte{byte(pv), byte(pv >> 8), byte(pv >> 16), byte(pv >> 24)})
+			h.Write(p.ID[0])
+			key := h.Sum(nil)
+			if bits == 128 {
+				for i := 0; i < 50; i++ {
+					s := md5.Sum(key[:n])
+					key = s[:]
+				}
+			}
+			key = key[:n]
+			found := false
+			for _, obj := range p.Body {
+				st, ok := obj.Object.(Stream)
+				if !ok {
+					continue
+				}
+				f := strings.Fields(obj.Name)
+				num, gen := atoi(f[0]), atoi(f[1])
+				k := append(append([]byte{}, key...), byte(num), byte(num>>8), byte(num>>16), byte(gen), byte(gen>>8))
+				s := md5.Sum(k)
+				c, _ := rc4.NewCipher(s[:min(n+5, 16)])
+				var buf bytes.Buffer
+				buf.ReadFrom(st.Data)
+				out := make([]byte, buf.Len())
+				c.XORKeyStream(out, buf.Bytes())
+				if bytes.Contains(out, []byte("(Secret text) Tj")) {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatal("stream not decrypted")
+			}
+		}
+	}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func TestEncryptAESV2(t *testing.T) {
+	for _, mode := range []string{"plain", "objstm", "lin"} {
+		data := encryptedDoc(t, &Encryption{UserPassword: "user", OwnerPassword: "owner", Permissions: PermitAll, AES: true}, func(d *Document) {
+			switch mode {
+			case "objstm":
+				d.ObjectStreams = true
+			case "lin":
+				d.Linearize = true
+			}
+		})
+		if !bytes.Contains(data, []byte("/CFM /AESV2")) {
+			t.Fatal("no AESV2")
+		}
+		conf := model.NewDefaultConfiguration()
+		conf.ValidationMode = model.ValidationRelaxed
+		conf.UserPW = "user"
+		if err := api.Validate(bytes.NewReader(data), conf); err != nil {
+			t.Fatalf("%v: %v", mode, err)
+		}
+		if mode != "plain" {
+			continue
+		}
+		p, err := Decode(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ed Dict
+		for _, obj := range p.Body {
+			if d, ok := obj.Object.(Dict); ok && d["Filter"] == Name("Standard") {
+				ed = d
+			}
+		}
+		if ed["V"] != Integer(4) || ed["R"] != Integer(4) {
+			t.Fatal(ed)
+		}
+		o := stringBytes(ed["O"])
+		pv := uint32(ed["P"].(Integer))
+		h := md5.New()
+		h.Write(padPassword("user"))
+		h.Write(o)
+		h.Write([]byte{byte(pv), byte(pv >> 8), byte(pv >> 16), byte(pv >> 24)})
+		h.Write(p.ID[0])
+		key := h.Sum(nil)
+		for i := 0; i < 50; i++ {
+			s := md5.Sum(key[:16])
+			key = s[:]
+		}
+		found := false
+		for _, obj := range p.Body {
+			st, ok := obj.Object.(Stream)
+			if !ok {
+				continue
+			}
+			f := strings.Fields(obj.Name)
+			num, gen := atoi(f[0]), atoi(f[1])
+			k := append(append([]byte{}, key...), byte(num), byte(num>>8), byte(num>>16), byte(gen), byte(gen>>8), 's', 'A', 'l', 'T')
+			s := md5.Sum(k)
+			var buf bytes.Buffer
+			buf.ReadFrom(st.Data)
+			ct := buf.Bytes()
+			if len(ct)%16 != 0 || len(ct) < 32 {
+				t.Fatal("bad length", len(ct))
+			}
+			c, _ := aes.NewCipher(s[:])
+			out := make([]byte, len(ct)-16)
+			cipher.NewCBCDecrypter(c, ct[:16]).CryptBlocks(out, ct[16:])
+			pad := int(out[len(out)-1])
+			if pad < 1 || pad > 16 {
+				t.Fatal("bad pad")
+			}
+			out = out[:len(out)-pad]
+			if bytes.Contains(out, []byte("(Secret text) Tj")) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("not decrypted")
+		}
+		// IVs differ between two encryptions.
+		if bytes.Equal(aesCrypt(make([]byte, 16), []byte("x")), aesCrypt(make([]byte, 16), []byte("x"))) {
+			t.Fatal("IV not random")
+		}
+	}
+	if _, err := (&Encryption{AES: true, KeyLength: 40}).handler(nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEncryptAES256(t *testing.T) {
+	const upw, opw = "pässwörd", "Øwner"
+	for _, mode := range []string{"plain", "objstm", "lin"} {
+		data := encryptedDoc(t, &Encryption{UserPassword: upw, OwnerPassword: opw, Permissions: PermitPrint | PermitCopy, AES: true, KeyLength: 256}, func(d *Document) {
+			switch mode {
+			case "objstm":
+				d.ObjectStreams = true
+			case "lin":
+				d.Linearize = true
+			}
+		})
+		for _, pw := range [][2]string{{upw, ""}, {"", opw}} {
+			conf := model.NewDefaultConfiguration()
+			conf.ValidationMode = model.ValidationRelaxed
+			conf.UserPW, conf.OwnerPW = pw[0], pw[1]
+			if err := api.Validate(bytes.NewReader(data), conf); err != nil {
+				t.Fatalf("%v %q: %v", mode, pw, err)
+			}
+		}
+		conf := model.NewDefaultConfiguration()
+		conf.UserPW, conf.OwnerPW = "nope", "nope"
+		if err := api.Validate(bytes.NewReader(data), conf); err == nil {
+			t.Fatal("wrong password accepted")
+		}
+		if mode != "plain" {
+			continue
+		}
+
+		p, err := Decode(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ed Dict
+		for _, obj := range p.Body {
+			if d, ok := obj.Object.(Dict); ok && d["Filter"] == Name("Standard") {
+				ed = d
+			}
+		}
+		u := stringBytes(ed["U"])
+		if !bytes.Equal(hashPassword([]byte(upw), u[32:40], nil), u[:32]) {
+			t.Fatal("U does not validate")
+		}
+		ik := hashPassword([]byte(upw), u[40:48], nil)
+		c, _ := aes.NewCipher(ik)
+		ue := stringBytes(ed["UE"])
+		key := make([]byte, 32)
+		cipher.NewCBCDecrypter(c, make([]byte, 16)).CryptBlocks(key, ue)
+		kc, _ := aes.NewCipher(key)
+		perms := make([]byte, 16)
+		kc.Decrypt(perms, stringBytes(ed["Perms"]))
+		if string(perms[9:12]) != "adb" || perms[8] != 'T' || int32(uint32(perms[0])|uint32(perms[1])<<8|uint32(perms[2])<<16|uint32(perms[3])<<24) != -3884 {
+			t.Fatalf("perms %x", perms)
+		}
+		found := false
+		for _, obj := range p.Body {
+			st, ok := obj.Object.(Stream)
+			if !ok {
+				continue
+			}
+			var buf bytes.Buffer
+			buf.ReadFrom(st.Data)
+			ct := buf.Bytes()
+			out := make([]byte, len(ct)-16)
+			cipher.NewCBCDecrypter(kc, ct[:16]).CryptBlocks(out, ct[16:])
+			if bytes.Contains(out, []byte("(Secret text) Tj")) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("not decrypted")
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	tests := []struct {
+		p Permissions
+		v int32
+	}{
+		{0, -3904},
+		{PermitPrint | PermitCopy, -3884},
+		{PermitAll, -4},
+		{PermitPrint | PermitPrintHighRes | PermitFillForms, -1596},
+		{0xFFFFFFFF, -4},
+	}
+	for _, tt := range tests {
+		if v := tt.p.Value(); v != tt.v {
+			t.Errorf("%#x: %v, want %v", uint32(tt.p), v, tt.v)
+		}
+	}
+}
+
+func TestEncryptHook(t *testing.T) {
+	var out bytes.Buffer
+	s := newEncodeState(&out)
+	xor := func(num, gen int, data []byte) []byte {
+		b := make([]byte, len(data))
+		for i, c := range data {
+			b[i] = c ^ byte(num)
+		}
+		return b
+	}
+	s.encrypt = xor
+	s.crypt = &securityHandler{num: 2}
+	objs := []Indirect{
+		{Name: "a", Object: Array{LiteralString("AB"), HexString("C"), Stream{Data: strings.NewReader("DE")}}},
+		{Name: "b", Object: Dict{"S": LiteralString("AB")}},
+	}
+	for _, obj := range objs {
+		if err := obj.encode(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.trailer(&PDF{Root: "a", ID: [2][]byte{[]byte("AB"), []byte("AB")}}, 3).encode(s); err != nil {
+		t.Fatal(err)
+	}
+	s.Flush()
+	got := out.String()
+	// Object 1 is XORed with 1, object 2 is the Encrypt dict and is
+	// left alone, and so is the ID in the trailer.
+	for _, want := range []string{"[<4043> <42> <</Length 2 >>\nstream\nED\nendstream]", "<</S (AB) >>", "<4142>"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"time"
+)
+
+// Equal returns true if a and b represent the same PDF object. Arrays
+// are equal if their elements are, in order, and Dicts if they have the
+// same keys with equal values. As in PDF itself, Integers and Reals are
+// compared by value, strings by their bytes, no matter how they're
+// encoded, and nil is the same as Null. A Dict entry whose value is
+// Null is the same as no entry at all.
+//
+// Streams are equal if their dictionaries, filters, and data are. The
+// data is read from the start if Data can be read at an offset, as it
+// can be for streams returned by Decode, and is used up otherwise.
+// Other objects are equal if they have the same type and contents.
+func Equal(a, b Object) bool {
+	if a == nil {
+		a = Null{}
+	}
+	if b == nil {
+		b = Null{}
+	}
+
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && (x == y)
+	}
+	if isString(a) {
+		return isString(b) && bytes.Equal(stringBytes(a), stringBytes(b))
+	}
+
+	switch a := a.(type) {
+	case Array:
+		b, ok := b.(Array)
+		if !ok || (len(a) != len(b)) {
+			return false
+		}
+		for i := range a {
+			if !Equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+
+	case Dict:
+		b, ok := b.(Dict)
+		if !ok {
+			return false
+		}
+		// A missing key looks up as nil, which is the same as Null.
+		for k, v := range a {
+			if !Equal(v, b[k]) {
+				return false
+			}
+		}
+		for k, w := range b {
+			if _, ok := a[k]; !ok && !Equal(w, nil) {
+				return false
+			}
+		}
+		return true
+
+	case Stream:
+		b, ok := b.(Stream)
+		if !ok || !Equal(a.Dict, b.Dict) {
+			return false
+		}
+		if ((len(a.Filters) > 0) || (len(b.Filters) > 0)) && !reflect.DeepEqual(a.Filters, b.Filters) {
+			return false
+		}
+		x, err := rawStreamData(a)
+		if err != nil {
+			return false
+		}
+		y, err := rawStreamData(b)
+		return (err == nil) && bytes.Equal(x, y)
+
+	case Indirect:
+		b, ok := b.(Indirect)
+		return ok && (a.Name == b.Name) && (a.Generation == b.Generation) && Equal(a.Object, b.Object)
+
+	case Date:
+		b, ok := b.(Date)
+		return ok && time.Time(a).Equal(time.Time(b))
+
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// isString returns true if obj is a string of either form.
+func isString(obj Object) bool {
+	switch obj.(type) {
+	case LiteralString, HexString:
+		return true
+	default:
+		return false
+	}
+}
+
+// rawStreamData returns the data of st as it is, without decoding it.
+func rawStreamData(st Stream) ([]byte, error) {
+	if st.Data == nil {
+		return nil, nil
+	}
+	if r, ok := st.Data.(io.ReaderAt); ok && (st.Length > 0) {
+		return io.ReadAll(io.NewSectionReader(r, 0, st.Length))
+	}
+	if st.Length > 0 {
+		return io.ReadAll(io.LimitReader(st.Data, st.Length))
+	}
+	return io.ReadAll(st.Data)
+}
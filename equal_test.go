@@ -0,0 +1,82 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEqual(t *testing.T) {
+	a := Dict{"A": Array{Integer(1), Real(2.5), Dict{"X": Name("x"), "Y": Reference("r")}}, "B": LiteralString("hi"), "C": nil}
+	b := Dict{"C": Null{}, "B": HexString("hi"), "A": Array{Real(1), Real(2.5), Dict{"Y": Reference("r"), "X": Name("x")}}}
+	if !Equal(a, b) || !Equal(b, a) {
+		t.Fatal("not equal")
+	}
+	b["A"].(Array)[2].(Dict)["X"] = Name("z")
+	if Equal(a, b) {
+		t.Fatal("equal")
+	}
+	if Equal(Array{Integer(1)}, Array{Integer(1), Integer(2)}) || Equal(Name("a"), LiteralString("a")) || Equal(Dict{"A": Null{}}, Dict{"A": Integer(0)}) {
+		t.Fatal("equal")
+	}
+	for _, pair := range [][2]Dict{{{"A": Null{}}, {}}, {{"A": nil}, {"B": Null{}}}, {{"A": Integer(1), "B": Null{}}, {"A": Real(1)}}} {
+		if !Equal(pair[0], pair[1]) || !Equal(pair[1], pair[0]) {
+			t.Fatal(pair)
+		}
+	}
+	if Equal(Dict{"A": Integer(1), "B": Null{}}, Dict{"B": Integer(1)}) || Equal(Dict{}, Dict{"A": Integer(0)}) {
+		t.Fatal("null keys")
+	}
+	if !Equal(LiteralString(""), HexString(nil)) || Equal(Integer(0), Null{}) {
+		t.Fatal("empty")
+	}
+
+	s1 := Stream{Dict: Dict{"Type": Name("X")}, Data: bytes.NewReader([]byte("abc"))}
+	s2 := Stream{Dict: Dict{"Type": Name("X")}, Data: strings.NewReader("abcdef"), Length: 3}
+	if !Equal(s1, s2) {
+		t.Fatal("streams")
+	}
+	s3 := Stream{Dict: Dict{"Type": Name("X")}, Data: strings.NewReader("abd")}
+	s1.Data = bytes.NewReader([]byte("abc"))
+	if Equal(s1, s3) {
+		t.Fatal("unequal streams")
+	}
+	if Equal(Stream{Filters: []Filter{FlateFilter{}}}, Stream{}) {
+		t.Fatal("filters")
+	}
+	now := time.Now()
+	if !Equal(Date(now), Date(now.UTC())) || !Equal(Indirect{Name: "a", Object: Integer(1)}, Indirect{Name: "a", Object: Real(1)}) {
+		t.Fatal("date/indirect")
+	}
+
+	// Round trip.
+	var d Document
+	d.Add(Dict{"S": Flate(strings.NewReader("data")), "N": Real(3)})
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := EncodeBytes(&d.PDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Decoded stream data is read at an offset, so comparing twice
+	// works.
+	for range 2 {
+		for i := range p.Body {
+			if !Equal(p.Body[i], q.Body[i]) {
+				t.Fatal(i)
+			}
+		}
+	}
+}
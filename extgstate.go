@@ -0,0 +1,44 @@
+package pdf
+
+// BlendMode is a blend mode, which determines how colors being painted
+// are combined with those already on the page.
+type BlendMode Name
+
+// Standard blend modes.
+const (
+	BlendNormal     BlendMode = "Normal"
+	BlendMultiply   BlendMode = "Multiply"
+	BlendScreen     BlendMode = "Screen"
+	BlendOverlay    BlendMode = "Overlay"
+	BlendDarken     BlendMode = "Darken"
+	BlendLighten    BlendMode = "Lighten"
+	BlendColorDodge BlendMode = "ColorDodge"
+	BlendColorBurn  BlendMode = "ColorBurn"
+	BlendHardLight  BlendMode = "HardLight"
+	BlendSoftLight  BlendMode = "SoftLight"
+	BlendDifference BlendMode = "Difference"
+	BlendExclusion  BlendMode = "Exclusion"
+	BlendHue        BlendMode = "Hue"
+	BlendSaturation BlendMode = "Saturation"
+	BlendColor      BlendMode = "Color"
+	BlendLuminosity BlendMode = "Luminosity"
+)
+
+// ExtGState returns a graphics state parameter dictionary that sets
+// the alpha constants for filling and stroking, from 0 for fully
+// transparent to 1 for opaque, and the blend mode. If mode is empty,
+// the blend mode is left alone.
+//
+// Graphics state parameter dictionaries are named in a resource
+// dictionary's ExtGState entry and applied with Content.SetExtGState.
+func ExtGState(fill, stroke float64, mode BlendMode) Dict {
+	dict := Dict{
+		"Type": Name("ExtGState"),
+		"ca":   Real(fill),
+		"CA":   Real(stroke),
+	}
+	if mode != "" {
+		dict["BM"] = Name(mode)
+	}
+	return dict
+}
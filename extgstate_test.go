@@ -0,0 +1,38 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExtGState(t *testing.T) {
+	var c Content
+	c.Save()
+	c.SetExtGState("GS0")
+	c.SetRGBFill(1, 0, 0)
+	c.Rectangle(0, 0, 100, 100)
+	c.Fill()
+	c.Restore()
+	if !strings.Contains(string(c.Bytes()), "/GS0 gs\n") {
+		t.Fatal(string(c.Bytes()))
+	}
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"ExtGState": Dict{
+		"GS0": ExtGState(0.5, 1, ""),
+		"GS1": ExtGState(0.25, 0.75, BlendMultiply),
+	}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if !bytes.Contains(data, []byte("/GS0 <</CA 1 /Type /ExtGState /ca 0.5 >>")) || !bytes.Contains(data, []byte("<</BM /Multiply /CA 0.75 /Type /ExtGState /ca 0.25 >>")) {
+		t.Fatal(string(data))
+	}
+	validate(t, data)
+}
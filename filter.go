@@ -0,0 +1,281 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A Filter encodes stream data in a way that a PDF reader can decode.
+type Filter interface {
+	// Name returns the name of the filter, such as FlateDecode.
+	Name() Name
+
+	// Encode returns a writer that encodes data written to it and
+	// writes the result to w. Closing the writer must flush any
+	// remaining data, but must not close w.
+	Encode(w io.Writer) (io.WriteCloser, error)
+}
+
+// A Decoder is a Filter that can also decode data that it has
+// encoded.
+type Decoder interface {
+	Filter
+
+	// Decode returns a reader that decodes the data read from r.
+	Decode(r io.Reader) (io.Reader, error)
+}
+
+// A ParamsFilter is a Filter that may need decode parameters.
+type ParamsFilter interface {
+	Filter
+
+	// Params returns the decode parameters for the filter, or nil if
+	// it doesn't need any.
+	Params() Dict
+}
+
+// applyFilters reads all of r and passes it through filters, last
+// first, buffering the result.
+func applyFilters(filters []Filter, r io.Reader) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	err := copyFiltered(&buf, filters, r)
+	if err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// copyFiltered reads all of r and writes it to w after passing it
+// through filters, last first.
+func copyFiltered(w io.Writer, filters []Filter, r io.Reader) error {
+	writers := make([]io.WriteCloser, 0, len(filters))
+	for _, f := range filters {
+		fw, err := f.Encode(w)
+		if err != nil {
+			return err
+		}
+		writers = append(writers, fw)
+		w = fw
+	}
+
+	_, err := io.Copy(w, r)
+	if err != nil {
+		return err
+	}
+
+	for i := len(writers) - 1; i >= 0; i-- {
+		err := writers[i].Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filterNames returns the value of the Filter entry of a stream
+// dictionary for filters.
+func filterNames(filters []Filter) Object {
+	if len(filters) == 1 {
+		return filters[0].Name()
+	}
+
+	names := make(Array, 0, len(filters))
+	for _, f := range filters {
+		names = append(names, f.Name())
+	}
+	return names
+}
+
+// filterParams returns the value of the DecodeParms entry of a stream
+// dictionary for filters, or nil if none of them have parameters. If
+// there is more than one filter, the parameters are given as an array
+// that lines up with the Filter array, with null standing in for
+// filters without parameters.
+func filterParams(filters []Filter) Object {
+	params := make(Array, len(filters))
+	var found bool
+	for i, f := range filters {
+		params[i] = Null{}
+		if f, ok := f.(ParamsFilter); ok {
+			if p := f.Params(); p != nil {
+				params[i] = p
+				found = true
+			}
+		}
+	}
+
+	switch {
+	case !found:
+		return nil
+	case len(params) == 1:
+		return params[0]
+	default:
+		return params
+	}
+}
+
+// decodeStream returns a reader that decodes the data of st, which is
+// expected to be encoded already as described by the Filter and
+// DecodeParms entries of its Dict, as is the case for decoded streams.
+func decodeStream(st Stream) (io.Reader, error) {
+	var r io.Reader = bytes.NewReader(nil)
+	if st.Data != nil {
+		r = st.Data
+	}
+	if st.Length > 0 {
+		r = io.LimitReader(r, st.Length)
+	}
+
+	var names, params Array
+	switch f := st.Dict["Filter"].(type) {
+	case nil:
+		return r, nil
+	case Name:
+		names = Array{f}
+		params = Array{st.Dict["DecodeParms"]}
+	case Array:
+		names = f
+		params, _ = st.Dict["DecodeParms"].(Array)
+	default:
+		return nil, fmt.Errorf("pdf: invalid stream Filter: %v", f)
+	}
+
+	for i, name := range names {
+		name, ok := name.(Name)
+		if !ok {
+			return nil, fmt.Errorf("pdf: invalid stream Filter: %v", name)
+		}
+		var p Dict
+		if i < len(params) {
+			p, _ = params[i].(Dict)
+		}
+
+		f, err := decoderFor(name, p)
+		if err != nil {
+			return nil, err
+		}
+		r, err = f.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// decoderFor returns the Decoder for the filter named name, configured
+// by the decode parameters in params.
+func decoderFor(name Name, params Dict) (Decoder, error) {
+	param := func(key Name, def int) int {
+		if v, ok := params[key].(Integer); ok {
+			return int(v)
+		}
+		return def
+	}
+
+	switch name {
+	case "FlateDecode", "Fl":
+		return FlateFilter{
+			Predictor:        param("Predictor", 1),
+			Colors:           param("Colors", 1),
+			BitsPerComponent: param("BitsPerComponent", 8),
+			Columns:          param("Columns", 1),
+		}, nil
+	case "LZWDecode", "LZW":
+		if param("Predictor", 1) > 1 {
+			return nil, errors.New("pdf: predictors are not supported with LZWDecode")
+		}
+		return LZWFilter{DisableEarlyChange: param("EarlyChange", 1) == 0}, nil
+	case "ASCIIHexDecode", "AHx":
+		return ASCIIHexFilter{}, nil
+	case "ASCII85Decode", "A85":
+		return ASCII85Filter{}, nil
+	case "RunLengthDecode", "RL":
+		return RunLengthFilter{}, nil
+	default:
+		return nil, fmt.Errorf("pdf: unsupported filter: %v", name)
+	}
+}
+
+// FlateFilter is the FlateDecode filter, which compresses data using
+// zlib.
+type FlateFilter struct {
+	// Predictor is the predictor to apply to the data before
+	// compressing it. Zero and one mean no predictor, and 10 through 15
+	// are the PNG predictors None, Sub, Up, Average, Paeth, and
+	// Optimum, respectively. With Optimum, each row uses whichever of
+	// the others seems best for it.
+	Predictor int
+
+	// Colors, BitsPerComponent, and Columns describe the layout of the
+	// data when a predictor is in use. If they are zero, the defaults
+	// of 1, 8, and 1 are used.
+	Colors           int
+	BitsPerComponent int
+	Columns          int
+
+	// Level is the compression level, as for zlib.NewWriterLevel, such
+	// as zlib.BestSpeed or zlib.BestCompression. Zero means
+	// zlib.DefaultCompression, rather than no compression, which
+	// is better done by leaving the filter out.
+	Level int
+}
+
+func (FlateFilter) Name() Name {
+	return "FlateDecode"
+}
+
+// Params returns the decode parameters for the filter, or nil if no
+// predictor is in use.
+func (f FlateFilter) Params() Dict {
+	if f.Predictor <= 1 {
+		return nil
+	}
+	return predictorParams(f.Predictor, f.Colors, f.BitsPerComponent, f.Columns)
+}
+
+func (f FlateFilter) Encode(w io.Writer) (io.WriteCloser, error) {
+	level := f.Level
+	if level == 0 {
+		level = zlib.DefaultCompression
+	}
+	zw, err := zlib.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+	if f.Predictor <= 1 {
+		return zw, nil
+	}
+
+	return newPredictorWriter(zw, f.Predictor, f.Colors, f.BitsPerComponent, f.Columns)
+}
+
+func (f FlateFilter) Decode(r io.Reader) (io.Reader, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if f.Predictor <= 1 {
+		return zr, nil
+	}
+
+	return newPredictorReader(zr, f.Predictor, f.Colors, f.BitsPerComponent, f.Columns)
+}
+
+// Flate returns a stream containing the data read from r, compressed
+// with the FlateDecode filter. Compression happens while the stream is
+// being encoded, and any errors reading from r are returned then.
+func Flate(r io.Reader) Stream {
+	return Stream{
+		Filters: []Filter{FlateFilter{}},
+		Data:    r,
+	}
+}
+
+// FlateBytes is like Flate, but takes the data to compress directly.
+func FlateBytes(data []byte) Stream {
+	return Flate(bytes.NewReader(data))
+}
@@ -0,0 +1,139 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func streamBody(t *testing.T, out []byte) (string, []byte) {
+	m := regexp.MustCompile(`(?s)^(<<.*?>>)\nstream\n(.*)\nendstream$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("not a stream: %q", out)
+	}
+	n, _ := strconv.Atoi(string(regexp.MustCompile(`/Length (\d+)`).FindSubmatch(m[1])[1]))
+	if n != len(m[2]) {
+		t.Fatalf("length %v != %v", n, len(m[2]))
+	}
+	return string(m[1]), m[2]
+}
+
+func TestFlate(t *testing.T) {
+	in := []byte(strings.Repeat("hello world ", 1000))
+	for _, st := range []Stream{Flate(bytes.NewReader(in)), FlateBytes(in)} {
+		var buf bytes.Buffer
+		if err := EncodeObject(&buf, st); err != nil {
+			t.Fatal(err)
+		}
+		dict, body := streamBody(t, buf.Bytes())
+		if !strings.Contains(dict, "/Filter /FlateDecode") {
+			t.Fatal(dict)
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, _ := io.ReadAll(zr)
+		if !bytes.Equal(out, in) {
+			t.Fatal("mismatch")
+		}
+	}
+}
+
+type upperFilter struct{}
+
+func (upperFilter) Name() Name { return "Upper" }
+
+type upperWriter struct{ w io.Writer }
+
+func (u upperWriter) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (upperWriter) Close() error { return nil }
+
+func (upperFilter) Encode(w io.Writer) (io.WriteCloser, error) { return upperWriter{w}, nil }
+
+type quoteFilter struct{}
+
+func (quoteFilter) Name() Name { return "Quote" }
+
+type quoteWriter struct{ w io.Writer }
+
+func (u quoteWriter) Write(p []byte) (int, error) {
+	_, err := io.WriteString(u.w, strings.ToLower(strconv.Quote(string(p))))
+	return len(p), err
+}
+
+func (quoteWriter) Close() error { return nil }
+
+func (quoteFilter) Encode(w io.Writer) (io.WriteCloser, error) { return quoteWriter{w}, nil }
+
+func TestFilterChain(t *testing.T) {
+	var buf bytes.Buffer
+	// Upper is decoded first, so it is applied last.
+	err := EncodeObject(&buf, Stream{Filters: []Filter{upperFilter{}, quoteFilter{}}, Data: strings.NewReader("Ab")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict, body := streamBody(t, buf.Bytes())
+	if !strings.Contains(dict, "/Filter [/Upper /Quote]") {
+		t.Fatal(dict)
+	}
+	if string(body) != `"AB"` {
+		t.Fatal(string(body))
+	}
+}
+
+func TestDecodeParmsChain(t *testing.T) {
+	var out bytes.Buffer
+	EncodeObject(&out, Stream{Filters: []Filter{ASCIIHexFilter{}, LZWFilter{DisableEarlyChange: true}}, Data: strings.NewReader("x")})
+	if !strings.Contains(out.String(), "/DecodeParms [null <</EarlyChange 0 >>] /Filter [/ASCIIHexDecode /LZWDecode]") {
+		t.Fatal(out.String())
+	}
+	out.Reset()
+	EncodeObject(&out, Stream{Filters: []Filter{ASCIIHexFilter{}, LZWFilter{}}, Data: strings.NewReader("x")})
+	if strings.Contains(out.String(), "DecodeParms") {
+		t.Fatal(out.String())
+	}
+}
+
+func TestFlateLevel(t *testing.T) {
+	var payload bytes.Buffer
+	for i := range 5000 {
+		fmt.Fprintf(&payload, "line %v of some quite compressible text %v\n", i%37, i%11)
+	}
+	size := func(level int) int {
+		st := Stream{Filters: []Filter{FlateFilter{Level: level}}, Data: bytes.NewReader(payload.Bytes())}
+		var out bytes.Buffer
+		if err := EncodeObject(&out, st); err != nil {
+			t.Fatal(err)
+		}
+		p, err := DecodeObject(bytes.NewReader(out.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := decodeStream(p.(Stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(b, payload.Bytes()) {
+			t.Fatal(err)
+		}
+		return out.Len()
+	}
+	fast, best, def := size(zlib.BestSpeed), size(zlib.BestCompression), size(0)
+	if best >= fast || def < best || def > fast {
+		t.Fatal(best, def, fast)
+	}
+	var out bytes.Buffer
+	if err := EncodeObject(&out, Stream{Filters: []Filter{FlateFilter{Level: 42}}, Data: bytes.NewReader(nil)}); err == nil {
+		t.Fatal("no error")
+	}
+}
@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"maps"
+	"strings"
+	"unicode/utf8"
+)
+
+// FlowText lays out text in rect, in font at the given size, with
+// baselines leading apart, wrapping it at spaces and breaking words
+// that are too long to fit on a line by themselves. Newlines start new
+// paragraphs. It then adds as many copies of page as it takes to hold
+// all of the text to d with AddPages, each with its Contents replaced
+// by its share of the text and font added to its Resources, and
+// returns AddPages' results.
+func (d *Document) FlowText(page Page, text string, font StandardFont, size, leading float64, rect Rectangle) (catalog Reference, refs []Reference, err error) {
+	rect = rect.Normalize()
+	lines := wrapText(text, font, size, rect.URX-rect.LLX)
+
+	// The first baseline is set a whole size below the top so that
+	// ascenders don't stick out of rect.
+	perPage := 1
+	if h := rect.URY - rect.LLY - size; (h > 0) && (leading > 0) {
+		perPage += int(h / leading)
+	}
+
+	resources := maps.Clone(page.Resources)
+	if resources == nil {
+		resources = make(Dict, 1)
+	}
+	fonts, _ := resources["Font"].(Dict)
+	fonts = maps.Clone(fonts)
+	if fonts == nil {
+		fonts = make(Dict, 1)
+	}
+	name := font.Name()
+	fonts[name] = font.Dict()
+	resources["Font"] = fonts
+	page.Resources = resources
+
+	var pages []Page
+	for len(lines) > 0 || len(pages) == 0 {
+		n := min(len(lines), perPage)
+
+		var c Content
+		c.BeginText()
+		c.SetFont(name, size)
+		c.SetLeading(leading)
+		c.SetTextPosition(rect.LLX, rect.URY-size)
+		for i, line := range lines[:n] {
+			if i > 0 {
+				c.NewLine()
+			}
+			c.ShowText(font.encode(line))
+		}
+		c.EndText()
+
+		page.Contents = c.Stream()
+		pages = append(pages, page)
+		lines = lines[n:]
+	}
+
+	return d.AddPages(pages)
+}
+
+// wrapText splits text into lines that are no wider than width when
+// shown in font at the given size.
+func wrapText(text string, font StandardFont, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		var line string
+		for _, word := range strings.Fields(para) {
+			if line != "" {
+				if font.Width(line+" "+word, size) <= width {
+					line += " " + word
+					continue
+				}
+				lines = append(lines, line)
+				line = ""
+			}
+
+			for font.Width(word, size) > width {
+				n := breakWord(word, font, size, width)
+				if n == len(word) {
+					break
+				}
+				lines = append(lines, word[:n])
+				word = word[n:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// breakWord returns the length of the longest prefix of word that fits
+// in width, which is always at least one rune so that layout makes
+// progress even if nothing fits.
+func breakWord(word string, font StandardFont, size, width float64) int {
+	_, n := utf8.DecodeRuneInString(word)
+	for n < len(word) {
+		_, next := utf8.DecodeRuneInString(word[n:])
+		if font.Width(word[:n+next], size) > width {
+			break
+		}
+		n += next
+	}
+	return n
+}
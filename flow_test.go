@@ -0,0 +1,51 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFlowText(t *testing.T) {
+	para := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 25) + "\n" + strings.Repeat("x", 300)
+	rect := Rectangle{72, 72, 300, 400}
+	width := rect.URX - rect.LLX
+	lines := wrapText(para, Helvetica, 12, width)
+	for _, line := range lines {
+		if Helvetica.Width(line, 12) > width {
+			t.Fatalf("%q too wide", line)
+		}
+	}
+	if lines[len(lines)-1] == "" || !strings.HasPrefix(lines[len(lines)-2], "xxx") {
+		t.Fatal(lines[len(lines)-3:])
+	}
+	var d Document
+	var refs []Reference
+	var err error
+	d.Root, refs, err = d.FlowText(Page{MediaBox: A4}, para, Helvetica, 12, 14, rect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	perPage := 1 + 22
+	want := (len(lines) + perPage - 1) / perPage
+	if len(refs) != want || want != 2 {
+		t.Fatal(len(refs), want, len(lines))
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+
+	var e Document
+	_, refs, err = e.FlowText(Page{MediaBox: A4}, "", Helvetica, 12, 14, rect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 {
+		t.Fatal(len(refs))
+	}
+	if got := wrapText("W", Helvetica, 12, 1); len(got) != 1 || got[0] != "W" {
+		t.Fatal(got)
+	}
+}
@@ -0,0 +1,153 @@
+package pdf
+
+import "strconv"
+
+// StandardFont is one of the 14 standard Type 1 fonts that every PDF
+// reader provides, and which therefore don't need to be embedded.
+type StandardFont int
+
+const (
+	Helvetica StandardFont = iota
+	HelveticaBold
+	HelveticaOblique
+	HelveticaBoldOblique
+	TimesRoman
+	TimesBold
+	TimesItalic
+	TimesBoldItalic
+	Courier
+	CourierBold
+	CourierOblique
+	CourierBoldOblique
+	Symbol
+	ZapfDingbats
+)
+
+var standardFontNames = [...]Name{
+	Helvetica:            "Helvetica",
+	HelveticaBold:        "Helvetica-Bold",
+	HelveticaOblique:     "Helvetica-Oblique",
+	HelveticaBoldOblique: "Helvetica-BoldOblique",
+	TimesRoman:           "Times-Roman",
+	TimesBold:            "Times-Bold",
+	TimesItalic:          "Times-Italic",
+	TimesBoldItalic:      "Times-BoldItalic",
+	Courier:              "Courier",
+	CourierBold:          "Courier-Bold",
+	CourierOblique:       "Courier-Oblique",
+	CourierBoldOblique:   "Courier-BoldOblique",
+	Symbol:               "Symbol",
+	ZapfDingbats:         "ZapfDingbats",
+}
+
+// Name returns the PostScript name of the font, such as
+// Helvetica-Bold.
+func (f StandardFont) Name() Name {
+	if (f < 0) || (int(f) >= len(standardFontNames)) {
+		return ""
+	}
+	return standardFontNames[f]
+}
+
+func (f StandardFont) String() string {
+	if name := f.Name(); name != "" {
+		return string(name)
+	}
+	return "StandardFont(" + strconv.Itoa(int(f)) + ")"
+}
+
+// symbolic returns true if f uses its own built-in encoding rather than
+// a standard Latin one.
+func (f StandardFont) symbolic() bool {
+	return (f == Symbol) || (f == ZapfDingbats)
+}
+
+// Dict returns a font dictionary for f, suitable for use in the Font
+// entry of a resource dictionary. Fonts other than Symbol and
+// ZapfDingbats use WinAnsiEncoding.
+func (f StandardFont) Dict() Dict {
+	dict := Dict{
+		"Type":     Name("Font"),
+		"Subtype":  Name("Type1"),
+		"BaseFont": f.Name(),
+	}
+	if !f.symbolic() {
+		dict["Encoding"] = Name("WinAnsiEncoding")
+	}
+	return dict
+}
+
+// Width returns the width of s when shown in f at the given size, in
+// the same units as size. Kerning is not taken into account.
+//
+// For fonts that use WinAnsiEncoding, runes are mapped to character
+// codes using that encoding. For Symbol and ZapfDingbats, runes below
+// 256 are used directly as character codes. Runes for which the font
+// has no glyph are measured as though they were spaces.
+func (f StandardFont) Width(s string, size float64) float64 {
+	if (f < 0) || (int(f) >= len(standardFontWidths)) {
+		return 0
+	}
+	widths := &standardFontWidths[f]
+
+	var total int
+	for _, r := range s {
+		code, ok := f.code(r)
+		w := int(widths[code])
+		if !ok || (w == 0) {
+			w = int(widths[' '])
+		}
+		total += w
+	}
+
+	return float64(total) * size / 1000
+}
+
+// encode returns s in f's encoding, for use with Content.ShowText.
+// Runes for which the font has no glyph are replaced with spaces, as
+// Width measures them.
+func (f StandardFont) encode(s string) string {
+	buf := make([]byte, 0, len(s))
+	for _, r := range s {
+		code, ok := f.code(r)
+		if !ok {
+			code = ' '
+		}
+		buf = append(buf, code)
+	}
+	return string(buf)
+}
+
+// code returns the character code for r in f's encoding.
+func (f StandardFont) code(r rune) (byte, bool) {
+	if f.symbolic() {
+		if (r < 0) || (r > 0xFF) {
+			return 0, false
+		}
+		return byte(r), true
+	}
+
+	return winAnsiCode(r)
+}
+
+// winAnsiCode returns the WinAnsiEncoding character code for r.
+func winAnsiCode(r rune) (byte, bool) {
+	switch {
+	case (r >= 0) && (r < 0x80) && (r != 0x7F):
+		return byte(r), true
+	case (r >= 0xA0) && (r <= 0xFF):
+		return byte(r), true
+	}
+
+	code, ok := winAnsiHigh[r]
+	return code, ok
+}
+
+// winAnsiHigh maps runes to the WinAnsiEncoding codes between 128 and
+// 159, which don't match up with Latin-1.
+var winAnsiHigh = map[rune]byte{
+	'€': 128, '‚': 130, 'ƒ': 131, '„': 132, '…': 133, '†': 134, '‡': 135,
+	'ˆ': 136, '‰': 137, 'Š': 138, '‹': 139, 'Œ': 140, 'Ž': 142, '‘': 145,
+	'’': 146, '“': 147, '”': 148, '•': 149, '–': 150, '—': 151, '˜': 152,
+	'™': 153, 'š': 154, '›': 155, 'œ': 156, 'ž': 158, 'Ÿ': 159,
+}
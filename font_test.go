@@ -0,0 +1,38 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStandardFont(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeObject(&buf, HelveticaBold.Dict())
+	if buf.String() != "<</BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding /Subtype /Type1 /Type /Font >>" {
+		t.Fatal(buf.String())
+	}
+	if _, ok := ZapfDingbats.Dict()["Encoding"]; ok {
+		t.Fatal("zapf")
+	}
+	if StandardFont(99).String() != "StandardFont(99)" || TimesBoldItalic.String() != "Times-BoldItalic" {
+		t.Fatal()
+	}
+}
+
+func TestWidth(t *testing.T) {
+	if w := Helvetica.Width("Hello", 12); w < 27.335 || w > 27.337 {
+		t.Fatal(w)
+	}
+	if w := Courier.Width("abc", 10); w != 18 {
+		t.Fatal(w)
+	}
+	if w := Helvetica.Width("€一", 1); w != 0.556+0.278 {
+		t.Fatal(w)
+	}
+	if w := TimesRoman.Width("Wé", 1000); w != 944+444 {
+		t.Fatal(w)
+	}
+	if w := Symbol.Width("a", 1000); w != 631 {
+		t.Fatal(w)
+	}
+}
@@ -0,0 +1,52 @@
+package pdf
+
+import "errors"
+
+// FormXObject is a self-contained content stream that can be drawn any
+// number of times, such as by Content.DrawXObject, after being added
+// to a document and named in a resource dictionary's XObject entry.
+type FormXObject struct {
+	// BBox is the bounding box of the form, in form space. Anything
+	// drawn outside of it is clipped.
+	BBox Rectangle
+
+	// Resources contains the resources needed by the form's content.
+	Resources Dict
+
+	// Content is the form's content stream. It must be a Stream, such
+	// as one returned by Content.Stream, or nil for an empty form.
+	Content Object
+
+	// Layer, if set, refers to the optional content group, such as
+	// one returned by Document.AddLayer, that the form belongs to. The
+	// form is only drawn while the layer is turned on.
+	Layer Reference
+}
+
+func (f FormXObject) encode(s *encodeState) error {
+	var st Stream
+	switch content := f.Content.(type) {
+	case nil:
+	case Stream:
+		st = content
+	default:
+		return errors.New("pdf: FormXObject.Content must be a Stream")
+	}
+
+	dict := make(Dict, len(st.Dict)+5)
+	for k, v := range st.Dict {
+		dict[k] = v
+	}
+	dict["Type"] = Name("XObject")
+	dict["Subtype"] = Name("Form")
+	dict["BBox"] = f.BBox
+	if f.Resources != nil {
+		dict["Resources"] = f.Resources
+	}
+	if f.Layer != "" {
+		dict["OC"] = f.Layer
+	}
+	st.Dict = dict
+
+	return st.encode(s)
+}
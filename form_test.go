@@ -0,0 +1,36 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFormXObject(t *testing.T) {
+	var fc Content
+	fc.Rectangle(0, 0, 10, 10)
+	fc.Fill()
+	var d Document
+	form := d.Add(FormXObject{BBox: Rectangle{0, 0, 10, 10}, Content: fc.Stream()})
+	var c Content
+	c.DrawXObject("Logo")
+	if !strings.Contains(string(c.Bytes()), "/Logo Do\n") {
+		t.Fatalf("%q", c.Bytes())
+	}
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Resources: Dict{"XObject": Dict{"Logo": form}}, Contents: c.Stream()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "/BBox [0 0 10 10] /Length 15 /Subtype /Form /Type /XObject") {
+		t.Fatal("form dict")
+	}
+	validate(t, out.Bytes())
+	if err := EncodeObject(&out, FormXObject{Content: Integer(1)}); err == nil {
+		t.Fatal("no err")
+	}
+}
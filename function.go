@@ -0,0 +1,206 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// unitDomain is the domain used by functions that don't specify one.
+var unitDomain = [2]float64{0, 1}
+
+// realArray returns an Array containing each of v as a Real.
+func realArray(v ...float64) Array {
+	a := make(Array, 0, len(v))
+	for _, v := range v {
+		a = append(a, Real(v))
+	}
+	return a
+}
+
+// SampledFunction is a type 0 function, which approximates a function
+// of m inputs and n outputs using a table of sample values.
+type SampledFunction struct {
+	// Domain holds the range of valid values of each input, as a pair
+	// of numbers per input.
+	Domain []float64
+
+	// Range holds the range of each output, as a pair of numbers per
+	// output.
+	Range []float64
+
+	// Size is the number of samples along each input dimension.
+	Size []int
+
+	// BitsPerSample is the size of each sample. It must be 1, 2, 4, 8,
+	// 12, 16, 24, or 32.
+	BitsPerSample int
+
+	// Samples holds the sample values, packed into bits, with the
+	// first input varying fastest and the outputs of each sample
+	// stored together.
+	Samples []byte
+}
+
+func (f SampledFunction) encode(s *encodeState) error {
+	if (len(f.Domain) == 0) || (len(f.Domain) != 2*len(f.Size)) {
+		return errors.New("pdf: SampledFunction.Domain must have two values for each of Size")
+	}
+	if (len(f.Range) == 0) || (len(f.Range)%2 != 0) {
+		return errors.New("pdf: SampledFunction.Range must have two values for each output")
+	}
+	switch f.BitsPerSample {
+	case 1, 2, 4, 8, 12, 16, 24, 32:
+	default:
+		return fmt.Errorf("pdf: invalid SampledFunction.BitsPerSample: %v", f.BitsPerSample)
+	}
+
+	bits := f.BitsPerSample * len(f.Range) / 2
+	size := make(Array, 0, len(f.Size))
+	for _, n := range f.Size {
+		if n <= 0 {
+			return fmt.Errorf("pdf: invalid SampledFunction.Size: %v", f.Size)
+		}
+		size = append(size, Integer(n))
+		bits *= n
+	}
+	if len(f.Samples) < (bits+7)/8 {
+		return fmt.Errorf("pdf: SampledFunction needs %v bytes of samples, not %v", (bits+7)/8, len(f.Samples))
+	}
+
+	st := FlateBytes(f.Samples)
+	st.Dict = Dict{
+		"FunctionType":  Integer(0),
+		"Domain":        realArray(f.Domain...),
+		"Range":         realArray(f.Range...),
+		"Size":          size,
+		"BitsPerSample": Integer(f.BitsPerSample),
+	}
+	return st.encode(s)
+}
+
+// ExponentialFunction is a type 2 function, which maps a single input
+// x to C0 + x^N * (C1 - C0), component-wise.
+type ExponentialFunction struct {
+	// Domain is the range of valid inputs. If it is the zero value,
+	// it is [0 1].
+	Domain [2]float64
+
+	// C0 and C1 are the outputs for inputs of 0 and 1. They must be
+	// the same length. If they are empty, they default to [0] and [1].
+	C0, C1 []float64
+
+	// N is the interpolation exponent. 1 interpolates linearly.
+	N float64
+}
+
+func (f ExponentialFunction) encode(s *encodeState) error {
+	if len(f.C0) != len(f.C1) {
+		return errors.New("pdf: ExponentialFunction.C0 and C1 must be the same length")
+	}
+
+	domain := f.Domain
+	if domain == [2]float64{} {
+		domain = unitDomain
+	}
+
+	dict := Dict{
+		"FunctionType": Integer(2),
+		"Domain":       realArray(domain[:]...),
+		"N":            Real(f.N),
+	}
+	if len(f.C0) > 0 {
+		dict["C0"] = realArray(f.C0...)
+		dict["C1"] = realArray(f.C1...)
+	}
+	return dict.encode(s)
+}
+
+// StitchingFunction is a type 3 function, which splits its domain into
+// subdomains and passes each one on to a different function.
+type StitchingFunction struct {
+	// Domain is the range of valid inputs. If it is the zero value,
+	// it is [0 1].
+	Domain [2]float64
+
+	// Functions are the functions for each subdomain. They must all
+	// take a single input and have the same number of outputs.
+	Functions []Object
+
+	// Bounds are the boundaries between the subdomains, in increasing
+	// order within Domain. There must be one fewer of them than there
+	// are functions.
+	Bounds []float64
+
+	// Encode maps each subdomain onto the domain of its function, as
+	// a pair of numbers per function. If it is empty, every subdomain
+	// is mapped onto [0 1].
+	Encode []float64
+}
+
+func (f StitchingFunction) encode(s *encodeState) error {
+	if len(f.Functions) == 0 {
+		return errors.New("pdf: StitchingFunction needs at least one function")
+	}
+	if len(f.Bounds) != len(f.Functions)-1 {
+		return fmt.Errorf("pdf: StitchingFunction with %v functions needs %v bounds, not %v", len(f.Functions), len(f.Functions)-1, len(f.Bounds))
+	}
+
+	domain := f.Domain
+	if domain == [2]float64{} {
+		domain = unitDomain
+	}
+
+	encode := f.Encode
+	if len(encode) == 0 {
+		encode = make([]float64, 0, 2*len(f.Functions))
+		for range f.Functions {
+			encode = append(encode, unitDomain[:]...)
+		}
+	}
+	if len(encode) != 2*len(f.Functions) {
+		return fmt.Errorf("pdf: StitchingFunction with %v functions needs %v Encode values, not %v", len(f.Functions), 2*len(f.Functions), len(encode))
+	}
+
+	dict := Dict{
+		"FunctionType": Integer(3),
+		"Domain":       realArray(domain[:]...),
+		"Functions":    Array(f.Functions),
+		"Bounds":       realArray(f.Bounds...),
+		"Encode":       realArray(encode...),
+	}
+	return dict.encode(s)
+}
+
+// PostScriptFunction is a type 4 function, which is a program written
+// in a small subset of PostScript. The program starts with the inputs
+// on the stack and leaves the outputs there.
+type PostScriptFunction struct {
+	// Domain and Range hold the range of each input and output,
+	// respectively, as pairs of numbers.
+	Domain, Range []float64
+
+	// Code is the program, including the braces around it, such as
+	// "{ 360 mul sin }".
+	Code string
+}
+
+func (f PostScriptFunction) encode(s *encodeState) error {
+	if (len(f.Domain) == 0) || (len(f.Domain)%2 != 0) {
+		return errors.New("pdf: PostScriptFunction.Domain must have two values for each input")
+	}
+	if (len(f.Range) == 0) || (len(f.Range)%2 != 0) {
+		return errors.New("pdf: PostScriptFunction.Range must have two values for each output")
+	}
+
+	st := Stream{
+		Dict: Dict{
+			"FunctionType": Integer(4),
+			"Domain":       realArray(f.Domain...),
+			"Range":        realArray(f.Range...),
+		},
+		Length: int64(len(f.Code)),
+		Data:   strings.NewReader(f.Code),
+	}
+	return st.encode(s)
+}
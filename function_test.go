@@ -0,0 +1,70 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeObject(&buf, ExponentialFunction{Domain: [2]float64{0, 2}, C0: []float64{0}, C1: []float64{1}, N: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<</C0 [0] /C1 [1] /Domain [0 2] /FunctionType 2 /N 2 >>" {
+		t.Fatal(buf.String())
+	}
+	buf.Reset()
+	st := StitchingFunction{Functions: []Object{ExponentialFunction{N: 1}, ExponentialFunction{N: 1}}, Bounds: []float64{0.25}, Encode: []float64{1, 0, 0, 1}}
+	if err := EncodeObject(&buf, st); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<</Bounds [0.25] /Domain [0 1] /Encode [1 0 0 1] /FunctionType 3 /Functions [<</Domain [0 1] /FunctionType 2 /N 1 >> <</Domain [0 1] /FunctionType 2 /N 1 >>] >>" {
+		t.Fatal(buf.String())
+	}
+	st.Bounds = nil
+	if err := EncodeObject(&buf, st); err == nil {
+		t.Fatal("bounds")
+	}
+
+	var d Document
+	sampled := d.Add(SampledFunction{Domain: []float64{0, 1}, Range: []float64{0, 1, 0, 1, 0, 1}, Size: []int{2}, BitsPerSample: 8, Samples: []byte{255, 0, 0, 0, 0, 255}})
+	ps := d.Add(PostScriptFunction{Domain: []float64{0, 1}, Range: []float64{0, 1, 0, 1, 0, 1}, Code: "{ dup dup }"})
+	var c Content
+	c.Shade("A")
+	c.Shade("B")
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"Shading": Dict{
+		"A": AxialShading(Name("DeviceRGB"), 0, 0, 100, 0, sampled),
+		"B": AxialShading(Name("DeviceRGB"), 0, 100, 100, 100, ps),
+	}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.ObjectStreams = true
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	for _, want := range []string{"/BitsPerSample 8 /Domain [0 1] /Filter /FlateDecode /FunctionType 0 /Length", "/Size [2]", "/Domain [0 1] /FunctionType 4 /Length 11 /Range [0 1 0 1 0 1] >>\nstream\n{ dup dup }"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Fatal(want)
+		}
+	}
+	validate(t, data)
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := p.Body[0].Object.(Stream)
+	zr, _ := zlib.NewReader(s.Data)
+	got, _ := io.ReadAll(zr)
+	if !bytes.Equal(got, []byte{255, 0, 0, 0, 0, 255}) {
+		t.Fatal(got)
+	}
+	if err := EncodeObject(&buf, SampledFunction{Domain: []float64{0, 1}, Range: []float64{0, 1}, Size: []int{4}, BitsPerSample: 8, Samples: []byte{1, 2}}); err == nil {
+		t.Fatal("short")
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/DeedleFake/pwall
+
+go 1.22
+
+require (
+	github.com/pdfcpu/pdfcpu v0.8.0
+	golang.org/x/image v0.18.0
+)
+
+require (
+	github.com/hhrutter/lzw v1.0.0 // indirect
+	github.com/hhrutter/tiff v1.0.1 // indirect
+	github.com/mattn/go-runewidth v0.0.15 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/rivo/uniseg v0.4.7 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
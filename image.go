@@ -0,0 +1,383 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Image returns an image XObject containing the color samples of img,
+// compressed with the Flate filter. Any transparency in img is
+// ignored; see Document.AddImage.
+//
+// A paletted image is stored using an indexed color space, with as few
+// bits per pixel as its palette allows.
+func Image(img image.Image) (Object, error) {
+	b := img.Bounds()
+	if b.Empty() {
+		return nil, errors.New("pdf: cannot embed empty image")
+	}
+	if p, ok := img.(*image.Paletted); ok {
+		return indexedImage(p)
+	}
+
+	// PDF images are stored top to bottom, the same as in Go, so rows
+	// can be written in order.
+	samples := make([]byte, 0, 3*b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			samples = append(samples, c.R, c.G, c.B)
+		}
+	}
+
+	return Stream{
+		Dict: Dict{
+			"Type":             Name("XObject"),
+			"Subtype":          Name("Image"),
+			"Width":            Integer(b.Dx()),
+			"Height":           Integer(b.Dy()),
+			"ColorSpace":       Name("DeviceRGB"),
+			"BitsPerComponent": Integer(8),
+		},
+		Filters: []Filter{FlateFilter{}},
+		Data:    bytes.NewReader(samples),
+	}, nil
+}
+
+func indexedImage(img *image.Paletted) (Object, error) {
+	if (len(img.Palette) == 0) || (len(img.Palette) > 256) {
+		return nil, fmt.Errorf("pdf: unsupported palette size: %v", len(img.Palette))
+	}
+
+	lookup := make([]byte, 0, 3*len(img.Palette))
+	for _, c := range img.Palette {
+		c := color.NRGBAModel.Convert(c).(color.NRGBA)
+		lookup = append(lookup, c.R, c.G, c.B)
+	}
+
+	bpc := 8
+	switch {
+	case len(img.Palette) <= 2:
+		bpc = 1
+	case len(img.Palette) <= 4:
+		bpc = 2
+	case len(img.Palette) <= 16:
+		bpc = 4
+	}
+
+	// Each row starts on a byte boundary.
+	b := img.Bounds()
+	stride := (b.Dx()*bpc + 7) / 8
+	samples := make([]byte, stride*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := samples[(y-b.Min.Y)*stride:]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			bit := (x - b.Min.X) * bpc
+			shift := 8 - bpc - bit%8
+			row[bit/8] |= (img.ColorIndexAt(x, y) & (1<<bpc - 1)) << shift
+		}
+	}
+
+	return Stream{
+		Dict: Dict{
+			"Type":             Name("XObject"),
+			"Subtype":          Name("Image"),
+			"Width":            Integer(b.Dx()),
+			"Height":           Integer(b.Dy()),
+			"ColorSpace":       Array{Name("Indexed"), Name("DeviceRGB"), Integer(len(img.Palette) - 1), String(lookup)},
+			"BitsPerComponent": Integer(bpc),
+		},
+		Filters: []Filter{FlateFilter{}},
+		Data:    bytes.NewReader(samples),
+	}, nil
+}
+
+// alphaMask returns a soft mask image XObject containing the alpha
+// samples of img, or nil if img is fully opaque.
+func alphaMask(img image.Image) Object {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return nil
+	}
+
+	b := img.Bounds()
+	samples := make([]byte, 0, b.Dx()*b.Dy())
+	opaque := true
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			_, _, _, a := img.At(x, y).RGBA()
+			samples = append(samples, byte(a>>8))
+			opaque = opaque && (a == 0xFFFF)
+		}
+	}
+	if opaque {
+		return nil
+	}
+
+	return Stream{
+		Dict: Dict{
+			"Type":             Name("XObject"),
+			"Subtype":          Name("Image"),
+			"Width":            Integer(b.Dx()),
+			"Height":           Integer(b.Dy()),
+			"ColorSpace":       Name("DeviceGray"),
+			"BitsPerComponent": Integer(8),
+		},
+		Filters: []Filter{FlateFilter{}},
+		Data:    bytes.NewReader(samples),
+	}
+}
+
+// AddImage adds img to the document as an image XObject and returns a
+// reference to it. If img is not fully opaque, its alpha channel is
+// added as well, as a separate image that the first refers to as its
+// soft mask.
+func (d *Document) AddImage(img image.Image) (Reference, error) {
+	obj, err := Image(img)
+	if err != nil {
+		return "", err
+	}
+
+	if mask := alphaMask(img); mask != nil {
+		obj.(Stream).Dict["SMask"] = d.Add(mask)
+	}
+	return d.Add(obj), nil
+}
+
+// ImageJPEG returns an image XObject containing the JPEG data read
+// from r. The data is embedded as is, using the DCTDecode filter, so
+// only the header is examined in order to determine the image's
+// dimensions and color space.
+func ImageJPEG(r io.Reader) (Object, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := parseJPEG(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dict := Dict{
+		"Type":             Name("XObject"),
+		"Subtype":          Name("Image"),
+		"Width":            Integer(info.width),
+		"Height":           Integer(info.height),
+		"BitsPerComponent": Integer(8),
+		"Filter":           Name("DCTDecode"),
+	}
+	switch info.components {
+	case 1:
+		dict["ColorSpace"] = Name("DeviceGray")
+	case 3:
+		dict["ColorSpace"] = Name("DeviceRGB")
+	case 4:
+		dict["ColorSpace"] = Name("DeviceCMYK")
+		if info.adobe {
+			// Adobe applications write CMYK JPEGs with inverted
+			// samples.
+			dict["Decode"] = Array{
+				Integer(1), Integer(0), Integer(1), Integer(0),
+				Integer(1), Integer(0), Integer(1), Integer(0),
+			}
+		}
+	default:
+		return nil, fmt.Errorf("pdf: unsupported number of JPEG components: %v", info.components)
+	}
+
+	return Stream{
+		Dict:   dict,
+		Length: int64(len(data)),
+		Data:   bytes.NewReader(data),
+	}, nil
+}
+
+type jpegInfo struct {
+	width, height int
+	components    int
+	adobe         bool
+}
+
+// parseJPEG reads the markers at the start of a JPEG file up to the
+// first start of frame.
+func parseJPEG(data []byte) (info jpegInfo, err error) {
+	if (len(data) < 2) || (data[0] != 0xFF) || (data[1] != 0xD8) {
+		return info, errors.New("pdf: not a JPEG image")
+	}
+
+	p := data[2:]
+	for {
+		// Markers may be preceded by any number of fill bytes.
+		for (len(p) > 1) && (p[0] == 0xFF) && (p[1] == 0xFF) {
+			p = p[1:]
+		}
+		if (len(p) < 4) || (p[0] != 0xFF) {
+			return info, errors.New("pdf: JPEG image has no start of frame")
+		}
+		marker := p[1]
+		length := int(binary.BigEndian.Uint16(p[2:]))
+		if (length < 2) || (len(p) < 2+length) {
+			return info, errors.New("pdf: JPEG segment is truncated")
+		}
+		seg := p[4 : 2+length]
+		p = p[2+length:]
+
+		switch {
+		case (marker == 0xEE) && bytes.HasPrefix(seg, []byte("Adobe")):
+			info.adobe = true
+
+		case (marker >= 0xC0) && (marker <= 0xCF) && (marker != 0xC4) && (marker != 0xC8) && (marker != 0xCC):
+			if len(seg) < 6 {
+				return info, errors.New("pdf: JPEG start of frame is truncated")
+			}
+			if bits := seg[0]; bits != 8 {
+				return info, fmt.Errorf("pdf: unsupported JPEG sample precision: %v", bits)
+			}
+			info.height = int(binary.BigEndian.Uint16(seg[1:]))
+			info.width = int(binary.BigEndian.Uint16(seg[3:]))
+			info.components = int(seg[5])
+			if (info.width == 0) || (info.height == 0) {
+				return info, errors.New("pdf: JPEG image has no dimensions")
+			}
+			return info, nil
+
+		case (marker == 0xD9) || (marker == 0xDA):
+			return info, errors.New("pdf: JPEG image has no start of frame")
+		}
+	}
+}
+
+// InlineImage is a small image that is embedded directly in a content
+// stream, rather than being stored as a separate XObject.
+type InlineImage struct {
+	Width, Height    int
+	BitsPerComponent int
+
+	// ColorSpace is either a device color space or the name of a color
+	// space in the ColorSpace resources. It is ignored for image masks.
+	ColorSpace Name
+
+	// ImageMask causes the image to be treated as a stencil mask,
+	// painting the current fill color where samples are 0. It implies
+	// a BitsPerComponent of 1.
+	ImageMask bool
+
+	// Filters are applied to Data when it is written, in the same way
+	// as for a Stream.
+	Filters []Filter
+
+	// Data contains the image's samples.
+	Data []byte
+}
+
+// inlineImageNames are the abbreviations of names used in inline
+// image dictionaries.
+var inlineImageNames = map[Name]Name{
+	"DeviceGray":      "G",
+	"DeviceRGB":       "RGB",
+	"DeviceCMYK":      "CMYK",
+	"Indexed":         "I",
+	"ASCIIHexDecode":  "AHx",
+	"ASCII85Decode":   "A85",
+	"LZWDecode":       "LZW",
+	"FlateDecode":     "Fl",
+	"RunLengthDecode": "RL",
+	"CCITTFaxDecode":  "CCF",
+	"DCTDecode":       "DCT",
+}
+
+// abbreviate returns the abbreviated form of name in an inline image
+// dictionary, if it has one.
+func abbreviate(name Name) Name {
+	if abbr, ok := inlineImageNames[name]; ok {
+		return abbr
+	}
+	return name
+}
+
+// DrawInlineImage draws img in the unit square of user space (BI, ID,
+// and EI).
+//
+// Readers find the end of an inline image's data by looking for the EI
+// operator, so if the data written would contain something that looks
+// like it, it is additionally encoded with the ASCIIHex filter, which
+// never produces one. The length of the data is also given, as PDF 2.0
+// allows.
+func (c *Content) DrawInlineImage(img InlineImage) {
+	data, err := applyFilters(img.Filters, bytes.NewReader(img.Data))
+	if (err == nil) && containsEI(data.Bytes()) {
+		img.Filters = append([]Filter{ASCIIHexFilter{}}, img.Filters...)
+		data, err = applyFilters(img.Filters, bytes.NewReader(img.Data))
+	}
+	if err != nil {
+		if c.err == nil {
+			c.err = fmt.Errorf("pdf: inline image: %w", err)
+		}
+		return
+	}
+
+	entries := []Object{
+		Name("W"), Integer(img.Width),
+		Name("H"), Integer(img.Height),
+	}
+	if img.ImageMask {
+		entries = append(entries, Name("IM"), Boolean(true))
+	} else {
+		entries = append(entries,
+			Name("BPC"), Integer(img.BitsPerComponent),
+			Name("CS"), abbreviate(img.ColorSpace),
+		)
+	}
+	if len(img.Filters) > 0 {
+		var filters Object
+		switch names := filterNames(img.Filters).(type) {
+		case Name:
+			filters = abbreviate(names)
+		case Array:
+			for i, name := range names {
+				names[i] = abbreviate(name.(Name))
+			}
+			filters = names
+		}
+		entries = append(entries, Name("F"), filters)
+		if params := filterParams(img.Filters); params != nil {
+			entries = append(entries, Name("DP"), params)
+		}
+	}
+	entries = append(entries, Name("L"), Integer(data.Len()))
+
+	c.op("BI")
+	for i, obj := range entries {
+		err := EncodeObject(&c.buf, obj)
+		if (err != nil) && (c.err == nil) {
+			c.err = fmt.Errorf("pdf: inline image: %w", err)
+		}
+		c.buf.WriteByte(" \n"[i%2])
+	}
+	c.buf.WriteString("ID ")
+	c.buf.Write(data.Bytes())
+	c.buf.WriteString("\nEI\n")
+}
+
+// containsEI returns true if data contains the EI operator preceded by
+// white space and followed by white space or a delimiter, or ends with
+// it.
+func containsEI(data []byte) bool {
+	for i := 0; i+2 <= len(data); i++ {
+		if (data[i] != 'E') || (data[i+1] != 'I') {
+			continue
+		}
+		if (i > 0) && !isWhitespace(data[i-1]) {
+			continue
+		}
+		if (i+2 == len(data)) || isWhitespace(data[i+2]) || isDelimiter(data[i+2]) {
+			return true
+		}
+	}
+	return false
+}
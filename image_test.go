@@ -0,0 +1,269 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestImageJPEG(t *testing.T) {
+	for _, tc := range []struct {
+		img image.Image
+		cs  string
+	}{
+		{image.NewRGBA(image.Rect(0, 0, 7, 5)), "DeviceRGB"},
+		{image.NewGray(image.Rect(0, 0, 3, 9)), "DeviceGray"},
+	} {
+		var buf bytes.Buffer
+		if c, ok := tc.img.(*image.RGBA); ok {
+			c.Set(1, 1, color.RGBA{255, 0, 0, 255})
+		}
+		if err := jpeg.Encode(&buf, tc.img, nil); err != nil {
+			t.Fatal(err)
+		}
+		jp := buf.Bytes()
+		obj, err := ImageJPEG(bytes.NewReader(jp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := obj.(Stream)
+		b := tc.img.Bounds()
+		if st.Dict["Width"] != Integer(b.Dx()) || st.Dict["Height"] != Integer(b.Dy()) || st.Dict["ColorSpace"] != Name(tc.cs) {
+			t.Fatal(st.Dict)
+		}
+		if _, ok := st.Dict["Decode"]; ok {
+			t.Fatal(st.Dict)
+		}
+		var out bytes.Buffer
+		if err := EncodeObject(&out, obj); err != nil {
+			t.Fatal(err)
+		}
+		_, body := streamBody(t, out.Bytes())
+		if !bytes.Equal(body, jp) {
+			t.Fatal("data changed")
+		}
+		d := Document{}
+		obj, _ = ImageJPEG(bytes.NewReader(jp))
+		ref := d.Add(obj)
+		catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Resources: Dict{"XObject": Dict{"Im0": ref}}, Contents: Stream{Data: bytes.NewReader([]byte("q 100 0 0 100 0 0 cm /Im0 Do Q"))}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Root = catalog
+		var pdf bytes.Buffer
+		if _, err := d.Finish(&pdf); err != nil {
+			t.Fatal(err)
+		}
+		validate(t, pdf.Bytes())
+	}
+	adobe := []byte{0xFF, 0xD8,
+		0xFF, 0xEE, 0, 14, 'A', 'd', 'o', 'b', 'e', 0, 100, 0, 0, 0, 0, 0,
+		0xFF, 0xFF, 0xC0, 0, 20, 8, 0, 3, 0, 5, 4, 1, 0x11, 0, 2, 0x11, 0, 3, 0x11, 0, 4, 0x11, 0,
+		0xFF, 0xD9}
+	obj, err := ImageJPEG(bytes.NewReader(adobe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := obj.(Stream)
+	if st.Dict["ColorSpace"] != Name("DeviceCMYK") || st.Dict["Width"] != Integer(5) || st.Dict["Height"] != Integer(3) || len(st.Dict["Decode"].(Array)) != 8 {
+		t.Fatal(st.Dict)
+	}
+	if _, err := ImageJPEG(bytes.NewReader([]byte("nope"))); err == nil {
+		t.Fatal("no err")
+	}
+}
+
+func TestImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(3, 4, 5, 6))
+	img.Set(3, 4, color.RGBA{1, 2, 3, 255})
+	img.Set(4, 4, color.RGBA{4, 5, 6, 255})
+	img.Set(3, 5, color.RGBA{7, 8, 9, 255})
+	img.Set(4, 5, color.RGBA{10, 11, 12, 255})
+	obj, err := Image(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := EncodeObject(&out, obj); err != nil {
+		t.Fatal(err)
+	}
+	_, body := streamBody(t, out.Bytes())
+	zr, _ := zlib.NewReader(bytes.NewReader(body))
+	got, _ := io.ReadAll(zr)
+	if !bytes.Equal(got, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}) {
+		t.Fatal(got)
+	}
+	if _, err := Image(image.NewRGBA(image.Rect(0, 0, 0, 0))); err == nil {
+		t.Fatal("empty")
+	}
+}
+
+func TestAddImageSMask(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	img.SetNRGBA(1, 0, color.NRGBA{10, 20, 30, 128})
+	var d Document
+	ref, err := d.AddImage(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := lookup(&d, ref).(Stream)
+	mref, ok := st.Dict["SMask"].(Reference)
+	if !ok {
+		t.Fatal(st.Dict)
+	}
+	m := lookup(&d, mref).(Stream)
+	if m.Dict["Width"] != Integer(2) || m.Dict["Height"] != Integer(2) || m.Dict["ColorSpace"] != Name("DeviceGray") {
+		t.Fatal(m.Dict)
+	}
+	samples, _ := io.ReadAll(m.Data)
+	if !bytes.Equal(samples, []byte{255, 128, 255, 255}) {
+		t.Fatal(samples)
+	}
+	for i := range d.Body {
+		if d.Body[i].Name == string(mref) {
+			m.Data = bytes.NewReader(samples)
+			d.Body[i].Object = m
+		}
+	}
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Resources: Dict{"XObject": Dict{"Im0": ref}}, Contents: Stream{Data: bytes.NewReader([]byte("/Im0 Do"))}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+
+	var d2 Document
+	_, _ = d2.AddImage(image.NewGray(image.Rect(0, 0, 1, 1)))
+	if len(d2.Body) != 1 {
+		t.Fatal("opaque got mask")
+	}
+}
+
+func TestIndexedImage(t *testing.T) {
+	pal := make(color.Palette, 16)
+	for i := range pal {
+		pal[i] = color.RGBA{uint8(i * 16), uint8(i), 0, 255}
+	}
+	img := image.NewPaletted(image.Rect(0, 0, 3, 2), pal)
+	copy(img.Pix, []uint8{1, 2, 3, 15, 14, 13})
+	obj, err := Image(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := obj.(Stream)
+	cs := st.Dict["ColorSpace"].(Array)
+	if cs[0] != Name("Indexed") || cs[1] != Name("DeviceRGB") || cs[2] != Integer(15) || st.Dict["BitsPerComponent"] != Integer(4) {
+		t.Fatal(st.Dict)
+	}
+	var out bytes.Buffer
+	EncodeObject(&out, obj)
+	_, body := streamBody(t, out.Bytes())
+	zr, _ := zlib.NewReader(bytes.NewReader(body))
+	got, _ := io.ReadAll(zr)
+	if !bytes.Equal(got, []byte{0x12, 0x30, 0xFE, 0xD0}) {
+		t.Fatalf("%x", got)
+	}
+	obj, _ = Image(img)
+	var d Document
+	ref := d.Add(obj)
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Resources: Dict{"XObject": Dict{"Im0": ref}}, Contents: Stream{Data: bytes.NewReader([]byte("/Im0 Do"))}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	out.Reset()
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+	for n, want := range map[int]int{2: 1, 3: 2, 17: 8} {
+		p := make(color.Palette, n)
+		for i := range p {
+			p[i] = color.Black
+		}
+		obj, _ := Image(image.NewPaletted(image.Rect(0, 0, 1, 1), p))
+		if obj.(Stream).Dict["BitsPerComponent"] != Integer(want) {
+			t.Fatal(n)
+		}
+	}
+}
+
+func TestInlineImage(t *testing.T) {
+	var c Content
+	c.Save()
+	c.Concat(Scale(20, 20))
+	c.DrawInlineImage(InlineImage{Width: 2, Height: 2, BitsPerComponent: 8, ColorSpace: "DeviceRGB", Data: []byte{255, 0, 0, 0, 255, 0, 0, 0, 255, 255, 255, 255}})
+	c.Restore()
+	got := string(c.Bytes())
+	want := "BI\n/W 2\n/H 2\n/BPC 8\n/CS /RGB\n/L 12\nID \xff\x00\x00\x00\xff\x00\x00\x00\xff\xff\xff\xff\nEI\n"
+	if !strings.Contains(got, want) {
+		t.Fatalf("%q", got)
+	}
+	var c2 Content
+	c2.DrawInlineImage(InlineImage{Width: 4, Height: 1, BitsPerComponent: 8, ColorSpace: "DeviceGray", Filters: []Filter{FlateFilter{}}, Data: []byte(" EI ")})
+	got = string(c2.Bytes())
+	if !strings.Contains(got, "/F [/AHx /Fl]\n") {
+		t.Fatalf("%q", got)
+	}
+	var c3 Content
+	c3.DrawInlineImage(InlineImage{Width: 4, Height: 1, BitsPerComponent: 8, ColorSpace: "DeviceGray", Data: []byte(" EI ")})
+	got = string(c3.Bytes())
+	if !strings.Contains(got, "/F /AHx\n") || strings.Contains(got, " EI ") {
+		t.Fatalf("%q", got)
+	}
+	var c4 Content
+	c4.DrawInlineImage(InlineImage{Width: 8, Height: 1, ImageMask: true, Data: []byte{0x55}})
+	if !strings.Contains(string(c4.Bytes()), "/IM true\n/L 1\n") {
+		t.Fatalf("%q", c4.Bytes())
+	}
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+}
+
+func TestInlineImageEIDelimiter(t *testing.T) {
+	for _, raw := range [][]byte{[]byte("a EI/x"), []byte("b EI[c"), []byte("\nEI<")} {
+		var c Content
+		c.DrawInlineImage(InlineImage{Width: len(raw), Height: 1, BitsPerComponent: 8, ColorSpace: "DeviceGray", Data: raw})
+		// Readers that ignore /L must still find the whole image.
+		content := regexp.MustCompile(`/L \d+\s*`).ReplaceAll(c.Bytes(), nil)
+		ops, err := ParseContent(content)
+		if err != nil || len(ops) != 1 {
+			t.Fatalf("%q %v %v", content, ops, err)
+		}
+		st := ops[0].Operands[0].(Stream)
+		if st.Dict["F"] != Name("AHx") {
+			t.Fatalf("%q not hex encoded", raw)
+		}
+		data, _ := io.ReadAll(st.Data)
+		r, err := ASCIIHexFilter{}.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(got, raw) {
+			t.Fatalf("%q %q %v", got, raw, err)
+		}
+	}
+}
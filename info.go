@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf16"
+)
+
+// Info is a document information dictionary. Empty fields are omitted.
+type Info struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Creator  string
+	Producer string
+
+	CreationDate time.Time
+	ModDate      time.Time
+}
+
+func (info Info) encode(s *encodeState) error {
+	dict := make(Dict, 8)
+	for k, v := range map[Name]string{
+		"Title":    info.Title,
+		"Author":   info.Author,
+		"Subject":  info.Subject,
+		"Keywords": info.Keywords,
+		"Creator":  info.Creator,
+		"Producer": info.Producer,
+	} {
+		if v != "" {
+			dict[k] = TextString(v)
+		}
+	}
+	if !info.CreationDate.IsZero() {
+		dict["CreationDate"] = Date(info.CreationDate)
+	}
+	if !info.ModDate.IsZero() {
+		dict["ModDate"] = Date(info.ModDate)
+	}
+
+	return dict.encode(s)
+}
+
+// SetInfo adds info to the document as an indirect object and sets
+// d.Info to refer to it.
+func (d *Document) SetInfo(info Info) {
+	d.Info = d.Add(info)
+}
+
+// Date is a PDF date, which is encoded as a string of the form
+// D:YYYYMMDDHHmmSSOHH'mm, including the time's offset from UTC.
+type Date time.Time
+
+func (d Date) encode(s *encodeState) error {
+	t := time.Time(d)
+	str := t.Format("D:20060102150405")
+
+	_, offset := t.Zone()
+	if offset == 0 {
+		str += "Z"
+	} else {
+		sign := '+'
+		if offset < 0 {
+			sign, offset = '-', -offset
+		}
+		offset /= 60
+		str += fmt.Sprintf("%c%02d'%02d", sign, offset/60, offset%60)
+	}
+
+	return LiteralString(str).encode(s)
+}
+
+// TextString returns a string object containing s as a PDF text
+// string. Text that is entirely ASCII is stored as is, and anything
+// else is stored as UTF-16 with a byte order mark.
+func TextString(s string) Object {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return String([]byte(s))
+	}
+
+	buf := []byte{0xFE, 0xFF}
+	for _, u := range utf16.Encode([]rune(s)) {
+		buf = append(buf, byte(u>>8), byte(u))
+	}
+	return HexString(buf)
+}
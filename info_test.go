@@ -0,0 +1,48 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestDate(t *testing.T) {
+	for _, tc := range []struct {
+		tm   time.Time
+		want string
+	}{
+		{time.Date(2024, 3, 9, 14, 5, 6, 0, time.FixedZone("", -(7*3600+30*60))), "(D:20240309140506-07'30)"},
+		{time.Date(2024, 3, 9, 14, 5, 6, 0, time.FixedZone("", 5*3600+45*60)), "(D:20240309140506+05'45)"},
+		{time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC), "(D:19991231235959Z)"},
+	} {
+		var buf bytes.Buffer
+		if err := EncodeObject(&buf, Date(tc.tm)); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Fatal(buf.String())
+		}
+	}
+}
+
+func TestInfo(t *testing.T) {
+	var d Document
+	d.SetInfo(Info{Title: "Hello", Author: "Zoë", CreationDate: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)})
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`trailer\n<<.*/Info 1 0 R`).Match(out.Bytes()) {
+		t.Fatal("trailer")
+	}
+	if !bytes.Contains(out.Bytes(), []byte("1 0 obj\n<</Author <FEFF005A006F00EB> /CreationDate (D:20200102030405Z) /Title (Hello) >>")) {
+		t.Fatal("info")
+	}
+	validate(t, out.Bytes())
+}
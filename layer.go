@@ -0,0 +1,52 @@
+package pdf
+
+// AddLayer adds an optional content group to the document, which
+// viewers show as a layer named name that can be turned on and off. It
+// returns a reference to the group, which must be named in a resource
+// dictionary's Properties entry so that Content.BeginLayer can refer
+// to it. Whole XObjects can also be put in a layer, such as by setting
+// FormXObject.Layer.
+//
+// SetLayers must be called after all of the layers have been added.
+func (d *Document) AddLayer(name string) Reference {
+	return d.addDistinct(Dict{
+		"Type": Name("OCG"),
+		"Name": TextString(name),
+	})
+}
+
+// SetLayers adds the optional content properties to the catalog,
+// listing every layer that has been added to the document so far in
+// the order that they were added. The layers in hidden are initially
+// turned off, while the rest are on. d.Root must already be set to the
+// catalog, such as one returned by AddPages.
+func (d *Document) SetLayers(hidden ...Reference) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	var ocgs Array
+	for _, obj := range d.Body {
+		dict, ok := obj.Object.(Dict)
+		if !ok || (dict["Type"] != Name("OCG")) {
+			continue
+		}
+		ocgs = append(ocgs, Reference(obj.Name))
+	}
+
+	config := Dict{"Order": ocgs}
+	if len(hidden) > 0 {
+		off := make(Array, 0, len(hidden))
+		for _, ref := range hidden {
+			off = append(off, ref)
+		}
+		config["OFF"] = off
+	}
+
+	catalog["OCProperties"] = Dict{
+		"OCGs": ocgs,
+		"D":    config,
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLayers(t *testing.T) {
+	var d Document
+	a := d.AddLayer("Background")
+	b := d.AddLayer("Notes")
+	var fc Content
+	fc.Rectangle(0, 0, 5, 5)
+	fc.Fill()
+	form := d.Add(FormXObject{BBox: Rectangle{0, 0, 5, 5}, Content: fc.Stream(), Layer: b})
+	var c Content
+	c.BeginLayer("L0")
+	c.Rectangle(0, 0, 100, 100)
+	c.Fill()
+	c.EndLayer()
+	c.BeginLayer("L1")
+	c.DrawXObject("F")
+	c.EndLayer()
+	got := string(c.Bytes())
+	if !strings.HasPrefix(got, "/OC /L0 BDC\n") || !strings.Contains(got, "EMC\n/OC /L1 BDC\n/F Do\nEMC\n") {
+		t.Fatal(got)
+	}
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{
+		"Properties": Dict{"L0": a, "L1": b},
+		"XObject":    Dict{"F": form},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetLayers(b); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if !bytes.Contains(data, []byte("/OCProperties <</D <</OFF [2 0 R] /Order [1 0 R 2 0 R] >> /OCGs [1 0 R 2 0 R] >>")) || !bytes.Contains(data, []byte("/OC 2 0 R")) {
+		t.Fatal(string(data))
+	}
+	validate(t, data)
+}
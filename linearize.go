@@ -0,0 +1,630 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"math/bits"
+	"slices"
+)
+
+// encodeLinearized writes the body of p as a linearized file after the
+// header, which must have already been written.
+//
+// The file is laid out as the specification requires: the
+// linearization parameter dictionary and the cross-reference section
+// for the first page come first, followed by the catalog, the hint
+// stream, and the first page along with everything that it uses. Then
+// come the remaining pages, each followed by the objects that only it
+// uses, the objects that are shared between those pages, everything
+// else, and finally the main cross-reference section. The first of
+// those sections is numbered after all of the others.
+func (s *encodeState) encodeLinearized(p *PDF) error {
+	if p.XrefStream || p.ObjectStreams {
+		return errors.New("pdf: linearized files cannot use cross-reference streams")
+	}
+
+	objs := make(map[string]Indirect, len(p.Body))
+	for _, obj := range p.Body {
+		if _, ok := objs[obj.Name]; ok {
+			return fmt.Errorf("pdf: duplicate object name %q", obj.Name)
+		}
+		objs[obj.Name] = obj
+	}
+	catalog, ok := objs[string(p.Root)].Object.(Dict)
+	if !ok {
+		return errors.New("pdf: PDF.Root does not refer to a catalog")
+	}
+
+	pages, stop := pageTree(objs, catalog)
+	if len(pages) == 0 {
+		return errors.New("pdf: cannot linearize a document without pages")
+	}
+	stop[string(p.Root)] = true
+
+	// Sort the objects into the sections that they belong in, keeping
+	// track of which pages use which of the objects that aren't in a
+	// page's own section.
+	uses := make([][]string, len(pages))
+	for i, page := range pages {
+		roots := append([]Object{objs[page.name].Object}, page.inherited...)
+		var err error
+		uses[i], err = closure(objs, stop, roots...)
+		if err != nil {
+			return err
+		}
+	}
+	first := append([]string{pages[0].name}, uses[0]...)
+	assigned := map[string]bool{string(p.Root): true}
+	for _, name := range first {
+		assigned[name] = true
+	}
+
+	users := make(map[string]int)
+	for _, names := range uses[1:] {
+		for _, name := range names {
+			users[name]++
+		}
+	}
+	sections := make([][]string, len(pages)-1)
+	for i, page := range pages[1:] {
+		sections[i] = []string{page.name}
+		assigned[page.name] = true
+		for _, name := range uses[i+1] {
+			if !assigned[name] && (users[name] == 1) {
+				sections[i] = append(sections[i], name)
+				assigned[name] = true
+			}
+		}
+	}
+	var shared []string
+	for _, names := range uses[1:] {
+		for _, name := range names {
+			if !assigned[name] {
+				shared = append(shared, name)
+				assigned[name] = true
+			}
+		}
+	}
+	var other []string
+	for _, obj := range p.Body {
+		if !assigned[obj.Name] {
+			other = append(other, obj.Name)
+		}
+	}
+
+	// Number everything in file order, but with the first page's
+	// section after the rest, which the main cross-reference section
+	// covers.
+	var main []string
+	for _, sec := range sections {
+		main = append(main, sec...)
+	}
+	main = append(main, shared...)
+	main = append(main, other...)
+	for i, name := range main {
+		s.names[name] = objID{num: i + 1, gen: objs[name].Generation}
+	}
+	linNum := len(main) + 1
+	s.names[string(p.Root)] = objID{num: linNum + 1, gen: objs[string(p.Root)].Generation}
+	hintNum := linNum + 2
+	s.unnamed = 2
+	if s.crypt != nil {
+		// The encryption dictionary is needed to read anything at all,
+		// so it goes with the catalog.
+		s.crypt.num = hintNum
+		hintNum++
+		s.unnamed++
+	}
+	for i, name := range first {
+		s.names[name] = objID{num: hintNum + 1 + i, gen: objs[name].Generation}
+	}
+	size := len(s.names) + s.unnamed + 1
+
+	var crypt []byte
+	if s.crypt != nil {
+		var buf bytes.Buffer
+		sub := s.with(&buf)
+		err := sub.encodeUnnamed(s.crypt.num, s.crypt.dict)
+		if err != nil {
+			return err
+		}
+		err = sub.Flush()
+		if err != nil {
+			return err
+		}
+		sub.release()
+		crypt = buf.Bytes()
+	}
+
+	data := make(map[string][]byte, len(objs))
+	for i, obj := range p.Body {
+		err := s.ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		sub := s.with(&buf)
+		err = obj.encode(sub)
+		if err != nil {
+			return atPath(err, fmt.Sprintf("Body[%v]", i))
+		}
+		_, err = sub.WriteString("\n")
+		if err != nil {
+			return err
+		}
+		err = sub.Flush()
+		if err != nil {
+			return err
+		}
+		sub.release()
+		data[obj.Name] = buf.Bytes()
+	}
+
+	// The parameter dictionary, the first page's cross-reference
+	// section, and the hint stream all depend on where everything ends
+	// up, including themselves, so space is reserved for them and
+	// increased until they fit, with any leftover filled with spaces.
+	var l linLayout
+	var lin, xref1, hint, xref []byte
+	for {
+		l.place(s, s.offset(), data, string(p.Root), crypt, first, sections, shared, other)
+		l.offsets[linNum] = l.lin
+		l.offsets[hintNum] = l.hint
+
+		var err error
+		lin, err = s.linDict(linNum, l, s.names[pages[0].name].num, len(pages))
+		if err != nil {
+			return err
+		}
+		xref1, err = s.linXref(linNum, size-linNum, l, p)
+		if err != nil {
+			return err
+		}
+		hint, err = s.linHints(hintNum, l, data, pages, uses, first, sections, shared)
+		if err != nil {
+			return err
+		}
+		if (len(lin) <= l.linLen) && (len(xref1) <= l.xref1Len) && (len(hint) <= l.hintLen) {
+			break
+		}
+		l.linLen = max(l.linLen, len(lin))
+		l.xref1Len = max(l.xref1Len, len(xref1))
+		l.hintLen = max(l.hintLen, len(hint))
+	}
+	xref = l.mainXref()
+
+	for num, off := range l.offsets {
+		s.offsets[num] = off
+	}
+	write := func(data []byte, n int) error {
+		_, err := s.Write(data)
+		if err != nil {
+			return err
+		}
+		_, err = s.Write(bytes.Repeat([]byte{' '}, n-len(data)))
+		return err
+	}
+	err := write(lin, l.linLen)
+	if err != nil {
+		return err
+	}
+	err = write(xref1, l.xref1Len)
+	if err != nil {
+		return err
+	}
+	_, err = s.Write(data[string(p.Root)])
+	if err != nil {
+		return err
+	}
+	_, err = s.Write(crypt)
+	if err != nil {
+		return err
+	}
+	err = write(hint, l.hintLen)
+	if err != nil {
+		return err
+	}
+	for _, name := range first {
+		_, err := s.Write(data[name])
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range main {
+		_, err := s.Write(data[name])
+		if err != nil {
+			return err
+		}
+	}
+	_, err = s.Write(xref)
+	if err != nil {
+		return err
+	}
+
+	s.startxref = l.xref1
+	return s.Flush()
+}
+
+// treePage is a page found in a page tree.
+type treePage struct {
+	name string
+
+	// inherited holds the resources of the page's ancestors, which it
+	// may use without having its own.
+	inherited []Object
+
+	// attrs holds the other inheritable attributes that the page's
+	// ancestors set, taken from the nearest one that sets each.
+	attrs Dict
+}
+
+// inheritableAttrs are the page attributes other than Resources that
+// pages can inherit from their ancestors in a page tree.
+var inheritableAttrs = []Name{"MediaBox", "CropBox", "Rotate"}
+
+// pageTree returns the pages in the page tree of catalog, in order,
+// along with the set of names of all of the nodes of the tree.
+func pageTree(objs map[string]Indirect, catalog Dict) (pages []treePage, nodes map[string]bool) {
+	nodes = make(map[string]bool)
+	var walk func(obj Object, inherited []Object, attrs Dict)
+	walk = func(obj Object, inherited []Object, attrs Dict) {
+		ref, ok := obj.(Reference)
+		if !ok || nodes[string(ref)] {
+			return
+		}
+		dict, ok := objs[string(ref)].Object.(Dict)
+		if !ok {
+			return
+		}
+		nodes[string(ref)] = true
+
+		if dict["Type"] != Name("Pages") {
+			pages = append(pages, treePage{name: string(ref), inherited: inherited, attrs: attrs})
+			return
+		}
+		if res, ok := dict["Resources"]; ok {
+			inherited = append(inherited[:len(inherited):len(inherited)], res)
+		}
+		var cloned bool
+		for _, k := range inheritableAttrs {
+			v, ok := dict[k]
+			if !ok {
+				continue
+			}
+			if !cloned {
+				attrs = maps.Clone(attrs)
+				if attrs == nil {
+					attrs = make(Dict, len(inheritableAttrs))
+				}
+				cloned = true
+			}
+			attrs[k] = v
+		}
+		kids, _ := dict["Kids"].(Array)
+		for _, kid := range kids {
+			walk(kid, inherited, attrs)
+		}
+	}
+	walk(catalog["Pages"], nil, nil)
+	return pages, nodes
+}
+
+// closure returns the names of the objects in objs that roots refer
+// to, directly or indirectly, in the order that they are found,
+// without following references to objects named in stop.
+func closure(objs map[string]Indirect, stop map[string]bool, roots ...Object) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	for len(roots) > 0 {
+		obj := roots[0]
+		roots = roots[1:]
+		err := references(obj, func(ref Reference) {
+			name := string(ref)
+			ind, ok := objs[name]
+			if !ok || seen[name] || stop[name] {
+				return
+			}
+			seen[name] = true
+			names = append(names, name)
+			roots = append(roots, ind.Object)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// linLayout is the location of everything in a linearized file.
+type linLayout struct {
+	// linLen, xref1Len, and hintLen are the space reserved for the
+	// parts of the file that depend on the layout.
+	linLen, xref1Len, hintLen int
+
+	lin, xref1, hint int64
+
+	// end is the offset of the end of the first page's section, and
+	// xref the offset of the main cross-reference section, which
+	// covers the first count objects.
+	end, xref int64
+	count     int
+
+	// size is the total size of the file.
+	size int64
+
+	offsets map[int]int64
+	gens    map[int]int
+}
+
+// place lays out the file given the space reserved in l, starting at
+// off.
+func (l *linLayout) place(s *encodeState, off int64, data map[string][]byte, catalog string, crypt []byte, first []string, sections [][]string, shared, other []string) {
+	l.offsets = make(map[int]int64)
+	l.gens = make(map[int]int)
+	put := func(name string) {
+		id := s.names[name]
+		l.offsets[id.num] = off
+		l.gens[id.num] = id.gen
+		off += int64(len(data[name]))
+	}
+
+	l.lin = off
+	off += int64(l.linLen)
+	l.xref1 = off
+	off += int64(l.xref1Len)
+	put(catalog)
+	if crypt != nil {
+		l.offsets[s.crypt.num] = off
+		off += int64(len(crypt))
+	}
+	l.hint = off
+	off += int64(l.hintLen)
+	for _, name := range first {
+		put(name)
+	}
+	l.end = off
+
+	l.count = 0
+	for _, sec := range sections {
+		for _, name := range sec {
+			put(name)
+		}
+		l.count += len(sec)
+	}
+	for _, name := range shared {
+		put(name)
+	}
+	for _, name := range other {
+		put(name)
+	}
+	l.count += len(shared) + len(other)
+	l.xref = off
+
+	l.size = off + int64(len(l.mainXref()))
+}
+
+// hintOffset returns off adjusted in the way that offsets in hint
+// tables are, which is as if the hint stream wasn't there.
+func (l linLayout) hintOffset(off int64) int64 {
+	if off > l.hint {
+		off -= int64(l.hintLen)
+	}
+	return off
+}
+
+// linHints returns the primary hint stream, which is object number
+// num, for the given layout. It contains a page offset hint table and
+// a shared object hint table, in which every object is in a group of
+// its own.
+//
+// As is common practice, the content stream of each page is described
+// as being the entire page, rather than just the page's actual
+// content stream.
+func (s *encodeState) linHints(num int, l linLayout, data map[string][]byte, pages []treePage, uses [][]string, first []string, sections [][]string, shared []string) ([]byte, error) {
+	size := func(names []string) (n int64) {
+		for _, name := range names {
+			n += int64(len(data[name]))
+		}
+		return n
+	}
+
+	groups := make(map[string]int, len(first)+len(shared))
+	lengths := make([]int64, 0, len(first)+len(shared))
+	for _, name := range append(first[:len(first):len(first)], shared...) {
+		groups[name] = len(lengths)
+		lengths = append(lengths, int64(len(data[name])))
+	}
+
+	type pageHint struct {
+		objects int
+		length  int64
+		shared  []int
+	}
+	hints := make([]pageHint, len(pages))
+	hints[0] = pageHint{objects: len(first), length: size(first)}
+	for i, sec := range sections {
+		h := pageHint{objects: len(sec), length: size(sec)}
+		for _, name := range uses[i+1] {
+			if g, ok := groups[name]; ok {
+				h.shared = append(h.shared, g)
+			}
+		}
+		hints[i+1] = h
+	}
+
+	minObjects, maxObjects := hints[0].objects, hints[0].objects
+	minLength, maxLength := hints[0].length, hints[0].length
+	var maxShared int
+	for _, h := range hints {
+		minObjects, maxObjects = min(minObjects, h.objects), max(maxObjects, h.objects)
+		minLength, maxLength = min(minLength, h.length), max(maxLength, h.length)
+		maxShared = max(maxShared, len(h.shared))
+	}
+	objectBits := bits.Len(uint(maxObjects - minObjects))
+	lengthBits := bits.Len64(uint64(maxLength - minLength))
+	sharedBits := bits.Len(uint(maxShared))
+	groupBits := bits.Len(uint(len(lengths) - 1))
+
+	var w bitWriter
+	w.write(uint64(minObjects), 32)
+	w.write(uint64(l.hintOffset(l.offsets[s.names[pages[0].name].num])), 32)
+	w.write(uint64(objectBits), 16)
+	w.write(uint64(minLength), 32)
+	w.write(uint64(lengthBits), 16)
+	w.write(0, 32)
+	w.write(0, 16)
+	w.write(uint64(minLength), 32)
+	w.write(uint64(lengthBits), 16)
+	w.write(uint64(sharedBits), 16)
+	w.write(uint64(groupBits), 16)
+	w.write(0, 16)
+	w.write(1, 16)
+
+	// Each item is given for every page before moving on to the next
+	// one, and each such run of items starts on a byte boundary.
+	for _, h := range hints {
+		w.write(uint64(h.objects-minObjects), objectBits)
+	}
+	w.align()
+	for _, h := range hints {
+		w.write(uint64(h.length-minLength), lengthBits)
+	}
+	w.align()
+	for _, h := range hints {
+		w.write(uint64(len(h.shared)), sharedBits)
+	}
+	w.align()
+	for _, h := range hints {
+		for _, g := range h.shared {
+			w.write(uint64(g), groupBits)
+		}
+	}
+	w.align()
+
+	// The numerators of the shared objects' positions and the content
+	// stream offsets are all zero, so they take no bits at all.
+	for _, h := range hints {
+		w.write(uint64(h.length-minLength), lengthBits)
+	}
+	w.align()
+	pageTable := len(w.buf)
+
+	minGroup, maxGroup := slices.Min(lengths), slices.Max(lengths)
+	groupLengthBits := bits.Len64(uint64(maxGroup - minGroup))
+	var sharedNum int
+	var sharedOff int64
+	if len(shared) > 0 {
+		sharedNum = s.names[shared[0]].num
+		sharedOff = l.hintOffset(l.offsets[sharedNum])
+	}
+	w.write(uint64(sharedNum), 32)
+	w.write(uint64(sharedOff), 32)
+	w.write(uint64(len(first)), 32)
+	w.write(uint64(len(lengths)), 32)
+	w.write(0, 16)
+	w.write(uint64(minGroup), 32)
+	w.write(uint64(groupLengthBits), 16)
+	for _, n := range lengths {
+		w.write(uint64(n-minGroup), groupLengthBits)
+	}
+	w.align()
+	for range lengths {
+		w.write(0, 1)
+	}
+	w.align()
+
+	var buf bytes.Buffer
+	sub := s.with(&buf)
+	defer sub.release()
+	st := FlateBytes(w.buf)
+	st.Dict = Dict{"S": Integer(pageTable)}
+	err := sub.encodeUnnamed(num, st)
+	if err != nil {
+		return nil, err
+	}
+	err = sub.Flush()
+	return buf.Bytes(), err
+}
+
+// bitWriter packs integers of arbitrary bit widths together, most
+// significant bit first.
+type bitWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *bitWriter) write(v uint64, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.n == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>i&1 != 0 {
+			w.buf[len(w.buf)-1] |= 0x80 >> w.n
+		}
+		w.n = (w.n + 1) % 8
+	}
+}
+
+// align pads the output to a byte boundary.
+func (w *bitWriter) align() {
+	w.n = 0
+}
+
+// linDict returns the linearization parameter dictionary, which is
+// object number num, for the given layout.
+func (s *encodeState) linDict(num int, l linLayout, page, pages int) ([]byte, error) {
+	var buf bytes.Buffer
+	sub := s.with(&buf)
+	defer sub.release()
+	err := sub.encodeUnnamed(num, Dict{
+		"Linearized": Integer(1),
+		"L":          Integer(l.size),
+		"H":          Array{Integer(l.hint), Integer(l.hintLen)},
+		"O":          Integer(page),
+		"E":          Integer(l.end),
+		"N":          Integer(pages),
+		"T":          Integer(l.xref + int64(len(fmt.Sprintf("xref\n0 %v", l.count+1)))),
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = sub.Flush()
+	return buf.Bytes(), err
+}
+
+// linXref returns the cross-reference section for the first page,
+// which covers count objects starting at num, and its trailer.
+func (s *encodeState) linXref(num, count int, l linLayout, p *PDF) ([]byte, error) {
+	var buf bytes.Buffer
+	sub := s.with(&buf)
+	defer sub.release()
+	fmt.Fprintf(sub, "xref\n%v %v\n", num, count)
+	for i := num; i < num+count; i++ {
+		fmt.Fprintf(sub, "%010d %05d n \n", l.offsets[i], l.gens[i])
+	}
+
+	trailer := s.trailer(p, num+count)
+	trailer["Prev"] = Integer(l.xref)
+	sub.WriteString("trailer\n")
+	err := trailer.encode(sub)
+	if err != nil {
+		return nil, err
+	}
+	sub.WriteString("\nstartxref\n0\n%%EOF\n")
+	err = sub.Flush()
+	return buf.Bytes(), err
+}
+
+// mainXref returns the main cross-reference section and its trailer.
+func (l linLayout) mainXref() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "xref\n0 %v\n0000000000 65535 f \n", l.count+1)
+	for i := 1; i <= l.count; i++ {
+		fmt.Fprintf(&buf, "%010d %05d n \n", l.offsets[i], l.gens[i])
+	}
+	fmt.Fprintf(&buf, "trailer\n<</Size %v >>\nstartxref\n%v\n%%%%EOF\n", l.count+1, l.xref1)
+	return buf.Bytes()
+}
@@ -0,0 +1,194 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+type bitReader struct {
+	b []byte
+	n int
+}
+
+func (r *bitReader) read(bits int) uint64 {
+	var v uint64
+	for i := 0; i < bits; i++ {
+		bit := r.b[r.n/8] >> (7 - r.n%8) & 1
+		v = v<<1 | uint64(bit)
+		r.n++
+	}
+	return v
+}
+
+func (r *bitReader) align() { r.n = (r.n + 7) / 8 * 8 }
+
+func TestLinearize(t *testing.T) {
+	var d Document
+	f1 := d.Add(Helvetica.Dict())
+	f2 := d.Add(Dict{"Type": Name("Font"), "Subtype": Name("Type1"), "BaseFont": Name("Courier")})
+	var ps []Page
+	for i := 0; i < 4; i++ {
+		var c Content
+		c.BeginText()
+		c.SetFont("F1", 12)
+		c.ShowText("page " + strconv.Itoa(i))
+		c.EndText()
+		fonts := Dict{"F1": f1}
+		if i > 0 {
+			fonts = Dict{"F1": f2}
+		}
+		ps = append(ps, Page{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"Font": fonts}})
+	}
+	cat, _, err := d.AddPages(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = cat
+	d.SetInfo(Info{Title: "Lin"})
+	d.Linearize = true
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	validate(t, data)
+
+	m := regexp.MustCompile(`^%PDF-1\.7\n%[^\n]*\n(\d+) 0 obj\n<<([^>]*)>>`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("no lin dict at start")
+	}
+	p, err := DecodeObject(bytes.NewReader(data[len("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n"):]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lin := p.(Indirect).Object.(Dict)
+	if lin["Linearized"] != Integer(1) || lin["L"] != Integer(len(data)) || lin["N"] != Integer(4) {
+		t.Fatal(lin)
+	}
+	q, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Body) != len(d.Body) {
+		t.Fatal(len(q.Body), len(d.Body))
+	}
+
+	// Page object offsets in order.
+	dd := newDecoder(bytes.NewReader(data), int64(len(data)))
+	if _, err := dd.readXrefs(); err != nil {
+		t.Fatal(err)
+	}
+	var pageOffs []int64
+	var pageNums []int
+	for num, e := range dd.entries {
+		if e.free {
+			continue
+		}
+		obj, err := dd.readObject(objID{num, e.gen}, e.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dict, ok := obj.Object.(Dict); ok && dict["Type"] == Name("Page") {
+			pageOffs = append(pageOffs, e.offset)
+			pageNums = append(pageNums, num)
+		}
+	}
+	o := int(lin["O"].(Integer))
+	var firstOff int64
+	for i, n := range pageNums {
+		if n == o {
+			firstOff = pageOffs[i]
+		}
+	}
+	for _, off := range pageOffs {
+		if off < firstOff {
+			t.Fatal("first page not first")
+		}
+	}
+	if firstOff > int64(lin["E"].(Integer)) {
+		t.Fatal("E")
+	}
+
+	// Hint stream.
+	h := lin["H"].(Array)
+	ho, hl := int64(h[0].(Integer)), int64(h[1].(Integer))
+	hp := newParser(bytes.NewReader(data[ho:]))
+	hobjx, err := hp.parseObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := hobjx.(Indirect).Object.(Stream)
+	zr, _ := zlib.NewReader(st.Data)
+	hd, _ := io.ReadAll(zr)
+	r := &bitReader{b: hd}
+	minObjs := r.read(32)
+	firstLoc := r.read(32)
+	objBits := r.read(16)
+	minLen := r.read(32)
+	lenBits := r.read(16)
+	r.read(32)
+	r.read(16)
+	r.read(32)
+	r.read(16)
+	r.read(16)
+	r.read(16)
+	r.read(16)
+	r.read(16)
+	if int64(firstLoc) != firstOff-hl {
+		t.Fatal("first loc", firstLoc, firstOff-hl)
+	}
+	var lens []uint64
+	for i := 0; i < 4; i++ {
+		if minObjs+r.read(int(objBits)) == 0 {
+			t.Fatal("page", i, "has no objects")
+		}
+	}
+	r.align()
+	for i := 0; i < 4; i++ {
+		lens = append(lens, minLen+r.read(int(lenBits)))
+	}
+	// Page i+1 starts at page i + length (adjusted).
+	var sorted []int64
+	for _, off := range pageOffs {
+		sorted = append(sorted, off-hl)
+	}
+	slices.Sort(sorted)
+	pos := int64(firstLoc)
+	E := int64(lin["E"].(Integer)) - hl
+	for i := 0; i < 4; i++ {
+		if pos != sorted[i] {
+			t.Fatal("page", i, pos, sorted[i])
+		}
+		pos += int64(lens[i])
+		if i == 0 && pos != E {
+			t.Fatal("E mismatch", pos, E)
+		}
+	}
+	if s := int(st.Dict["S"].(Integer)); s <= 0 || s >= len(hd) {
+		t.Fatal("S", s)
+	}
+}
+
+func TestLinearizeOnePage(t *testing.T) {
+	var d Document
+	cat, _, err := d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = cat
+	d.Linearize = true
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+	d.XrefStream = true
+	if _, err := d.Finish(&out); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,250 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// LZWFilter is the LZWDecode filter, which compresses data using the
+// variable-width LZW scheme used by TIFF.
+type LZWFilter struct {
+	// DisableEarlyChange causes the code width to be increased one code
+	// later than is normal, corresponding to an EarlyChange parameter
+	// of 0. Some old readers require this.
+	DisableEarlyChange bool
+}
+
+func (LZWFilter) Name() Name {
+	return "LZWDecode"
+}
+
+// Params returns the decode parameters for the filter, or nil if the
+// defaults are being used.
+func (f LZWFilter) Params() Dict {
+	if !f.DisableEarlyChange {
+		return nil
+	}
+
+	return Dict{"EarlyChange": Integer(0)}
+}
+
+func (f LZWFilter) Encode(w io.Writer) (io.WriteCloser, error) {
+	return &lzwWriter{w: w, early: f.early()}, nil
+}
+
+func (f LZWFilter) Decode(r io.Reader) (io.Reader, error) {
+	return &lzwReader{r: bufio.NewReader(r), early: f.early()}, nil
+}
+
+func (f LZWFilter) early() int {
+	if f.DisableEarlyChange {
+		return 0
+	}
+	return 1
+}
+
+const (
+	lzwClear    = 256
+	lzwEOD      = 257
+	lzwFirst    = 258
+	lzwMinWidth = 9
+	lzwMaxWidth = 12
+	lzwMaxCodes = 1 << lzwMaxWidth
+)
+
+// lzwWidth returns the code width in use by a decoder whose next code
+// is next.
+func lzwWidth(next, early int) uint {
+	width := uint(lzwMinWidth)
+	for (next+early >= 1<<width) && (width < lzwMaxWidth) {
+		width++
+	}
+	return width
+}
+
+// lzwWriter collects all of the data written to it and compresses it
+// when closed.
+type lzwWriter struct {
+	w     io.Writer
+	early int
+	buf   bytes.Buffer
+
+	out   []byte
+	bits  uint32
+	nbits uint
+}
+
+func (w *lzwWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+// emit writes code using the width that a decoder whose next code is
+// next will expect.
+func (w *lzwWriter) emit(code, next int) {
+	width := lzwWidth(next, w.early)
+	w.bits |= uint32(code) << (32 - width - w.nbits)
+	w.nbits += width
+	for w.nbits >= 8 {
+		w.out = append(w.out, byte(w.bits>>24))
+		w.bits <<= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *lzwWriter) Close() error {
+	data := w.buf.Bytes()
+
+	// The encoder's table runs one entry ahead of the decoder's, as the
+	// decoder can't add an entry until it sees the code after it.
+	// dnext tracks the decoder's side so that code widths line up.
+	table := make(map[int]int)
+	next, dnext := lzwFirst, lzwFirst
+	w.emit(lzwClear, dnext)
+
+	prefix, n := -1, 0
+	for _, c := range data {
+		if prefix < 0 {
+			prefix = int(c)
+			continue
+		}
+
+		key := prefix<<8 | int(c)
+		if code, ok := table[key]; ok {
+			prefix = code
+			continue
+		}
+
+		w.emit(prefix, dnext)
+		if n > 0 {
+			dnext++
+		}
+		n++
+
+		table[key] = next
+		next++
+		prefix = int(c)
+
+		if next == lzwMaxCodes {
+			w.emit(lzwClear, dnext)
+			clear(table)
+			next, dnext, n = lzwFirst, lzwFirst, 0
+		}
+	}
+	if prefix >= 0 {
+		w.emit(prefix, dnext)
+		if n > 0 {
+			dnext++
+		}
+	}
+	w.emit(lzwEOD, dnext)
+
+	if w.nbits > 0 {
+		w.out = append(w.out, byte(w.bits>>24))
+	}
+
+	_, err := w.w.Write(w.out)
+	return err
+}
+
+type lzwReader struct {
+	r     *bufio.Reader
+	early int
+
+	bits  uint32
+	nbits uint
+
+	table [][]byte
+	prev  []byte
+	buf   []byte
+	eod   bool
+}
+
+func (r *lzwReader) Read(data []byte) (int, error) {
+	for (len(r.buf) == 0) && !r.eod {
+		err := r.next()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(r.buf) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(data, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *lzwReader) reset() {
+	if r.table == nil {
+		r.table = make([][]byte, lzwFirst, lzwMaxCodes)
+		for i := range 256 {
+			r.table[i] = []byte{byte(i)}
+		}
+	}
+	r.table = r.table[:lzwFirst]
+	r.prev = nil
+}
+
+func (r *lzwReader) code() (int, error) {
+	width := lzwWidth(len(r.table), r.early)
+	for r.nbits < width {
+		c, err := r.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		r.bits |= uint32(c) << (24 - r.nbits)
+		r.nbits += 8
+	}
+
+	code := int(r.bits >> (32 - width))
+	r.bits <<= width
+	r.nbits -= width
+	return code, nil
+}
+
+// next decodes the next code into r.buf.
+func (r *lzwReader) next() error {
+	if r.table == nil {
+		r.reset()
+	}
+
+	code, err := r.code()
+	if err == io.EOF {
+		// Tolerate a missing end-of-data code.
+		r.eod = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case code == lzwClear:
+		r.reset()
+		return nil
+
+	case code == lzwEOD:
+		r.eod = true
+		return nil
+
+	case code < len(r.table):
+		r.buf = r.table[code]
+		if (r.prev != nil) && (len(r.table) < lzwMaxCodes) {
+			r.table = append(r.table, append(r.prev[:len(r.prev):len(r.prev)], r.buf[0]))
+		}
+
+	case (code == len(r.table)) && (r.prev != nil):
+		r.buf = append(r.prev[:len(r.prev):len(r.prev)], r.prev[0])
+		r.table = append(r.table, r.buf)
+
+	default:
+		return errors.New("pdf: invalid code in LZWDecode data")
+	}
+
+	r.prev = r.buf
+	return nil
+}
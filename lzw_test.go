@@ -0,0 +1,53 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/lzw"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLZW(t *testing.T) {
+	text := []byte(strings.Repeat("TOBEORNOTTOBEORTOBEORNOT#", 2000))
+	bin := make([]byte, 50000)
+	rand.Read(bin)
+	for _, f := range []LZWFilter{{}, {DisableEarlyChange: true}} {
+		for _, in := range [][]byte{nil, {1}, {1, 1, 1, 1}, text, bin, append(bytes.Repeat([]byte{0}, 10000), bin[:9000]...)} {
+			roundTrip(t, f, in)
+		}
+	}
+	// Known vector from the PDF spec: 45 45 45 45 45 65 45 45 45 66 with EarlyChange 1
+	var buf bytes.Buffer
+	w, _ := LZWFilter{}.Encode(&buf)
+	w.Write([]byte{45, 45, 45, 45, 45, 65, 45, 45, 45, 66})
+	w.Close()
+	want := []byte{0x80, 0x0B, 0x60, 0x50, 0x22, 0x0C, 0x0C, 0x85, 0x01}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("% X", buf.Bytes())
+	}
+
+	var out bytes.Buffer
+	EncodeObject(&out, Stream{Filters: []Filter{LZWFilter{}}, Data: strings.NewReader("x")})
+	if strings.Contains(out.String(), "EarlyChange") {
+		t.Fatal(out.String())
+	}
+	out.Reset()
+	EncodeObject(&out, Stream{Filters: []Filter{LZWFilter{DisableEarlyChange: true}}, Data: strings.NewReader("x")})
+	if !strings.Contains(out.String(), "/DecodeParms <</EarlyChange 0 >>") {
+		t.Fatal(out.String())
+	}
+}
+
+func TestLZWStd(t *testing.T) {
+	in := []byte(strings.Repeat("TOBEORNOTTOBEORTOBEORNOT#abcdefghijklmnopq", 5000))
+	var buf bytes.Buffer
+	w, _ := LZWFilter{DisableEarlyChange: true}.Encode(&buf)
+	w.Write(in)
+	w.Close()
+	out, err := io.ReadAll(lzw.NewReader(&buf, lzw.MSB, 8))
+	if err != nil || !bytes.Equal(out, in) {
+		t.Fatal(err, len(out))
+	}
+}
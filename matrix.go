@@ -0,0 +1,50 @@
+package pdf
+
+import "math"
+
+// Matrix is a transformation matrix [a b c d e f], mapping (x, y) to
+// (a*x + c*y + e, b*x + d*y + f).
+type Matrix [6]float64
+
+// Identity is the matrix that leaves coordinates unchanged.
+var Identity = Matrix{1, 0, 0, 1, 0, 0}
+
+// Translate returns a matrix that moves coordinates by (x, y).
+func Translate(x, y float64) Matrix {
+	return Matrix{1, 0, 0, 1, x, y}
+}
+
+// Scale returns a matrix that scales coordinates by sx horizontally
+// and sy vertically.
+func Scale(sx, sy float64) Matrix {
+	return Matrix{sx, 0, 0, sy, 0, 0}
+}
+
+// Rotate returns a matrix that rotates coordinates counterclockwise
+// about the origin by the angle r, in radians.
+func Rotate(r float64) Matrix {
+	sin, cos := math.Sincos(r)
+	return Matrix{cos, sin, -sin, cos, 0, 0}
+}
+
+// Mul returns the product of m and n, which is the transformation
+// that applies m first and then n.
+func (m Matrix) Mul(n Matrix) Matrix {
+	return Matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// Apply returns the result of transforming (x, y) by m.
+func (m Matrix) Apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+func (m Matrix) encode(s *encodeState) error {
+	return Array{Real(m[0]), Real(m[1]), Real(m[2]), Real(m[3]), Real(m[4]), Real(m[5])}.encode(s)
+}
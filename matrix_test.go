@@ -0,0 +1,30 @@
+package pdf
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	m := Translate(10, 20).Mul(Scale(2, 3))
+	if m != (Matrix{2, 0, 0, 3, 20, 60}) {
+		t.Fatal(m)
+	}
+	x, y := m.Apply(1, 1)
+	if x != 22 || y != 63 {
+		t.Fatal(x, y)
+	}
+	r := Rotate(math.Pi / 2)
+	x, y = r.Apply(1, 0)
+	if math.Abs(x) > 1e-12 || math.Abs(y-1) > 1e-12 {
+		t.Fatal(x, y)
+	}
+	if Identity.Mul(m) != m || m.Mul(Identity) != m {
+		t.Fatal("identity")
+	}
+	var c Content
+	c.Concat(Matrix{1, 2, 3, 4, 5, 6.5})
+	if string(c.Bytes()) != "1 2 3 4 5 6.5 cm\n" {
+		t.Fatalf("%q", c.Bytes())
+	}
+}
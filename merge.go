@@ -0,0 +1,160 @@
+package pdf
+
+import "errors"
+
+// Merge appends the pages of src, such as a document read with Decode,
+// to the page tree of dst, along with the objects that they use, such
+// as fonts and images. The objects are renamed as they are added, with
+// dst.Add, so that they are deduplicated if dst.Dedup is set. Pages
+// keep any attributes that they inherited in src, such as their
+// MediaBox, and don't inherit those of dst's page tree. Other parts of
+// src, such as its outline and form, are left out, and references to
+// objects that aren't copied become null.
+//
+// The data of src's streams is shared with dst, so src can't be
+// encoded after being merged. dst.Root must already be set to a
+// catalog with a page tree, such as one returned by AddPages.
+func Merge(dst *Document, src *PDF) error {
+	catalog, err := dst.catalog()
+	if err != nil {
+		return err
+	}
+	ref, _ := catalog["Pages"].(Reference)
+	root, ok := dst.pageTreeNode(ref)
+	if !ok {
+		return errors.New("pdf: Document has no page tree")
+	}
+
+	objs := make(map[string]Indirect, len(src.Body))
+	for _, obj := range src.Body {
+		objs[obj.Name] = obj
+	}
+	srcCatalog, ok := objs[string(src.Root)].Object.(Dict)
+	if !ok {
+		return errors.New("pdf: source Root does not refer to a catalog")
+	}
+	pages, nodes := pageTree(objs, srcCatalog)
+
+	// Pages are added first, so that references between them, such as
+	// from links and from annotations' P entries, can be renamed
+	// before the pages themselves are filled in. Objects that are
+	// found to refer back to themselves along the way are added in the
+	// same way.
+	names := make(map[string]Reference)
+	indices := make(map[string]int)
+	reserve := func(name string) Reference {
+		ref := dst.addDistinct(Null{})
+		names[name] = ref
+		indices[name] = len(dst.Body) - 1
+		return ref
+	}
+	for _, page := range pages {
+		reserve(page.name)
+	}
+
+	var rename func(obj Object) Object
+	copying := make(map[string]bool)
+	add := func(name string) Object {
+		if ref, ok := names[name]; ok {
+			return ref
+		}
+		obj, ok := objs[name]
+		if !ok || nodes[name] {
+			return Null{}
+		}
+		if copying[name] {
+			return reserve(name)
+		}
+
+		copying[name] = true
+		renamed := rename(obj.Object)
+		delete(copying, name)
+
+		if i, ok := indices[name]; ok {
+			dst.Body[i].Object = renamed
+			return names[name]
+		}
+		ref := dst.Add(renamed)
+		names[name] = ref
+		return ref
+	}
+	rename = func(obj Object) Object {
+		switch obj := obj.(type) {
+		case Reference:
+			return add(string(obj))
+		case Array:
+			a := make(Array, 0, len(obj))
+			for _, v := range obj {
+				a = append(a, rename(v))
+			}
+			return a
+		case Dict:
+			d := make(Dict, len(obj))
+			for k, v := range obj {
+				d[k] = rename(v)
+			}
+			return d
+		case Stream:
+			obj.Dict = rename(obj.Dict).(Dict)
+			return obj
+		default:
+			return obj
+		}
+	}
+
+	kids, _ := root["Kids"].(Array)
+	for _, page := range pages {
+		dict := objs[page.name].Object.(Dict)
+		dict = rename(dict).(Dict)
+		dict["Parent"] = ref
+
+		for k, v := range page.attrs {
+			if _, ok := dict[k]; !ok {
+				dict[k] = rename(v)
+			}
+		}
+		if _, ok := dict["Resources"]; !ok && (len(page.inherited) > 0) {
+			dict["Resources"] = rename(page.inherited[len(page.inherited)-1])
+		}
+
+		// Anything that the page still lacks would otherwise be
+		// inherited from dst's tree instead, so it is given the
+		// default explicitly.
+		if _, ok := root["Resources"]; ok {
+			if _, ok := dict["Resources"]; !ok {
+				dict["Resources"] = Dict{}
+			}
+		}
+		if _, ok := root["Rotate"]; ok {
+			if _, ok := dict["Rotate"]; !ok {
+				dict["Rotate"] = Integer(0)
+			}
+		}
+		if _, ok := root["CropBox"]; ok {
+			box, ok := dict["MediaBox"]
+			if _, cropped := dict["CropBox"]; ok && !cropped {
+				dict["CropBox"] = box
+			}
+		}
+
+		dst.Body[indices[page.name]].Object = dict
+		kids = append(kids, names[page.name])
+	}
+	count, _ := root["Count"].(Integer)
+	root["Kids"] = kids
+	root["Count"] = count + Integer(len(pages))
+	return nil
+}
+
+// pageTreeNode returns the intermediate page tree node that ref refers
+// to.
+func (d *Document) pageTreeNode(ref Reference) (Dict, bool) {
+	for _, obj := range d.Body {
+		if obj.Name != string(ref) {
+			continue
+		}
+		dict, ok := obj.Object.(Dict)
+		return dict, ok && (dict["Type"] == Name("Pages"))
+	}
+	return nil, false
+}
@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func mergeSource(t *testing.T, n int) *PDF {
+	var d Document
+	font := d.Add(Helvetica.Dict())
+	var pages []Page
+	for i := range n {
+		var c Content
+		c.BeginText()
+		c.SetFont("F1", 12)
+		c.ShowText("page " + itoa(i))
+		c.EndText()
+		pages = append(pages, Page{Contents: c.Stream(), Annots: []Object{LinkURI(Rectangle{0, 0, 10, 10}, "https://example.com")}})
+	}
+	var err error
+	d.Root, _, err = d.AddPageTree(Page{MediaBox: A5, Rotate: degrees(90), Resources: Dict{"Font": Dict{"F1": font}}}, pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Decode(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestMerge(t *testing.T) {
+	var dst Document
+	dst.Dedup = true
+	var err error
+	dst.Root, _, err = dst.AddPageTree(Page{Resources: Dict{}, CropBox: A4}, []Page{{MediaBox: A4}, {MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Merge(&dst, mergeSource(t, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Merge(&dst, mergeSource(t, 0)); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := dst.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	validate(t, data)
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := map[string]Indirect{}
+	for _, o := range p.Body {
+		objs[o.Name] = o
+	}
+	pages, _ := pageTree(objs, objs[string(p.Root)].Object.(Dict))
+	if len(pages) != 5 {
+		t.Fatal(len(pages))
+	}
+	var fonts int
+	for _, o := range p.Body {
+		if d, ok := o.Object.(Dict); ok && d["Type"] == Name("Font") {
+			fonts++
+		}
+	}
+	if fonts != 1 {
+		t.Fatal("fonts not deduplicated:", fonts)
+	}
+	for i, page := range pages {
+		dict := objs[page.name].Object.(Dict)
+		parent := objs[string(dict["Parent"].(Reference))].Object.(Dict)
+		var found bool
+		for _, kid := range parent["Kids"].(Array) {
+			found = found || kid == Reference(page.name)
+		}
+		if !found {
+			t.Fatal("parent", i)
+		}
+		if i >= 2 {
+			if dict["Rotate"] != Integer(90) || dict["MediaBox"] == nil || dict["Resources"].(Dict)["Font"] == nil || dict["CropBox"] == nil {
+				t.Fatal(dict)
+			}
+			annot := objs[string(dict["Annots"].(Array)[0].(Reference))].Object.(Dict)
+			if annot["P"] != Reference(page.name) {
+				t.Fatal(annot)
+			}
+		}
+	}
+	root := objs[string(objs[string(p.Root)].Object.(Dict)["Pages"].(Reference))].Object.(Dict)
+	if root["Count"] != Integer(5) {
+		t.Fatal(root)
+	}
+}
@@ -0,0 +1,720 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Object is a PDF object that can be encoded into a document.
+type Object interface {
+	encode(s *encodeState) error
+}
+
+// EncodeObject writes the PDF representation of obj to w. If obj is
+// nil, the null object is written.
+func EncodeObject(w io.Writer, obj Object) error {
+	if obj == nil {
+		obj = Null{}
+	}
+
+	// Nested objects are written straight into their parent's state
+	// so that offsets, such as those of signature placeholders, are
+	// relative to the start of the file.
+	if s, ok := w.(*encodeState); ok {
+		return obj.encode(s)
+	}
+
+	s := newObjectState(w)
+	defer s.release()
+	s.scanIndirects(obj)
+	err := obj.encode(s)
+	if err != nil {
+		return err
+	}
+	return s.Flush()
+}
+
+// EncodeObjectBytes returns the PDF representation of obj, as
+// EncodeObject would write it.
+func EncodeObjectBytes(obj Object) ([]byte, error) {
+	var buf bytes.Buffer
+	err := EncodeObject(&buf, obj)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Null is the PDF null object. EncodeObject also writes null for a nil
+// Object, but Null makes the intent explicit inside of an Array or
+// Dict.
+type Null struct{}
+
+func (Null) encode(s *encodeState) error {
+	_, err := s.WriteString("null")
+	return err
+}
+
+// Comment is a PDF comment, which readers ignore, for making encoded
+// output easier to follow. As comments aren't objects in their own
+// right, Comments can only be encoded on their own or as elements of
+// an Array. A Comment as the value in a Dict is an error. Line breaks
+// in the comment are written as spaces so that it stays on one line.
+type Comment string
+
+func (c Comment) encode(s *encodeState) error {
+	text := strings.NewReplacer("\r", " ", "\n", " ").Replace(string(c))
+	_, err := s.WriteString("%" + text + "\n")
+	return err
+}
+
+// Boolean is a PDF boolean object.
+type Boolean bool
+
+func (b Boolean) encode(s *encodeState) error {
+	_, err := fmt.Fprint(s, bool(b))
+	return err
+}
+
+// Integer is a PDF integer object.
+type Integer int64
+
+func (i Integer) encode(s *encodeState) error {
+	s.scratch = strconv.AppendInt(s.scratch[:0], int64(i), 10)
+	_, err := s.Write(s.scratch)
+	return err
+}
+
+// Real is a PDF real number object. Reals are written in the shortest
+// plain decimal form that represents them exactly, as PDF has no
+// syntax for exponents.
+type Real float64
+
+func (r Real) encode(s *encodeState) error {
+	if math.IsNaN(float64(r)) || math.IsInf(float64(r), 0) {
+		return fmt.Errorf("pdf: cannot encode non-finite Real: %v", float64(r))
+	}
+
+	s.scratch = appendReal(s.scratch[:0], float64(r))
+	_, err := s.Write(s.scratch)
+	return err
+}
+
+// formatReal formats f as a PDF number.
+func formatReal(f float64) string {
+	return string(appendReal(nil, f))
+}
+
+// appendReal appends f, formatted as a PDF number, to buf.
+func appendReal(buf []byte, f float64) []byte {
+	if f == 0 {
+		// Avoid writing negative zero as -0.
+		return append(buf, '0')
+	}
+
+	return strconv.AppendFloat(buf, f, 'f', -1, 64)
+}
+
+// literalStringReplacer escapes delimiters and the control characters
+// that have escape sequences. Line endings in particular must be
+// escaped, as readers normalize raw ones inside of literal strings.
+var literalStringReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`(`, `\(`,
+	`)`, `\)`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"\b", `\b`,
+	"\f", `\f`,
+)
+
+// LiteralString is a PDF string object that is encoded in the literal,
+// parenthesized form.
+type LiteralString string
+
+func (str LiteralString) encode(s *encodeState) error {
+	if s.encrypting() {
+		// Encrypted strings are binary, so they're better off as hex.
+		return HexString(str).encode(s)
+	}
+
+	err := s.WriteByte('(')
+	if err != nil {
+		return err
+	}
+
+	_, err = literalStringReplacer.WriteString(s, string(str))
+	if err != nil {
+		return err
+	}
+
+	return s.WriteByte(')')
+}
+
+// HexString is a PDF string object that is encoded in hexadecimal
+// form. Long strings are split across several lines; see
+// PDF.HexLineLength.
+type HexString []byte
+
+func (str HexString) encode(s *encodeState) error {
+	str = s.encrypted(str)
+	err := s.WriteByte('<')
+	if err != nil {
+		return err
+	}
+
+	line := s.hexLine / 2
+	if line <= 0 {
+		line = len(str)
+	}
+	for i := 0; i < len(str); i += line {
+		if i > 0 {
+			err := s.WriteByte('\n')
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(s, "%X", []byte(str[i:min(i+line, len(str))]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.WriteByte('>')
+}
+
+// String returns a string object containing b, choosing whichever of
+// LiteralString and HexString is likely to produce smaller output.
+//
+// Every byte takes two characters in hexadecimal form. In literal form,
+// printable ASCII takes one, or two if it needs to be escaped, and
+// anything else is counted as four, the length of an octal escape,
+// as binary data doesn't survive well in literal strings. In practice
+// this means that a literal string is chosen when roughly two thirds
+// or more of b is printable.
+func String(b []byte) Object {
+	var lit int
+	for _, c := range b {
+		switch {
+		case strings.IndexByte(`\()`+"\n\r\t\b\f", c) >= 0:
+			lit += 2
+		case (c >= ' ') && (c <= '~'):
+			lit++
+		default:
+			lit += 4
+		}
+	}
+
+	if lit <= 2*len(b) {
+		return LiteralString(b)
+	}
+	return HexString(b)
+}
+
+// Name is a PDF name object. Names are written with a leading slash,
+// which should not be included in the value. A name may not be empty,
+// and may not be longer than MaxNameLength bytes once encoded.
+type Name string
+
+// MaxNameLength is the maximum length of an encoded name, not
+// including the leading slash.
+const MaxNameLength = 127
+
+func (n Name) encode(s *encodeState) error {
+	if n == "" {
+		return errors.New("pdf: cannot encode empty Name")
+	}
+
+	buf := make([]byte, 0, len(n)+1)
+	buf = append(buf, '/')
+	for i := 0; i < len(n); i++ {
+		// Names are byte strings, so anything outside of the regular
+		// characters is escaped a byte at a time.
+		c := n[i]
+		if isRegular(c) {
+			buf = append(buf, c)
+			continue
+		}
+
+		buf = append(buf, '#', hexDigits[c>>4], hexDigits[c&0xF])
+	}
+	if len(buf)-1 > MaxNameLength {
+		return fmt.Errorf("pdf: Name is too long: %v > %v bytes", len(buf)-1, MaxNameLength)
+	}
+
+	_, err := s.Write(buf)
+	return err
+}
+
+const hexDigits = "0123456789ABCDEF"
+
+// isRegular returns true if c may appear in a name without being
+// escaped.
+func isRegular(c byte) bool {
+	if (c < '!') || (c > '~') {
+		return false
+	}
+
+	return strings.IndexByte("()<>[]{}/%#", c) < 0
+}
+
+// Array is a PDF array object.
+type Array []Object
+
+func (a Array) encode(s *encodeState) error {
+	err := s.WriteByte('[')
+	if err != nil {
+		return err
+	}
+
+	s.depth++
+	for i, obj := range a {
+		switch {
+		case s.indent != "":
+			err = s.newline()
+		case i > 0:
+			err = s.WriteByte(' ')
+		}
+		if err != nil {
+			return err
+		}
+
+		err := EncodeObject(s, obj)
+		if err != nil {
+			return atPath(err, fmt.Sprintf("[%v]", i))
+		}
+	}
+	s.depth--
+
+	if (s.indent != "") && (len(a) > 0) {
+		err := s.newline()
+		if err != nil {
+			return err
+		}
+	}
+	return s.WriteByte(']')
+}
+
+// Dict is a PDF dictionary object. Entries are encoded in ascending
+// order of their keys so that output is reproducible.
+type Dict map[Name]Object
+
+// keys returns the keys of d, sorted.
+func (d Dict) keys() []Name {
+	keys := make([]Name, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func (d Dict) encode(s *encodeState) error {
+	_, err := s.WriteString("<<")
+	if err != nil {
+		return err
+	}
+
+	s.depth++
+	s.dicts++
+	for _, k := range d.keys() {
+		v := d[k]
+		if _, ok := v.(Comment); ok {
+			return atPath(errors.New("pdf: Comment can't be a Dict value"), "/"+string(k))
+		}
+
+		if s.indent != "" {
+			err := s.newline()
+			if err != nil {
+				return err
+			}
+		}
+
+		err := k.encode(s)
+		if err != nil {
+			return err
+		}
+
+		err = s.WriteByte(' ')
+		if err != nil {
+			return err
+		}
+
+		err = EncodeObject(s, v)
+		if err != nil {
+			return atPath(err, "/"+string(k))
+		}
+
+		if s.indent == "" {
+			err = s.WriteByte(' ')
+			if err != nil {
+				return err
+			}
+		}
+	}
+	s.depth--
+	s.dicts--
+
+	if (s.indent != "") && (len(d) > 0) {
+		err := s.newline()
+		if err != nil {
+			return err
+		}
+	}
+	_, err = s.WriteString(">>")
+	return err
+}
+
+// Stream is a PDF stream object. Length bytes are copied from Data
+// into the stream when it is encoded. If Length is not positive, Data
+// is read until EOF and buffered in memory so that the length can be
+// determined before the stream is written.
+//
+// If Filters is not empty, Data is passed through each of the filters
+// before being written, and Length, if positive, limits how much of
+// Data is read. The length of the filtered data is always computed.
+type Stream struct {
+	// Dict contains any entries of the stream's dictionary other than
+	// Length, which is always written by the encoder, so including it
+	// is an error. If Filters is not empty, the Filter and DecodeParms
+	// entries written for it replace any in Dict.
+	Dict Dict
+
+	// Filters are the filters to apply to the stream's data, in the
+	// order that a reader would need to decode them in. In other
+	// words, the last filter is applied to Data first.
+	Filters []Filter
+
+	Length int64
+	Data   io.Reader
+}
+
+func (st Stream) encode(s *encodeState) error {
+	if _, ok := st.Dict["Length"]; ok {
+		return errors.New("pdf: Stream.Dict must not contain Length")
+	}
+
+	dict := make(Dict, len(st.Dict)+2)
+	for k, v := range st.Dict {
+		dict[k] = v
+	}
+
+	data := st.Data
+	if data == nil {
+		data = strings.NewReader("")
+	}
+	data = s.reader(data)
+
+	if (s.file != nil) && s.file.indirectLength && !s.encrypting() && ((len(st.Filters) > 0) || (st.Length <= 0)) {
+		return st.encodeIndirectLength(s, dict, data)
+	}
+
+	if len(st.Filters) > 0 {
+		if st.Length > 0 {
+			data = io.LimitReader(data, st.Length)
+		}
+		buf, err := applyFilters(st.Filters, data)
+		if err != nil {
+			return err
+		}
+		dict["Filter"] = filterNames(st.Filters)
+		if params := filterParams(st.Filters); params != nil {
+			dict["DecodeParms"] = params
+		}
+		st.Length = int64(buf.Len())
+		data = buf
+	} else if st.Length <= 0 {
+		var buf bytes.Buffer
+		_, err := buf.ReadFrom(data)
+		if err != nil {
+			return err
+		}
+		st.Length = int64(buf.Len())
+		data = &buf
+	}
+	if s.encrypting() {
+		buf, err := io.ReadAll(io.LimitReader(data, st.Length))
+		if err != nil {
+			return err
+		}
+		buf = s.encrypted(buf)
+		st.Length = int64(len(buf))
+		data = bytes.NewReader(buf)
+	}
+	dict["Length"] = Integer(st.Length)
+
+	err := dict.encode(s)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.WriteString("\nstream\n")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(s, data, st.Length)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.WriteString("\nendstream")
+	return err
+}
+
+// encodeIndirectLength writes st with data copied straight into the
+// file, and its Length referring to an object that is written once
+// the length is known. dict holds the rest of the stream's entries.
+func (st Stream) encodeIndirectLength(s *encodeState, dict Dict, data io.Reader) error {
+	num := s.newObjNum()
+	dict["Length"] = ObjectRef{Number: num}
+	if len(st.Filters) > 0 {
+		if st.Length > 0 {
+			data = io.LimitReader(data, st.Length)
+		}
+		dict["Filter"] = filterNames(st.Filters)
+		if params := filterParams(st.Filters); params != nil {
+			dict["DecodeParms"] = params
+		}
+	}
+
+	err := dict.encode(s)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.WriteString("\nstream\n")
+	if err != nil {
+		return err
+	}
+
+	start := s.offset()
+	err = copyFiltered(s, st.Filters, data)
+	if err != nil {
+		return err
+	}
+	s.file.lengths = append(s.file.lengths, streamLength{num: num, n: s.offset() - start})
+
+	_, err = s.WriteString("\nendstream")
+	return err
+}
+
+// Indirect is a named indirect object. Other objects can refer to it
+// using a Reference with the same name.
+type Indirect struct {
+	Name string
+
+	// Generation is the generation number of the object, which is
+	// normally zero for a newly created document.
+	Generation int
+
+	Object Object
+}
+
+func (obj Indirect) encode(s *encodeState) error {
+	return encodeIndirect(s, s.objName(obj.Name, obj.Generation), obj.Object)
+}
+
+// IndirectRef is an indirect object identified by its object and
+// generation numbers, rather than by name, for writing objects with
+// numbers that are already known using EncodeObject. Other objects can
+// refer to it using an ObjectRef with the same numbers. It can't be
+// used in a PDF's Body, which numbers its objects itself. Objects that
+// EncodeObject numbers by name skip the numbers of the IndirectRefs
+// given to it, and writing two objects with the same number is an
+// error.
+type IndirectRef struct {
+	Number, Generation int
+	Object             Object
+}
+
+func (obj IndirectRef) encode(s *encodeState) error {
+	return encodeIndirect(s, objID{num: obj.Number, gen: obj.Generation}, obj.Object)
+}
+
+// encodeIndirect writes obj as the indirect object id. Indirect
+// objects can't be nested inside of other objects, other than Arrays
+// given directly to EncodeObject.
+func encodeIndirect(s *encodeState, id objID, obj Object) error {
+	if (s.obj.num != 0) || (s.dicts > 0) {
+		return fmt.Errorf("pdf: indirect object %v %v is inside of another object", id.num, id.gen)
+	}
+	if _, ok := s.offsets[id.num]; ok {
+		return fmt.Errorf("pdf: object number %v is written more than once", id.num)
+	}
+
+	if s.offsets == nil {
+		s.offsets = make(map[int]int64)
+	}
+	s.offsets[id.num] = s.offset()
+
+	_, err := fmt.Fprintf(s, "%v %v obj\n", id.num, id.gen)
+	if err != nil {
+		return err
+	}
+
+	s.obj = id
+	err = EncodeObject(s, obj)
+	s.obj = objID{}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.WriteString("\nendobj")
+	return err
+}
+
+// scanIndirects records the generations of the Indirects in obj, which
+// may be one itself or an Array holding them, by name, and the numbers
+// of the IndirectRefs, so that the objects that are numbered by name
+// don't take them.
+func (s *encodeState) scanIndirects(obj Object) {
+	switch obj := obj.(type) {
+	case Indirect:
+		if s.gens == nil {
+			s.gens = make(map[string]int)
+		}
+		s.gens[obj.Name] = obj.Generation
+	case IndirectRef:
+		if s.reserved == nil {
+			s.reserved = make(map[int]bool)
+		}
+		s.reserved[obj.Number] = true
+	case Array:
+		for _, v := range obj {
+			s.scanIndirects(v)
+		}
+	}
+}
+
+// Reference is a reference to the Indirect object with the given
+// name. The reference carries the generation number of the object
+// that it refers to.
+type Reference string
+
+func (r Reference) encode(s *encodeState) error {
+	id := s.objName(string(r), 0)
+	_, err := fmt.Fprintf(s, "%v %v R", id.num, id.gen)
+	return err
+}
+
+// ObjectRef is a reference to an indirect object by its object and
+// generation numbers, rather than by name, such as to an IndirectRef.
+//
+// The objects of a PDF's Body are numbered in order starting from one,
+// so ObjectRef{Number: 1} refers to Body[0], except in a linearized
+// file, for which they are renumbered. Unlike References, ObjectRefs
+// aren't checked by Document.Check or followed when working out what
+// refers to what, such as for linearization. For an object in a
+// decoded PDF, Reference returns the equivalent Reference by name,
+// which is better used there.
+type ObjectRef struct {
+	Number, Generation int
+}
+
+func (r ObjectRef) encode(s *encodeState) error {
+	_, err := fmt.Fprintf(s, "%v %v R", r.Number, r.Generation)
+	return err
+}
+
+// Reference returns a Reference to the object that r refers to, by the
+// name that it is given when a PDF is decoded.
+func (r ObjectRef) Reference() Reference {
+	return Reference(ObjectName(r.Number, r.Generation))
+}
+
+// errContainsItself is returned by references for objects that
+// contain themselves directly, rather than by way of a Reference, and
+// so can never be encoded.
+var errContainsItself = errors.New("pdf: object contains itself")
+
+// references calls fn for each reference in obj, in the order that
+// they would be encoded in.
+func references(obj Object, fn func(Reference)) error {
+	// Containers are identified by where their contents are. Arrays
+	// also need their lengths, as a subslice could share the start of
+	// its parent without being the same.
+	type container struct {
+		ptr uintptr
+		n   int
+	}
+	var parents []container
+
+	var walk func(obj Object) error
+	enter := func(c container, f func() error) error {
+		if c.ptr == 0 {
+			return nil
+		}
+		if slices.Contains(parents, c) {
+			return errContainsItself
+		}
+		parents = append(parents, c)
+		err := f()
+		parents = parents[:len(parents)-1]
+		return err
+	}
+	walk = func(obj Object) error {
+		switch obj := obj.(type) {
+		case Reference:
+			fn(obj)
+		case Array:
+			return enter(container{reflect.ValueOf(obj).Pointer(), len(obj)}, func() error {
+				for _, v := range obj {
+					err := walk(v)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		case Dict:
+			return enter(container{reflect.ValueOf(obj).Pointer(), 0}, func() error {
+				keys := make([]Name, 0, len(obj))
+				for k := range obj {
+					keys = append(keys, k)
+				}
+				slices.Sort(keys)
+				for _, k := range keys {
+					err := walk(obj[k])
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		case Stream:
+			return walk(obj.Dict)
+		case FormXObject:
+			if obj.Layer != "" {
+				fn(obj.Layer)
+			}
+			err := walk(obj.Resources)
+			if err != nil {
+				return err
+			}
+			return walk(obj.Content)
+		case StitchingFunction:
+			return walk(Array(obj.Functions))
+		case TilingPattern:
+			err := walk(obj.Resources)
+			if err != nil {
+				return err
+			}
+			return walk(obj.Content)
+		case Indirect:
+			return walk(obj.Object)
+		}
+		return nil
+	}
+	return walk(obj)
+}
@@ -0,0 +1,558 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStreamAutoLength(t *testing.T) {
+	data := []byte("some (data)\nwith lines\n")
+	var buf bytes.Buffer
+	if err := EncodeObject(&buf, Stream{Data: bytes.NewBuffer(data)}); err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`(?s)/Length (\d+).*?stream\n(.*)\nendstream$`).FindSubmatch(buf.Bytes())
+	if m == nil {
+		t.Fatal(buf.String())
+	}
+	n, _ := strconv.Atoi(string(m[1]))
+	if n != len(data) || !bytes.Equal(m[2], data) {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestDictSorted(t *testing.T) {
+	d := Dict{"Zeta": Integer(1), "Alpha": Integer(2), "Mid": Integer(3), "B": Integer(4), "a": Integer(5)}
+	var a, b bytes.Buffer
+	EncodeObject(&a, d)
+	EncodeObject(&b, d)
+	if a.String() != b.String() {
+		t.Fatal("unstable")
+	}
+	if a.String() != "<</Alpha 2 /B 4 /Mid 3 /Zeta 1 /a 5 >>" {
+		t.Fatal(a.String())
+	}
+}
+
+func TestReal(t *testing.T) {
+	tests := map[float64]string{
+		1.5: "1.5", 0.001: "0.001", 100: "100", 0.0000001: "0.0000001",
+		-2.25: "-2.25", -3: "-3", math.Copysign(0, -1): "0", 1e21: "1000000000000000000000",
+	}
+	for in, want := range tests {
+		var buf bytes.Buffer
+		EncodeObject(&buf, Real(in))
+		if buf.String() != want {
+			t.Errorf("%v: %q", in, buf.String())
+		}
+	}
+	var buf bytes.Buffer
+	EncodeObject(&buf, Real(math.SmallestNonzeroFloat64))
+	if !strings.HasPrefix(buf.String(), "0.000") || strings.ContainsAny(buf.String(), "eE") || !strings.HasSuffix(buf.String(), "5") {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestRealNonFinite(t *testing.T) {
+	for in, want := range map[float64]string{math.NaN(): "NaN", math.Inf(1): "+Inf", math.Inf(-1): "-Inf"} {
+		err := EncodeObject(new(bytes.Buffer), Array{Real(in)})
+		if err == nil || err.Error() != "pdf: [0]: cannot encode non-finite Real: "+want {
+			t.Errorf("%v: %v", in, err)
+		}
+	}
+	if err := EncodeObject(new(bytes.Buffer), Real(1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLiteralEscapes(t *testing.T) {
+	in := "a(b)\\c\r\nd\te\bf\fg"
+	var buf bytes.Buffer
+	EncodeObject(&buf, LiteralString(in))
+	want := `(a\(b\)\\c\r\nd\te\bf\fg)`
+	if buf.String() != want {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestString(t *testing.T) {
+	if _, ok := String([]byte("Hello, (world)!\n")).(LiteralString); !ok {
+		t.Fatal("ascii")
+	}
+	if _, ok := String([]byte{0, 1, 2, 0xff, 'a', 0x80, 0x90}).(HexString); !ok {
+		t.Fatal("binary")
+	}
+}
+
+func TestHexWrap(t *testing.T) {
+	data := make([]byte, 10*1024)
+	rand.Read(data)
+	var buf bytes.Buffer
+	EncodeObject(&buf, HexString(data))
+	out := buf.String()
+	lines := strings.Split(out, "\n")
+	for _, l := range lines {
+		if len(l) > 66 {
+			t.Fatal(len(l))
+		}
+	}
+	h := strings.NewReplacer("\n", "", "<", "", ">", "").Replace(out)
+	dec, err := hex.DecodeString(h)
+	if err != nil || !bytes.Equal(dec, data) {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	EncodeObject(&buf, HexString{1, 2})
+	if buf.String() != "<0102>" {
+		t.Fatal(buf.String())
+	}
+	buf.Reset()
+	Encode(&buf, &PDF{Root: "a", HexLineLength: -1, Body: []Indirect{{Name: "a", Object: HexString(data)}}})
+	if !strings.Contains(buf.String(), "\n<"+strings.ToUpper(hex.EncodeToString(data))+">\n") {
+		t.Fatal("wrapped")
+	}
+}
+
+func TestName(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeObject(&buf, Name("")); err == nil {
+		t.Fatal("empty")
+	}
+	if err := EncodeObject(&buf, Name(strings.Repeat("a", 128))); err == nil {
+		t.Fatal("long")
+	}
+	if err := EncodeObject(&buf, Name(strings.Repeat("a", 127))); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncodeObject(&buf, Name(strings.Repeat("é", 21))); err != nil {
+		t.Fatal(err) // 21*6 = 126
+	}
+	if err := EncodeObject(&buf, Name(strings.Repeat("é", 22))); err == nil {
+		t.Fatal("long escaped")
+	}
+	buf.Reset()
+	EncodeObject(&buf, Name("café A#"))
+	if buf.String() != "/caf#C3#A9#20A#23" {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestGeneration(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := Encode(&buf, &PDF{Root: "cat", Body: []Indirect{
+		{Name: "cat", Object: Dict{"Type": Name("Catalog"), "X": Reference("x")}},
+		{Name: "x", Generation: 5, Object: Integer(3)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"2 5 obj\n3", "/X 2 5 R", " 00005 n \n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in %s", want, out)
+		}
+	}
+
+	// A Reference that comes before its Indirect has its generation.
+	b, err := EncodeObjectBytes(Array{Reference("x"), Indirect{Name: "x", Generation: 5, Object: Integer(3)}})
+	if err != nil || string(b) != "[1 5 R 1 5 obj\n3\nendobj]" {
+		t.Fatalf("%q %v", b, err)
+	}
+}
+
+func TestNull(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeObject(&buf, Dict{"A": Null{}, "B": Array{Integer(1), Null{}, nil}})
+	if buf.String() != "<</A null /B [1 null null] >>" {
+		t.Fatal(buf.String())
+	}
+}
+
+type onceReader struct {
+	n, reads int
+	eof      bool
+	out      *countingBuffer
+	t        *testing.T
+}
+
+func (r *onceReader) Read(p []byte) (int, error) {
+	if r.eof {
+		return 0, errors.New("read after EOF")
+	}
+	r.reads++
+	if r.reads > 50 && r.out.n == 0 {
+		r.t.Error("data is being buffered")
+	}
+	if r.n == 0 {
+		r.eof = true
+		return 0, io.EOF
+	}
+	n := min(len(p), r.n, 1000)
+	for i := range n {
+		p[i] = byte('a' + i%26)
+	}
+	r.n -= n
+	return n, nil
+}
+
+type countingBuffer struct {
+	bytes.Buffer
+	n int
+}
+
+func (b *countingBuffer) Write(p []byte) (int, error) {
+	b.n += len(p)
+	return b.Buffer.Write(p)
+}
+
+func TestStreamIndirectLength(t *testing.T) {
+	for _, mode := range []func(*Document){
+		func(*Document) {},
+		func(d *Document) { d.XrefStream = true },
+		func(d *Document) { d.ObjectStreams = true },
+	} {
+		var out countingBuffer
+		var d Document
+		d.Add(Stream{Data: &onceReader{n: 1 << 20, out: &out, t: t}})
+		d.Add(Stream{Data: &onceReader{n: 1 << 20, out: &out, t: t}, Filters: []Filter{FlateFilter{}}})
+		d.Add(Stream{Data: &onceReader{n: 1 << 20, out: &out, t: t}, Length: 10})
+		var err error
+		d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.StreamIndirectLength = true
+		mode(&d)
+		if _, err := d.Finish(&out); err != nil {
+			t.Fatal(err)
+		}
+		data := out.Bytes()
+		if !bytes.Contains(data, []byte("<</Length 7 0 R >>\nstream\n")) || !bytes.Contains(data, []byte("<</Filter /FlateDecode /Length 8 0 R >>")) || !bytes.Contains(data, []byte("endobj\n7 0 obj\n1048576\nendobj\n")) || !bytes.Contains(data, []byte("<</Length 10 >>")) {
+			t.Fatal(string(data[:200]))
+		}
+		validate(t, data)
+	}
+}
+
+func TestStreamIndirectLengthDangling(t *testing.T) {
+	p := &PDF{
+		Body: []Indirect{
+			{Name: "cat", Object: Dict{"Type": Name("Catalog"), "X": Reference("missing")}},
+			{Name: "st", Object: Stream{Data: strings.NewReader("abc")}},
+		},
+		Root:                 "cat",
+		StreamIndirectLength: true,
+	}
+	data, err := EncodeBytes(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.LastIndex(data, []byte("\nxref\n"))
+	lines := strings.Split(string(data[i+1:]), "\n")
+	if lines[1] != "0 5" {
+		t.Fatal(lines[1])
+	}
+	// The dangling reference is 3 and the length of the stream is 4.
+	if lines[5] != "0000000000 00000 f " {
+		t.Fatal(lines[5])
+	}
+	off, _ := strconv.Atoi(lines[6][:10])
+	if !bytes.HasPrefix(data[off:], []byte("4 0 obj\n3\nendobj")) {
+		t.Fatalf("%q", data[off:])
+	}
+	if _, err := Decode(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestComment(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeObject(&buf, Comment("a\nb\r\nc")); err != nil || buf.String() != "%a b  c\n" {
+		t.Fatalf("%q %v", buf.String(), err)
+	}
+	buf.Reset()
+	arr := Array{Integer(1), Comment("x\n%%EOF\nendobj"), Integer(2)}
+	if err := EncodeObject(&buf, arr); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := DecodeObject(bytes.NewReader(buf.Bytes()))
+	if err != nil || !reflect.DeepEqual(obj, Array{Integer(1), Integer(2)}) {
+		t.Fatalf("%q %v %v", buf.String(), obj, err)
+	}
+	var d Document
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Add(arr)
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Body[len(p.Body)-1].Object; !reflect.DeepEqual(got, Array{Integer(1), Integer(2)}) {
+		t.Fatal(got)
+	}
+	validate(t, data)
+
+	buf.Reset()
+	err = EncodeObject(&buf, Dict{"A": Comment("hi"), "B": Integer(1)})
+	if err == nil || !strings.Contains(err.Error(), "/A") {
+		t.Fatal(err)
+	}
+	err = EncodeObject(&buf, Array{Dict{"X": Array{Comment("fine")}}, Stream{Dict: Dict{"C": Comment("no")}}})
+	if err == nil || !strings.Contains(err.Error(), "/C") {
+		t.Fatal(err)
+	}
+}
+
+func TestIndent(t *testing.T) {
+	obj := Dict{"A": Array{Integer(1), Dict{"B": Name("C")}, Array{}}, "D": Dict{}}
+	var buf bytes.Buffer
+	if err := EncodeObject(&buf, obj); err != nil || buf.String() != "<</A [1 <</B /C >> []] /D <<>> >>" {
+		t.Fatalf("%q", buf.String())
+	}
+
+	s := newEncodeState(&buf)
+	buf.Reset()
+	s.indent = "  "
+	if err := EncodeObject(s, obj); err != nil {
+		t.Fatal(err)
+	}
+	s.Flush()
+	want := "<<\n  /A [\n    1\n    <<\n      /B /C\n    >>\n    []\n  ]\n  /D <<>>\n>>"
+	if buf.String() != want {
+		t.Fatalf("%q", buf.String())
+	}
+
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Resources: Dict{"X": obj}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Indent = "\t"
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "\n\t/Type /Catalog\n") {
+		t.Fatal(out.String())
+	}
+	validate(t, out.Bytes())
+}
+
+func nestedObject() Object {
+	inner := Dict{"Type": Name("Font"), "Widths": Array{Integer(1), Real(2.5), LiteralString("x")}}
+	return Array{inner, Dict{"A": Array{inner, Reference("r")}, "B": HexString("ab")}, Integer(3)}
+}
+
+func TestEncodeNestedUnchanged(t *testing.T) {
+	var a, b bytes.Buffer
+	if err := EncodeObject(&a, nestedObject()); err != nil {
+		t.Fatal(err)
+	}
+	// Encoding the parts separately gives the same result as encoding
+	// them nested.
+	b.WriteString("[")
+	for i, obj := range nestedObject().(Array) {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		EncodeObject(&b, obj)
+	}
+	b.WriteString("]")
+	if a.String() != b.String() {
+		t.Fatalf("%q %q", a.String(), b.String())
+	}
+}
+
+func BenchmarkEncodeObject(b *testing.B) {
+	obj := nestedObject()
+	b.ReportAllocs()
+	for range b.N {
+		EncodeObject(io.Discard, obj)
+	}
+}
+
+func BenchmarkContent(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		var c Content
+		for range 100 {
+			c.MoveTo(1, 2)
+			c.LineTo(3.5, 4)
+			c.Stroke()
+		}
+	}
+}
+
+func BenchmarkEncodeDocument(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		var d Document
+		for i := range 2000 {
+			d.Add(Dict{"N": Integer(i), "A": Array{Integer(i), Name("x")}})
+		}
+		var err error
+		d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+		if err != nil {
+			b.Fatal(err)
+		}
+		d.Finish(io.Discard)
+	}
+}
+
+func TestNumberFormatUnchanged(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var a Array
+	var want []string
+	for i := range 5000 {
+		n := Integer(r.Int63n(1<<40) - 1<<39)
+		f := (r.Float64() - 0.5) * math.Pow(10, float64(i%20-10))
+		if i%7 == 0 {
+			f = math.Copysign(0, -1)
+		}
+		a = append(a, n, Real(f))
+		old := strconv.FormatFloat(f, 'f', -1, 64)
+		if f == 0 {
+			old = "0"
+		}
+		want = append(want, fmt.Sprint(int64(n)), old)
+	}
+	a = append(a, Integer(math.MinInt64), Integer(math.MaxInt64))
+	want = append(want, fmt.Sprint(int64(math.MinInt64)), fmt.Sprint(int64(math.MaxInt64)))
+	var out bytes.Buffer
+	if err := EncodeObject(&out, a); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "["+strings.Join(want, " ")+"]" {
+		t.Fatal("mismatch")
+	}
+}
+
+func BenchmarkEncodeReals(b *testing.B) {
+	a := make(Array, 10000)
+	for i := range a {
+		a[i] = Real(float64(i) * 1.37)
+	}
+	b.ReportAllocs()
+	for range b.N {
+		EncodeObject(io.Discard, a)
+	}
+}
+
+func TestStreamDict(t *testing.T) {
+	st := Stream{
+		Dict: Dict{
+			"Type": Name("XObject"), "Subtype": Name("Image"), "Width": Integer(1), "Height": Integer(1),
+			"ColorSpace": Name("DeviceGray"), "BitsPerComponent": Integer(8), "Filter": Name("Bogus"),
+		},
+		Filters: []Filter{FlateFilter{}},
+		Data:    bytes.NewReader([]byte{0x80}),
+	}
+	b, err := EncodeObjectBytes(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"/Type /XObject", "/Subtype /Image", "/Width 1", "/Length ", "/Filter /FlateDecode"} {
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("%q missing %q", b, want)
+		}
+	}
+	st.Dict["Length"] = Integer(3)
+	if _, err := EncodeObjectBytes(st); err == nil || !strings.Contains(err.Error(), "Length") {
+		t.Fatal(err)
+	}
+}
+
+func TestObjectRef(t *testing.T) {
+	b, err := EncodeObjectBytes(ObjectRef{12, 0})
+	if err != nil || string(b) != "12 0 R" {
+		t.Fatal(string(b), err)
+	}
+	b, err = EncodeObjectBytes(IndirectRef{Number: 7, Generation: 2, Object: Array{ObjectRef{Number: 12}, Reference("x")}})
+	if err != nil || string(b) != "7 2 obj\n[12 0 R 1 0 R]\nendobj" {
+		t.Fatalf("%q %v", b, err)
+	}
+
+	// References by number and by name line up.
+	var d Document
+	a := d.Add(Dict{"Self": ObjectRef{Number: 1}})
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Resources: Dict{"A": a}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := EncodeBytes(&d.PDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	self := p.Body[0].Object.(Dict)["Self"].(Reference)
+	if string(self) != p.Body[0].Name || (ObjectRef{Number: 1}).Reference() != self {
+		t.Fatal(self, p.Body[0].Name)
+	}
+
+	// Encrypted trailers still refer to the encryption dictionary.
+	d.Encryption = &Encryption{UserPassword: "u"}
+	data, err = EncodeBytes(&d.PDF)
+	if err != nil || !bytes.Contains(data, []byte("/Encrypt ")) {
+		t.Fatal(err)
+	}
+}
+
+func TestIndirectRefNumbers(t *testing.T) {
+	b, err := EncodeObjectBytes(Array{Reference("a"), IndirectRef{Number: 1, Object: Integer(1)}, IndirectRef{Number: 2, Object: Reference("b")}})
+	if err != nil || string(b) != "[3 0 R 1 0 obj\n1\nendobj 2 0 obj\n4 0 R\nendobj]" {
+		t.Fatalf("%q %v", b, err)
+	}
+	_, err = EncodeObjectBytes(Array{IndirectRef{Number: 1, Object: Integer(1)}, Indirect{Name: "a", Object: Integer(2)}, IndirectRef{Number: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = EncodeObjectBytes(Array{IndirectRef{Number: 1}, IndirectRef{Number: 1}})
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Fatal(err)
+	}
+
+	for _, obj := range []Object{
+		Dict{"X": IndirectRef{Number: 1, Object: Integer(1)}},
+		Array{Dict{"X": Array{Indirect{Name: "a"}}}},
+		IndirectRef{Number: 1, Object: Indirect{Name: "a"}},
+		Indirect{Name: "a", Object: Array{IndirectRef{Number: 5}}},
+	} {
+		_, err := EncodeObjectBytes(obj)
+		if err == nil || !strings.Contains(err.Error(), "inside of another object") {
+			t.Fatal(obj, err)
+		}
+	}
+
+	for _, packed := range []bool{false, true} {
+		var d Document
+		d.ObjectStreams = packed
+		d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Resources: Dict{"X": IndirectRef{Number: 9}}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := EncodeBytes(&d.PDF); err == nil || !strings.Contains(err.Error(), "inside of another object") {
+			t.Fatal(packed, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+)
+
+// Operation is a single operator in a content stream along with its
+// operands.
+//
+// An inline image, from BI to EI, is a single operation with the
+// operator BI. Its only operand is a Stream whose Dict contains the
+// image's entries, with any abbreviations left as they are, and whose
+// Data is the image's data, still encoded.
+type Operation struct {
+	Operator string
+	Operands []Object
+}
+
+// ParseContent splits the content stream data, which should already be
+// decoded, into operations.
+func ParseContent(data []byte) ([]Operation, error) {
+	p := newParser(bytes.NewReader(data))
+
+	var ops []Operation
+	var operands []Object
+	for {
+		tok, err := p.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if tok.Kind == TokenKeyword {
+			switch tok.Value {
+			case "true", "false", "null":
+			case "BI":
+				img, err := p.parseInlineImage(tok)
+				if err != nil {
+					return nil, err
+				}
+				ops = append(ops, Operation{Operator: tok.Value, Operands: append(operands, img)})
+				operands = nil
+				continue
+			default:
+				ops = append(ops, Operation{Operator: tok.Value, Operands: operands})
+				operands = nil
+				continue
+			}
+		}
+
+		obj, err := p.parseFrom(tok)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, obj)
+	}
+
+	if len(operands) > 0 {
+		return nil, p.s.errorf(p.s.Offset(), "operands without an operator at end of content")
+	}
+	return ops, nil
+}
+
+// parseInlineImage parses the rest of the inline image that starts
+// with the BI operator bi.
+func (p *parser) parseInlineImage(bi Token) (Stream, error) {
+	dict := Dict{}
+	for {
+		tok, err := p.nextMust()
+		if err != nil {
+			return Stream{}, err
+		}
+		if (tok.Kind == TokenKeyword) && (tok.Value == "ID") {
+			break
+		}
+		if tok.Kind != TokenName {
+			return Stream{}, p.s.errorf(tok.Offset, "inline image key must be a name, not %v", tok.Kind)
+		}
+
+		val, err := p.nextMust()
+		if err != nil {
+			return Stream{}, err
+		}
+		obj, err := p.parseFrom(val)
+		if err != nil {
+			return Stream{}, err
+		}
+		dict[Name(tok.Value)] = obj
+	}
+	if len(p.peeked) > 0 {
+		return Stream{}, p.s.errorf(bi.Offset, "unexpected inline image data")
+	}
+
+	// A single white-space character separates ID from the data.
+	_, err := p.s.readByte()
+	if err != nil {
+		return Stream{}, p.s.unexpectedEOF(err)
+	}
+
+	var data []byte
+	length, ok := dict["L"].(Integer)
+	if !ok {
+		length, ok = dict["Length"].(Integer)
+	}
+	if ok && (length >= 0) {
+		var buf bytes.Buffer
+		n, err := io.CopyN(&buf, p.s.r, int64(length))
+		p.s.off += n
+		if err != nil {
+			return Stream{}, p.s.unexpectedEOF(err)
+		}
+		data = buf.Bytes()
+
+		ei, err := p.nextMust()
+		if err != nil {
+			return Stream{}, err
+		}
+		if (ei.Kind != TokenKeyword) || (ei.Value != "EI") {
+			return Stream{}, p.s.errorf(ei.Offset, "expected EI")
+		}
+	} else {
+		data, err = p.scanInlineImageData(bi)
+		if err != nil {
+			return Stream{}, err
+		}
+	}
+
+	return Stream{
+		Dict:   dict,
+		Length: int64(len(data)),
+		Data:   bytes.NewReader(data),
+	}, nil
+}
+
+// scanInlineImageData reads the data of an inline image without a
+// length up to the EI operator that ends it, which is the first EI
+// surrounded by white space, or followed by a delimiter or the end of
+// the content. The white space before EI isn't included.
+func (p *parser) scanInlineImageData(bi Token) ([]byte, error) {
+	var data []byte
+	for {
+		c, err := p.s.readByte()
+		if err == io.EOF {
+			return nil, p.s.errorf(bi.Offset, "inline image without EI")
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, c)
+
+		n := len(data)
+		if (n < 3) || !isWhitespace(data[n-3]) || (data[n-2] != 'E') || (data[n-1] != 'I') {
+			continue
+		}
+		next, err := p.s.readByte()
+		if err == nil {
+			p.s.unreadByte()
+		}
+		if (err == io.EOF) || ((err == nil) && (isWhitespace(next) || isDelimiter(next))) {
+			return data[:n-3], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseContent(t *testing.T) {
+	ops, err := ParseContent([]byte("q 1 0 0 1 10 20 cm /F1 12 Tf [(a) -20 (b)] TJ 0 0 m 5 5 l S Q"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Operation{
+		{"q", nil},
+		{"cm", []Object{Integer(1), Integer(0), Integer(0), Integer(1), Integer(10), Integer(20)}},
+		{"Tf", []Object{Name("F1"), Integer(12)}},
+		{"TJ", []Object{Array{LiteralString("a"), Integer(-20), LiteralString("b")}}},
+		{"m", []Object{Integer(0), Integer(0)}},
+		{"l", []Object{Integer(5), Integer(5)}},
+		{"S", nil},
+		{"Q", nil},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Fatalf("%#v", ops)
+	}
+
+	var c Content
+	c.Save()
+	c.DrawInlineImage(InlineImage{Width: 2, Height: 1, BitsPerComponent: 8, ColorSpace: "DeviceGray", Data: []byte("E\nEI x")})
+	c.Restore()
+	for _, data := range [][]byte{c.Bytes(), []byte("q BI /W 2 /H 1 /CS /G /BPC 8 ID \x01EIx\nEI Q")} {
+		ops, err = ParseContent(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ops) != 3 || ops[1].Operator != "BI" || len(ops[1].Operands) != 1 {
+			t.Fatalf("%q %#v", data, ops)
+		}
+		st := ops[1].Operands[0].(Stream)
+		b, _ := io.ReadAll(st.Data)
+		if st.Dict["W"] != Integer(2) || len(b) == 0 || ops[2].Operator != "Q" {
+			t.Fatal(st.Dict)
+		}
+	}
+	if _, err := ParseContent([]byte("BI /W 1 ID xx")); err == nil {
+		t.Fatal("no error")
+	}
+	if _, err := ParseContent([]byte("1 2")); err == nil {
+		t.Fatal("no error")
+	}
+}
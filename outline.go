@@ -0,0 +1,90 @@
+package pdf
+
+// Outline is an item in a document's outline, commonly shown by
+// viewers as a tree of bookmarks.
+type Outline struct {
+	Title string
+
+	// Dest is the page that the item jumps to when it is activated.
+	Dest Reference
+
+	// DestName, if not empty, is used instead of Dest to jump to a
+	// named destination; see Document.Dests.
+	DestName string
+
+	// Closed hides the item's children until the item is expanded.
+	Closed bool
+
+	Children []Outline
+}
+
+// SetOutline adds an outline containing items to the document and
+// refers to it from the catalog. d.Root must already be set to the
+// catalog, such as one returned by AddPages.
+func (d *Document) SetOutline(items []Outline) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	root := Dict{"Type": Name("Outlines")}
+	ref := d.addDistinct(root)
+	if len(items) > 0 {
+		first, last, count := d.addOutlineItems(ref, items)
+		root["First"] = first
+		root["Last"] = last
+		root["Count"] = Integer(count)
+	}
+
+	catalog["Outlines"] = ref
+	return nil
+}
+
+// addOutlineItems adds a list of sibling outline items with the given
+// parent. It returns references to the first and last of them, along
+// with the number of items that are visible with parent expanded.
+func (d *Document) addOutlineItems(parent Reference, items []Outline) (first, last Reference, visible int) {
+	// Every sibling needs to be referenced by its neighbors, so they
+	// are all added before any are filled in.
+	dicts := make([]Dict, len(items))
+	refs := make([]Reference, len(items))
+	for i := range items {
+		dicts[i] = Dict{}
+		refs[i] = d.addDistinct(dicts[i])
+	}
+
+	for i, item := range items {
+		dict := dicts[i]
+		dict["Title"] = TextString(item.Title)
+		dict["Parent"] = parent
+		switch {
+		case item.DestName != "":
+			dict["Dest"] = LiteralString(item.DestName)
+		case item.Dest != "":
+			dict["Dest"] = DestFit(item.Dest)
+		}
+		if i > 0 {
+			dict["Prev"] = refs[i-1]
+		}
+		if i < len(items)-1 {
+			dict["Next"] = refs[i+1]
+		}
+
+		visible++
+		if len(item.Children) == 0 {
+			continue
+		}
+
+		first, last, count := d.addOutlineItems(refs[i], item.Children)
+		dict["First"] = first
+		dict["Last"] = last
+		if item.Closed {
+			dict["Count"] = Integer(-count)
+			continue
+		}
+		dict["Count"] = Integer(count)
+		visible += count
+	}
+
+	return refs[0], refs[len(refs)-1], visible
+}
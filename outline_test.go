@@ -0,0 +1,48 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOutline(t *testing.T) {
+	var d Document
+	catalog, pages, err := d.AddPages([]Page{{MediaBox: A4}, {MediaBox: A4}, {MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	err = d.SetOutline([]Outline{
+		{Title: "One", Dest: pages[0], Children: []Outline{{Title: "1a", Dest: pages[0]}, {Title: "1b", Dest: pages[1]}}},
+		{Title: "Two", Dest: pages[1], Closed: true, Children: []Outline{{Title: "2a", Dest: pages[2]}, {Title: "2b"}, {Title: "2c"}}},
+		{Title: "Three", Dest: pages[2]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := lookup(&d, lookup(&d, catalog).(Dict)["Outlines"].(Reference)).(Dict)
+	if root["Count"] != Integer(5) {
+		t.Fatal(root)
+	}
+	one := lookup(&d, root["First"].(Reference)).(Dict)
+	two := lookup(&d, one["Next"].(Reference)).(Dict)
+	three := lookup(&d, two["Next"].(Reference)).(Dict)
+	if lookup(&d, root["Last"].(Reference)).(Dict)["Title"] != LiteralString("Three") {
+		t.Fatal("last")
+	}
+	if two["Prev"] != root["First"] || three["Prev"] != one["Next"] || three["Next"] != nil || one["Prev"] != nil {
+		t.Fatal("links")
+	}
+	if one["Count"] != Integer(2) || two["Count"] != Integer(-3) || three["Count"] != nil {
+		t.Fatal(one["Count"], two["Count"])
+	}
+	a := lookup(&d, one["First"].(Reference)).(Dict)
+	if a["Parent"] != root["First"] {
+		t.Fatal("parent")
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+}
@@ -0,0 +1,55 @@
+package pdf
+
+import "errors"
+
+// Output intent subtypes, which say what an OutputIntent is for.
+const (
+	OutputIntentPDFA Name = "GTS_PDFA1"
+	OutputIntentPDFX Name = "GTS_PDFX"
+)
+
+// OutputIntent returns an output intent of the given subtype, such as
+// OutputIntentPDFA, that describes the intended output device or
+// production condition with profile, an ICC profile for a gray, RGB,
+// or CMYK color space. info identifies and describes the condition,
+// such as "sRGB IEC61966-2.1". The intent is added to a document with
+// Document.AddOutputIntent.
+func OutputIntent(subtype Name, profile []byte, info string) Object {
+	st := FlateBytes(profile)
+	st.Dict = Dict{}
+	if n := iccComponents(profile); n != 0 {
+		st.Dict["N"] = Integer(n)
+	}
+
+	return Dict{
+		"Type":                      Name("OutputIntent"),
+		"S":                         subtype,
+		"OutputConditionIdentifier": TextString(info),
+		"Info":                      TextString(info),
+		"DestOutputProfile":         st,
+	}
+}
+
+// AddOutputIntent adds the output intent intent, such as one returned
+// by OutputIntent, to the catalog's OutputIntents, adding its profile
+// to the document as well. d.Root must already be set to the catalog,
+// such as one returned by AddPages.
+func (d *Document) AddOutputIntent(intent Object) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	if dict, ok := intent.(Dict); ok {
+		if st, ok := dict["DestOutputProfile"].(Stream); ok {
+			if _, ok := st.Dict["N"]; !ok {
+				return errors.New("pdf: output intent profile is not an ICC profile for a gray, RGB, or CMYK color space")
+			}
+			dict["DestOutputProfile"] = d.Add(st)
+		}
+	}
+
+	intents, _ := catalog["OutputIntents"].(Array)
+	catalog["OutputIntents"] = append(intents, intent)
+	return nil
+}
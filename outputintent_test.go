@@ -0,0 +1,47 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOutputIntent(t *testing.T) {
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddOutputIntent(OutputIntent(OutputIntentPDFX, []byte("junk"), "x")); err == nil {
+		t.Fatal("bad profile")
+	}
+	if err := d.AddOutputIntent(OutputIntent(OutputIntentPDFA, testProfile(), "sRGB IEC61966-2.1")); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := map[string]Object{}
+	for _, o := range p.Body {
+		objs[o.Name] = o.Object
+	}
+	intents := objs[string(p.Root)].(Dict)["OutputIntents"].(Array)
+	if len(intents) != 1 {
+		t.Fatal(intents)
+	}
+	intent := intents[0].(Dict)
+	if intent["S"] != Name("GTS_PDFA1") || intent["OutputConditionIdentifier"] != LiteralString("sRGB IEC61966-2.1") {
+		t.Fatal(intent)
+	}
+	st := objs[string(intent["DestOutputProfile"].(Reference))].(Stream)
+	if st.Dict["N"] != Integer(3) {
+		t.Fatal(st.Dict)
+	}
+	validate(t, data)
+}
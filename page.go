@@ -0,0 +1,361 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Page is a single page of a document.
+type Page struct {
+	// MediaBox is the boundary of the physical medium that the page is
+	// to be displayed or printed on. If it is zero, the page inherits
+	// the one set for the whole page tree, if any; see AddPageTree.
+	MediaBox Rectangle
+
+	// CropBox is the region of the page that is displayed or printed.
+	// If it is zero, it is inherited like MediaBox, or defaults to the
+	// MediaBox.
+	CropBox Rectangle
+
+	// BleedBox, TrimBox, and ArtBox are the regions of the page to
+	// which its contents are clipped for production, the intended
+	// dimensions of the finished page, and the extent of its
+	// meaningful contents. They are left out if they are zero, in
+	// which case they default to the CropBox. Unlike CropBox, they
+	// can't be inherited.
+	BleedBox, TrimBox, ArtBox Rectangle
+
+	// Rotate points to the number of degrees by which the page is
+	// rotated clockwise when it is displayed or printed, which must be
+	// a multiple of 90. It is written between 0 and 270, so 360 is the
+	// same as 0. If it is nil, it is inherited like MediaBox.
+	Rotate *int
+
+	// Contents is the page's content stream. It may be a Stream, in
+	// which case it is added to the document as an indirect object, a
+	// Reference to a stream, or an Array of such references.
+	Contents Object
+
+	// Resources contains the resources needed by the page's content
+	// stream. If it is nil, it is inherited like MediaBox.
+	Resources Dict
+
+	// Annots are the page's annotations, such as those returned by
+	// LinkURI. Dicts are added to the document as indirect objects,
+	// with their P entries referring back to the page, as are any
+	// appearance streams that they contain.
+	Annots []Object
+}
+
+// pageBox is one of the boundaries of a page.
+type pageBox struct {
+	name Name
+	rect Rectangle
+}
+
+// boxes returns the boundaries of p other than MediaBox, whether or
+// not they are set.
+func (p Page) boxes() []pageBox {
+	return []pageBox{
+		{"CropBox", p.CropBox},
+		{"BleedBox", p.BleedBox},
+		{"TrimBox", p.TrimBox},
+		{"ArtBox", p.ArtBox},
+	}
+}
+
+// CheckBoxes returns an error listing any of the page's boundaries
+// that extend beyond its MediaBox. Such boxes are still valid, as
+// readers clip them to the MediaBox, so AddPages doesn't check them,
+// but they usually indicate a mistake. Pages that inherit their
+// MediaBox aren't checked.
+func (p Page) CheckBoxes() error {
+	if (p.MediaBox == Rectangle{}) {
+		return nil
+	}
+
+	var errs []error
+	for _, box := range p.boxes() {
+		if (box.rect != Rectangle{}) && !p.MediaBox.Contains(box.rect) {
+			errs = append(errs, fmt.Errorf("pdf: %v %v extends beyond MediaBox %v", box.name, box.rect, p.MediaBox))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// maxKids is the maximum number of children given to each node of a
+// page tree built by AddPages.
+const maxKids = 16
+
+// AddPages adds pages to d along with a page tree containing them and a
+// catalog referring to the tree. It returns a reference to the
+// catalog, which should normally become d.Root, and references to each
+// of the pages, in order.
+//
+// The page tree is balanced, with every page at the same depth and no
+// node having more than a handful of children.
+//
+// It returns an error, without adding anything, if a page has no
+// MediaBox or is rotated by something other than a multiple of 90
+// degrees.
+func (d *Document) AddPages(pages []Page) (catalog Reference, refs []Reference, err error) {
+	return d.AddPageTree(Page{}, pages)
+}
+
+// AddPageTree is like AddPages, but sets the MediaBox, CropBox,
+// Rotate, and Resources of tree, if they aren't empty, on the root of
+// the page tree, to be inherited by any pages that don't set their
+// own. The rest of tree is ignored.
+func (d *Document) AddPageTree(tree Page, pages []Page) (catalog Reference, refs []Reference, err error) {
+	err = checkRotate(tree.Rotate, "page tree")
+	if err != nil {
+		return "", nil, err
+	}
+	for i, page := range pages {
+		if (page.MediaBox == Rectangle{}) && (tree.MediaBox == Rectangle{}) {
+			return "", nil, fmt.Errorf("pdf: page %v has no MediaBox and none to inherit", i)
+		}
+		err := checkRotate(page.Rotate, fmt.Sprintf("page %v", i))
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	type node struct {
+		ref   Reference
+		dict  Dict
+		count int
+	}
+
+	level := make([]node, 0, len(pages))
+	for _, page := range pages {
+		dict := Dict{"Type": Name("Page")}
+		if (page.MediaBox != Rectangle{}) {
+			dict["MediaBox"] = page.MediaBox
+		}
+		page.inheritable(dict)
+		for _, box := range page.boxes() {
+			if (box.rect != Rectangle{}) {
+				dict[box.name] = box.rect
+			}
+		}
+		switch contents := page.Contents.(type) {
+		case nil:
+		case Stream:
+			dict["Contents"] = d.Add(contents)
+		default:
+			dict["Contents"] = contents
+		}
+
+		ref := d.addDistinct(dict)
+		if len(page.Annots) > 0 {
+			annots := make(Array, 0, len(page.Annots))
+			for _, annot := range page.Annots {
+				if annot, ok := annot.(Dict); ok {
+					annot["P"] = ref
+					d.addAppearances(annot)
+					annots = append(annots, d.addDistinct(annot))
+					continue
+				}
+				annots = append(annots, annot)
+			}
+			dict["Annots"] = annots
+		}
+
+		level = append(level, node{ref: ref, dict: dict, count: 1})
+		refs = append(refs, ref)
+	}
+
+	// Group each level into intermediate nodes until there's only one
+	// node left, which becomes the root. Parents are added after their
+	// children, so the children's Parent entries are filled in once
+	// the parent's reference is known.
+	for {
+		next := make([]node, 0, (len(level)+maxKids-1)/maxKids)
+		for len(level) > 0 || len(next) == 0 {
+			n := min(len(level), maxKids)
+			kids := make(Array, 0, n)
+			var count int
+			for _, kid := range level[:n] {
+				kids = append(kids, kid.ref)
+				count += kid.count
+			}
+
+			dict := Dict{
+				"Type":  Name("Pages"),
+				"Kids":  kids,
+				"Count": Integer(count),
+			}
+			ref := d.addDistinct(dict)
+			for _, kid := range level[:n] {
+				kid.dict["Parent"] = ref
+			}
+
+			next = append(next, node{ref: ref, dict: dict, count: count})
+			level = level[n:]
+		}
+
+		level = next
+		if len(level) == 1 {
+			break
+		}
+	}
+
+	root := level[0].dict
+	if (tree.MediaBox != Rectangle{}) {
+		root["MediaBox"] = tree.MediaBox
+	}
+	if (tree.CropBox != Rectangle{}) {
+		root["CropBox"] = tree.CropBox
+	}
+	tree.inheritable(root)
+
+	catalog = d.addDistinct(Dict{
+		"Type":  Name("Catalog"),
+		"Pages": level[0].ref,
+	})
+	return catalog, refs, nil
+}
+
+// checkRotate returns an error if rotate is set to something other
+// than a multiple of 90 degrees. what is the page or page tree that it
+// belongs to.
+func checkRotate(rotate *int, what string) error {
+	if (rotate != nil) && (*rotate%90 != 0) {
+		return fmt.Errorf("pdf: %v is rotated by %v degrees, which isn't a multiple of 90", what, *rotate)
+	}
+	return nil
+}
+
+// inheritable sets the attributes of p other than its boundaries that
+// can be inherited through a page tree in dict, a page or page tree
+// node, if they are set.
+func (p Page) inheritable(dict Dict) {
+	if p.Rotate != nil {
+		// Go's % keeps the sign of the dividend, so negative rotations
+		// need another turn.
+		dict["Rotate"] = Integer((*p.Rotate%360 + 360) % 360)
+	}
+	if p.Resources != nil {
+		dict["Resources"] = p.Resources
+	}
+}
+
+// Pages returns the pages in p's page tree, in order, for inspection,
+// such as with ExtractText. Each page has the attributes that it
+// inherits, and the references in its Contents and Resources are
+// replaced by the objects that they refer to, so that it can be used
+// on its own. Annots are left out. To copy pages into another
+// document, use Merge instead.
+func (p *PDF) Pages() ([]Page, error) {
+	objs := make(map[string]Indirect, len(p.Body))
+	for _, obj := range p.Body {
+		objs[obj.Name] = obj
+	}
+	catalog, ok := objs[string(p.Root)].Object.(Dict)
+	if !ok {
+		return nil, errors.New("pdf: Root does not refer to a catalog")
+	}
+
+	tree, _ := pageTree(objs, catalog)
+	pages := make([]Page, 0, len(tree))
+	for _, tp := range tree {
+		dict := objs[tp.name].Object.(Dict)
+		attr := func(k Name) Object {
+			if v, ok := dict[k]; ok {
+				return resolve(objs, v)
+			}
+			return resolve(objs, tp.attrs[k])
+		}
+
+		var page Page
+		page.MediaBox, _ = toRectangle(attr("MediaBox"))
+		page.CropBox, _ = toRectangle(attr("CropBox"))
+		page.BleedBox, _ = toRectangle(resolve(objs, dict["BleedBox"]))
+		page.TrimBox, _ = toRectangle(resolve(objs, dict["TrimBox"]))
+		page.ArtBox, _ = toRectangle(resolve(objs, dict["ArtBox"]))
+		if rotate, ok := attr("Rotate").(Integer); ok {
+			r := int(rotate)
+			page.Rotate = &r
+		}
+
+		res, ok := dict["Resources"]
+		if !ok && (len(tp.inherited) > 0) {
+			res = tp.inherited[len(tp.inherited)-1]
+		}
+		page.Resources, _ = resolve(objs, res).(Dict)
+		if contents, ok := dict["Contents"]; ok {
+			page.Contents = resolve(objs, contents)
+		}
+
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// resolve returns a copy of obj with the references in it replaced by
+// the objects in objs that they refer to, recursively, except for
+// references that would lead back to an object already being
+// resolved, which are left as they are.
+func resolve(objs map[string]Indirect, obj Object) Object {
+	resolving := make(map[string]bool)
+	var walk func(obj Object) Object
+	walk = func(obj Object) Object {
+		switch obj := obj.(type) {
+		case Reference:
+			ind, ok := objs[string(obj)]
+			if !ok || resolving[string(obj)] {
+				return obj
+			}
+			resolving[string(obj)] = true
+			defer delete(resolving, string(obj))
+			return walk(ind.Object)
+		case Array:
+			a := make(Array, 0, len(obj))
+			for _, v := range obj {
+				a = append(a, walk(v))
+			}
+			return a
+		case Dict:
+			d := make(Dict, len(obj))
+			for k, v := range obj {
+				d[k] = walk(v)
+			}
+			return d
+		case Stream:
+			obj.Dict, _ = walk(obj.Dict).(Dict)
+			return obj
+		default:
+			return obj
+		}
+	}
+	return walk(obj)
+}
+
+// toRectangle converts an array of four numbers to a Rectangle.
+func toRectangle(obj Object) (Rectangle, bool) {
+	a, ok := obj.(Array)
+	if !ok || (len(a) != 4) {
+		return Rectangle{}, false
+	}
+	var v [4]float64
+	for i := range v {
+		v[i], ok = toNumber(a[i])
+		if !ok {
+			return Rectangle{}, false
+		}
+	}
+	return Rectangle{v[0], v[1], v[2], v[3]}, true
+}
+
+// toNumber returns the value of obj if it is an Integer or a Real.
+func toNumber(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case Integer:
+		return float64(obj), true
+	case Real:
+		return float64(obj), true
+	default:
+		return 0, false
+	}
+}
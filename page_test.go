@@ -0,0 +1,184 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func lookup(d *Document, r Reference) Object {
+	for _, obj := range d.Body {
+		if obj.Name == string(r) {
+			return obj.Object
+		}
+	}
+	return nil
+}
+
+func checkTree(t *testing.T, d *Document, ref Reference, parent Reference) int {
+	dict := lookup(d, ref).(Dict)
+	if parent != "" && dict["Parent"] != parent {
+		t.Fatalf("bad parent for %v", ref)
+	}
+	if dict["Type"] == Name("Page") {
+		return 1
+	}
+	var n int
+	for _, k := range dict["Kids"].(Array) {
+		n += checkTree(t, d, k.(Reference), ref)
+	}
+	if dict["Count"] != Integer(n) {
+		t.Fatalf("count %v != %v", dict["Count"], n)
+	}
+	return n
+}
+
+func TestPages(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 16, 17, 300} {
+		var d Document
+		pages := make([]Page, n)
+		for i := range pages {
+			pages[i] = Page{MediaBox: Rectangle{0, 0, 612, 792}, Contents: Stream{Data: strings.NewReader("q Q")}}
+		}
+		cat, refs, err := d.AddPages(pages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Root = cat
+		if len(refs) != n {
+			t.Fatal(len(refs))
+		}
+		root := lookup(&d, cat).(Dict)["Pages"].(Reference)
+		if _, ok := lookup(&d, root).(Dict)["Parent"]; ok {
+			t.Fatal("root has parent")
+		}
+		if got := checkTree(t, &d, root, ""); got != n {
+			t.Fatal(got)
+		}
+		var buf bytes.Buffer
+		if _, err := d.Finish(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func degrees(n int) *int {
+	return &n
+}
+
+func TestPageTreeInheritance(t *testing.T) {
+	var d Document
+	res := Dict{"ProcSet": Array{Name("PDF")}}
+	var err error
+	d.Root, _, err = d.AddPageTree(Page{MediaBox: A4, Rotate: degrees(90), Resources: res}, []Page{
+		{},
+		{Rotate: degrees(180)},
+		{Rotate: degrees(360), MediaBox: Rectangle{0, 0, 100, 100}},
+		{Rotate: degrees(0)},
+		{Rotate: degrees(-90)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := map[string]Object{}
+	for _, o := range p.Body {
+		objs[o.Name] = o.Object
+	}
+	root := objs[string(objs[string(p.Root)].(Dict)["Pages"].(Reference))].(Dict)
+	if root["Rotate"] != Integer(90) || root["MediaBox"] == nil || root["Resources"] == nil {
+		t.Fatal(root)
+	}
+	var pages []Dict
+	for _, kid := range root["Kids"].(Array) {
+		pages = append(pages, objs[string(kid.(Reference))].(Dict))
+	}
+	if _, ok := pages[0]["Rotate"]; ok || pages[0]["MediaBox"] != nil || pages[0]["Resources"] != nil {
+		t.Fatal(pages[0])
+	}
+	if pages[1]["Rotate"] != Integer(180) || pages[2]["Rotate"] != Integer(0) || pages[2]["MediaBox"] == nil {
+		t.Fatal(pages)
+	}
+	if pages[3]["Rotate"] != Integer(0) || pages[4]["Rotate"] != Integer(270) {
+		t.Fatal(pages[3], pages[4])
+	}
+	validate(t, data)
+
+	decoded, err := p.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded[0].Rotate != 90 || *decoded[3].Rotate != 0 {
+		t.Fatal(*decoded[0].Rotate, *decoded[3].Rotate)
+	}
+
+	var e Document
+	n := len(e.Body)
+	_, _, err = e.AddPages([]Page{{MediaBox: A4}, {MediaBox: A4, Rotate: degrees(45)}})
+	if err == nil || !strings.Contains(err.Error(), "page 1 is rotated by 45 degrees") || len(e.Body) != n {
+		t.Fatal(err)
+	}
+	_, _, err = e.AddPageTree(Page{MediaBox: A4, Rotate: degrees(100)}, []Page{{}})
+	if err == nil || !strings.Contains(err.Error(), "multiple of 90") {
+		t.Fatal(err)
+	}
+}
+
+func TestPageMissingMediaBox(t *testing.T) {
+	var d Document
+	_, _, err := d.AddPages([]Page{{MediaBox: A4}, {}})
+	if err == nil || !strings.Contains(err.Error(), "page 1 has no MediaBox") || len(d.Body) != 0 {
+		t.Fatal(err)
+	}
+	if _, _, err := d.AddPageTree(Page{MediaBox: A4}, []Page{{}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPageBoxes(t *testing.T) {
+	page := Page{MediaBox: A4, TrimBox: Rectangle{20, 20, 575, 821}}
+	if err := page.CheckBoxes(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Page{MediaBox: A4, ArtBox: Rectangle{-1, 0, 10, 10}}).CheckBoxes(); err == nil {
+		t.Fatal("art box")
+	}
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range p.Body {
+		dict, ok := o.Object.(Dict)
+		if !ok || dict["Type"] != Name("Page") {
+			continue
+		}
+		if dict["TrimBox"] == nil || dict["MediaBox"] == nil {
+			t.Fatal(dict)
+		}
+		for _, k := range []Name{"CropBox", "BleedBox", "ArtBox", "Rotate"} {
+			if _, ok := dict[k]; ok {
+				t.Fatal(k)
+			}
+		}
+	}
+	validate(t, data)
+}
@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// PageLabelStyle is the numbering style of a range of page labels.
+type PageLabelStyle string
+
+// Page label numbering styles. PageLabelNone labels pages with only
+// their prefix.
+const (
+	PageLabelNone       PageLabelStyle = ""
+	PageLabelDecimal    PageLabelStyle = "D"
+	PageLabelUpperRoman PageLabelStyle = "R"
+	PageLabelLowerRoman PageLabelStyle = "r"
+	PageLabelUpperAlpha PageLabelStyle = "A"
+	PageLabelLowerAlpha PageLabelStyle = "a"
+)
+
+// PageLabel describes the labels of a range of pages, from the page
+// with index Page, counting from zero, up to the start of the next
+// range.
+type PageLabel struct {
+	Page   int
+	Style  PageLabelStyle
+	Prefix string
+
+	// Start is the number of the first page in the range. Values less
+	// than 1 are treated as 1.
+	Start int
+}
+
+func (l PageLabel) encode(s *encodeState) error {
+	dict := Dict{"Type": Name("PageLabel")}
+	if l.Style != PageLabelNone {
+		dict["S"] = Name(l.Style)
+	}
+	if l.Prefix != "" {
+		dict["P"] = TextString(l.Prefix)
+	}
+	if l.Start > 1 {
+		dict["St"] = Integer(l.Start)
+	}
+	return dict.encode(s)
+}
+
+// SetPageLabels adds a number tree containing labels to the document
+// and refers to it from the catalog. d.Root must already be set to the
+// catalog, such as one returned by AddPages.
+//
+// Labels may be given in any order, but there must be one beginning at
+// the first page.
+func (d *Document) SetPageLabels(labels []PageLabel) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	labels = slices.Clone(labels)
+	slices.SortFunc(labels, func(a, b PageLabel) int { return a.Page - b.Page })
+	if (len(labels) == 0) || (labels[0].Page != 0) {
+		return errors.New("pdf: page labels must begin at the first page")
+	}
+
+	entries := make([]treeEntry, 0, len(labels))
+	for i, l := range labels {
+		if (i > 0) && (l.Page == labels[i-1].Page) {
+			return fmt.Errorf("pdf: duplicate page labels for page %v", l.Page)
+		}
+		entries = append(entries, treeEntry{key: Integer(l.Page), value: l})
+	}
+
+	catalog["PageLabels"] = d.addTree("Nums", entries)
+	return nil
+}
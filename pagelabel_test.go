@@ -0,0 +1,42 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPageLabels(t *testing.T) {
+	var d Document
+	pages := make([]Page, 6)
+	for i := range pages {
+		pages[i].MediaBox = A4
+	}
+	catalog, _, err := d.AddPages(pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	if err := d.SetPageLabels([]PageLabel{{Page: 2, Style: PageLabelDecimal}}); err == nil {
+		t.Fatal("no first")
+	}
+	err = d.SetPageLabels([]PageLabel{
+		{Page: 3, Style: PageLabelDecimal},
+		{Page: 0, Style: PageLabelLowerRoman},
+		{Page: 5, Style: PageLabelUpperAlpha, Prefix: "App-", Start: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := lookup(&d, lookup(&d, catalog).(Dict)["PageLabels"].(Reference)).(Dict)
+	leaf := lookup(&d, root["Kids"].(Array)[0].(Reference)).(Dict)
+	var buf bytes.Buffer
+	EncodeObject(&buf, leaf["Nums"])
+	if buf.String() != "[0 <</S /r /Type /PageLabel >> 3 <</S /D /Type /PageLabel >> 5 <</P (App-) /S /A /St 2 /Type /PageLabel >>]" {
+		t.Fatal("nums")
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+}
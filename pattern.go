@@ -0,0 +1,83 @@
+package pdf
+
+import "errors"
+
+// TilingPattern is a pattern that fills an area by repeating a cell of
+// content at fixed intervals. It must be added to a document as an
+// indirect object and named in a resource dictionary's Pattern entry,
+// after which Content.SetFillPattern and Content.SetStrokePattern can
+// paint with it.
+type TilingPattern struct {
+	// BBox is the bounding box of the pattern cell, in pattern space.
+	BBox Rectangle
+
+	// XStep and YStep are the horizontal and vertical distances
+	// between cells, in pattern space. If they are zero, the width and
+	// height of BBox are used, respectively.
+	XStep, YStep float64
+
+	// Uncolored causes the cell's content to be painted in the color
+	// given when the pattern is selected, rather than in colors of its
+	// own. The content must not set any colors in that case.
+	Uncolored bool
+
+	// Matrix maps pattern space to the default coordinate space of
+	// the page or form that uses the pattern. The zero value is
+	// treated as Identity.
+	Matrix Matrix
+
+	// Resources contains the resources needed by the cell's content.
+	Resources Dict
+
+	// Content is the content stream that draws a single cell. It must
+	// be a Stream, such as one returned by Content.Stream, or nil for
+	// an empty cell.
+	Content Object
+}
+
+func (p TilingPattern) encode(s *encodeState) error {
+	var st Stream
+	switch content := p.Content.(type) {
+	case nil:
+	case Stream:
+		st = content
+	default:
+		return errors.New("pdf: TilingPattern.Content must be a Stream")
+	}
+
+	bbox := p.BBox.Normalize()
+	xstep, ystep := p.XStep, p.YStep
+	if xstep == 0 {
+		xstep = bbox.URX - bbox.LLX
+	}
+	if ystep == 0 {
+		ystep = bbox.URY - bbox.LLY
+	}
+	paintType := 1
+	if p.Uncolored {
+		paintType = 2
+	}
+	resources := p.Resources
+	if resources == nil {
+		resources = Dict{}
+	}
+
+	dict := make(Dict, len(st.Dict)+9)
+	for k, v := range st.Dict {
+		dict[k] = v
+	}
+	dict["Type"] = Name("Pattern")
+	dict["PatternType"] = Integer(1)
+	dict["PaintType"] = Integer(paintType)
+	dict["TilingType"] = Integer(1)
+	dict["BBox"] = p.BBox
+	dict["XStep"] = Real(xstep)
+	dict["YStep"] = Real(ystep)
+	dict["Resources"] = resources
+	if (p.Matrix != Matrix{}) && (p.Matrix != Identity) {
+		dict["Matrix"] = p.Matrix
+	}
+	st.Dict = dict
+
+	return st.encode(s)
+}
@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTilingPattern(t *testing.T) {
+	var cell Content
+	cell.SetLineWidth(1)
+	cell.MoveTo(0, 0)
+	cell.LineTo(10, 10)
+	cell.Stroke()
+	var d Document
+	hatch := d.Add(TilingPattern{BBox: Rectangle{0, 0, 10, 10}, Content: cell.Stream()})
+	unc := d.Add(TilingPattern{BBox: Rectangle{0, 0, 5, 5}, XStep: 8, Uncolored: true, Matrix: Scale(2, 2), Content: cell.Stream()})
+	var c Content
+	c.SetFillColorSpace("Pattern")
+	c.SetFillPattern("P1")
+	c.Rectangle(50, 50, 200, 200)
+	c.Fill()
+	c.SetFillColorSpace("CS0")
+	c.SetFillPattern("P2", 1, 0, 0)
+	c.SetStrokeColorSpace("Pattern")
+	c.SetStrokePattern("P1")
+	c.Rectangle(300, 300, 100, 100)
+	c.FillStroke()
+	if !strings.Contains(string(c.Bytes()), "/Pattern cs\n/P1 scn\n") || !strings.Contains(string(c.Bytes()), "/CS0 cs\n1 0 0 /P2 scn\n") || !strings.Contains(string(c.Bytes()), "/Pattern CS\n/P1 SCN\n") {
+		t.Fatal(string(c.Bytes()))
+	}
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{
+		"Pattern":    Dict{"P1": hatch, "P2": unc},
+		"ColorSpace": Dict{"CS0": Array{Name("Pattern"), Name("DeviceRGB")}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.ObjectStreams = true
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	for _, want := range []string{"/PaintType 1 /PatternType 1 /Resources <<>> /TilingType 1 /Type /Pattern /XStep 10 /YStep 10", "/Matrix [2 0 0 2 0 0] /PaintType 2", "/XStep 8 /YStep 5"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Fatal(want)
+		}
+	}
+	validate(t, data)
+}
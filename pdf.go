@@ -0,0 +1,852 @@
+// Package pdf implements a low-level encoder for PDF documents.
+//
+// A document is built from the Object types defined in this package,
+// which map directly onto the basic object types of the PDF
+// specification. Indirect objects are identified by name rather than
+// by number; the encoder assigns object numbers as it writes them.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Version is the version of the PDF specification that output
+// produced by this package claims to conform to by default; see
+// PDF.Version.
+const Version = "1.7"
+
+// versions are the versions of the PDF specification that PDF.Version
+// may be set to, in order.
+var versions = []string{"1.0", "1.1", "1.2", "1.3", "1.4", "1.5", "1.6", "1.7", "2.0"}
+
+// PDF is a complete PDF document.
+type PDF struct {
+	// Body contains the indirect objects that make up the document.
+	Body []Indirect
+
+	// Root is a reference to the document's catalog. It must be set.
+	Root Reference
+
+	// Info is an optional reference to the document's information
+	// dictionary.
+	Info Reference
+
+	// ID is the pair of file identifiers written to the trailer. The
+	// first is meant to be fixed when a file is first created, while
+	// the second changes every time that it is modified. If the first
+	// is nil, no identifiers are written, unless the document is
+	// encrypted, in which case random ones are.
+	ID [2][]byte
+
+	// Encryption, if not nil, causes the document to be encrypted.
+	Encryption *Encryption
+
+	// Version is the version of the PDF specification that the file
+	// claims to conform to, such as "1.4" or "2.0". Encoding fails if
+	// the document's options need a later version, such as 1.5 for
+	// XrefStream. If it is empty, the package's Version is used, or
+	// 2.0 if that is needed for 256-bit AES encryption.
+	Version string
+
+	// HexLineLength is the number of hex digits written per line when
+	// encoding a HexString. If it is zero, DefaultHexLineLength is
+	// used. If it is negative, hex strings are never split.
+	HexLineLength int
+
+	// Indent, if not empty, causes arrays and dictionaries to be
+	// written with each element or entry on its own line, indented by
+	// Indent once for each level of nesting, to make the output easier
+	// to read. Files are considerably larger this way, so it is meant
+	// for debugging.
+	Indent string
+
+	// XrefStream causes the cross-reference table and trailer to be
+	// written as a single compressed cross-reference stream instead.
+	// Readers older than PDF 1.5 can't read such files.
+	XrefStream bool
+
+	// ObjectStreams causes objects other than streams to be packed
+	// together into compressed object streams, which can make files
+	// with many small objects considerably smaller. It implies
+	// XrefStream.
+	ObjectStreams bool
+
+	// Linearize causes the file to be arranged so that its first page
+	// can be displayed before the rest of it has been read, such as
+	// while it is still being downloaded. The document must have a
+	// page tree. It can't be combined with XrefStream or
+	// ObjectStreams.
+	Linearize bool
+
+	// StreamIndirectLength causes streams that would otherwise have to
+	// be buffered in memory to find their lengths, such as those with
+	// filters or without a Length, to be written straight to the file
+	// instead, with Length referring to a separate object written
+	// after the stream. Streams in linearized or encrypted files are
+	// still buffered.
+	StreamIndirectLength bool
+}
+
+// maxObjStm is the maximum number of objects packed into each object
+// stream.
+const maxObjStm = 100
+
+// DefaultHexLineLength is the default value of PDF.HexLineLength.
+const DefaultHexLineLength = 64
+
+// EncodeResult describes a file written by Encode.
+type EncodeResult struct {
+	// Size is the number of bytes written.
+	Size int64
+
+	// StartXref is the offset of the cross-reference section that the
+	// end of the file points to. For a linearized file, that is the
+	// first page's section.
+	StartXref int64
+
+	// Signatures are the placeholders reserved for each Signature in
+	// the file, in the order that they were written.
+	Signatures []SignaturePlaceholder
+}
+
+// Encode writes p to w as a complete PDF file.
+func Encode(w io.Writer, p *PDF) (EncodeResult, error) {
+	return EncodeContext(context.Background(), w, p)
+}
+
+// EncodeBytes returns p encoded as a complete PDF file, as Encode
+// would write it.
+func EncodeBytes(p *PDF) ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := Encode(&buf, p)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo writes p to w as a complete PDF file, as Encode does, so that
+// PDF implements io.WriterTo. It returns the number of bytes written,
+// including any written before an error.
+func (p *PDF) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	_, err := Encode(cw, p)
+	return cw.n, err
+}
+
+// EncodeContext is like Encode, but stops early if ctx is done,
+// returning the context's error, possibly wrapped in an EncodeError.
+// The context is checked before each object is written and while
+// stream data is being read.
+func EncodeContext(ctx context.Context, w io.Writer, p *PDF) (EncodeResult, error) {
+	if p.Root == "" {
+		return EncodeResult{}, errors.New("pdf: PDF.Root must be set")
+	}
+
+	s := newEncodeState(w)
+	defer s.release()
+	s.ctx = ctx
+	s.file = new(fileState)
+	err := s.encode(p)
+	if err != nil {
+		return EncodeResult{}, err
+	}
+	return s.result(), nil
+}
+
+// version returns the version of the PDF specification that p should
+// be written as; see PDF.Version.
+func (p *PDF) version() string {
+	if p.Version != "" {
+		return p.Version
+	}
+	if (p.Encryption != nil) && p.Encryption.AES && (p.Encryption.KeyLength == 256) {
+		return "2.0"
+	}
+	return Version
+}
+
+// checkVersion returns an error if version isn't a known version of the
+// PDF specification or if p's options use features that it lacks.
+func (p *PDF) checkVersion(version string) error {
+	if !slices.Contains(versions, version) {
+		return fmt.Errorf("pdf: unknown PDF version %q", version)
+	}
+
+	e := p.Encryption
+	features := []struct {
+		used bool
+		min  string
+		name string
+	}{
+		{(e != nil) && e.AES && (e.KeyLength == 256), "2.0", "256-bit AES encryption"},
+		{(e != nil) && e.AES, "1.6", "AES encryption"},
+		{p.ObjectStreams, "1.5", "ObjectStreams"},
+		{p.XrefStream, "1.5", "XrefStream"},
+		{(e != nil) && (e.KeyLength != 40), "1.4", "128-bit encryption"},
+		{p.Linearize, "1.2", "Linearize"},
+	}
+
+	// Known versions all sort correctly as strings. The features are
+	// checked from the latest requirement down so that the error gives
+	// the version that is actually needed.
+	for _, f := range features {
+		if f.used && (version < f.min) {
+			return fmt.Errorf("pdf: %v requires PDF %v or later, not %v", f.name, f.min, version)
+		}
+	}
+	return nil
+}
+
+// encode writes p as a complete PDF file.
+func (s *encodeState) encode(p *PDF) error {
+	if p.HexLineLength != 0 {
+		s.hexLine = p.HexLineLength
+	}
+	s.indent = p.Indent
+	if p.Encryption != nil {
+		if p.ID[0] == nil {
+			c := *p
+			id := newFileID()
+			c.ID = [2][]byte{id, id}
+			p = &c
+		}
+
+		var err error
+		s.crypt, err = p.Encryption.handler(p.ID[0])
+		if err != nil {
+			return err
+		}
+		s.encrypt = s.crypt.encrypt
+	}
+
+	version := p.version()
+	err := p.checkVersion(version)
+	if err != nil {
+		return err
+	}
+
+	// The comment after the header has bytes above 127 so that tools
+	// that guess at whether files are text treat it as binary.
+	_, err = fmt.Fprintf(s, "%%PDF-%v\n%%\xE2\xE3\xCF\xD3\n", version)
+	if err != nil {
+		return err
+	}
+	if p.Linearize {
+		return s.encodeLinearized(p)
+	}
+
+	// Number the body up front so that the cross-reference table lines
+	// up with it no matter what order references show up in.
+	for _, obj := range p.Body {
+		if _, ok := s.names[obj.Name]; ok {
+			return fmt.Errorf("pdf: duplicate object name %q", obj.Name)
+		}
+		s.objName(obj.Name, obj.Generation)
+	}
+	if s.crypt != nil {
+		s.crypt.num = s.newObjNum()
+		err := s.encodeUnnamed(s.crypt.num, s.crypt.dict)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Only streams in the body get indirect lengths. The encoder's
+	// own streams are already in memory, and cross-reference streams
+	// can't have them.
+	s.file.indirectLength = p.StreamIndirectLength
+	var packed []Indirect
+	for i, obj := range p.Body {
+		if p.ObjectStreams && (obj.Generation == 0) && !isStream(obj.Object) {
+			packed = append(packed, obj)
+			continue
+		}
+
+		err := s.ctx.Err()
+		if err != nil {
+			return err
+		}
+		err = obj.encode(s)
+		if err != nil {
+			return atPath(err, fmt.Sprintf("Body[%v]", i))
+		}
+		_, err = s.WriteString("\n")
+		if err != nil {
+			return err
+		}
+		err = s.encodeLengths()
+		if err != nil {
+			return err
+		}
+	}
+	s.file.indirectLength = false
+	for len(packed) > 0 {
+		n := min(len(packed), maxObjStm)
+		err := s.encodeObjStm(packed[:n])
+		if err != nil {
+			return err
+		}
+		packed = packed[n:]
+	}
+
+	s.startxref = s.offset()
+	if p.XrefStream || p.ObjectStreams {
+		err = s.encodeXrefStream(p)
+		if err != nil {
+			return err
+		}
+	} else {
+		// References to objects that don't exist still get numbers,
+		// so the table covers those, too.
+		count := len(s.names) + s.unnamed
+		err = s.encodeXref(count)
+		if err != nil {
+			return err
+		}
+
+		err = s.encodeTrailer(p, count+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(s, "startxref\n%v\n%%%%EOF\n", s.startxref)
+	if err != nil {
+		return err
+	}
+
+	return s.Flush()
+}
+
+// EncodeError is returned when an object can't be encoded. It records
+// where in the document the object is.
+type EncodeError struct {
+	// Path locates the object, starting from the PDF if it is known.
+	// Dictionary entries are given by their keys and array elements by
+	// their indices, so Body[2]/Pages/Kids[0]/MediaBox[3] is the last
+	// element of the MediaBox of the first kid of the Pages entry of
+	// the third object in the body.
+	Path string
+	Err  error
+}
+
+func (err *EncodeError) Error() string {
+	return fmt.Sprintf("pdf: %v: %v", err.Path, strings.TrimPrefix(err.Err.Error(), "pdf: "))
+}
+
+func (err *EncodeError) Unwrap() error {
+	return err.Err
+}
+
+// atPath prepends elem to the path of err, turning it into an
+// EncodeError if it isn't one already.
+func atPath(err error, elem string) error {
+	if err, ok := err.(*EncodeError); ok {
+		err.Path = elem + err.Path
+		return err
+	}
+	return &EncodeError{Path: elem, Err: err}
+}
+
+// encodeXref writes a cross-reference table covering the first count
+// objects. Those that were never written are listed as free.
+func (s *encodeState) encodeXref(count int) error {
+	_, err := fmt.Fprintf(s, "xref\n0 %v\n0000000000 65535 f \n", count+1)
+	if err != nil {
+		return err
+	}
+
+	gens := make(map[int]int, len(s.names))
+	for _, id := range s.names {
+		gens[id.num] = id.gen
+	}
+	for num := 1; num <= count; num++ {
+		off, ok := s.offsets[num]
+		if !ok {
+			_, err := s.WriteString("0000000000 00000 f \n")
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, err := fmt.Fprintf(s, "%010d %05d n \n", off, gens[num])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isStream returns true if obj is encoded as a stream, and so can't
+// be stored in an object stream. Signatures can't be either, so they
+// count as streams too.
+func isStream(obj Object) bool {
+	switch obj.(type) {
+	case Stream, FormXObject, TilingPattern, SampledFunction, PostScriptFunction, Signature:
+		return true
+	default:
+		return false
+	}
+}
+
+// encodeObjStm writes objs, which must not be streams, as a single
+// object stream numbered after everything else in the document.
+func (s *encodeState) encodeObjStm(objs []Indirect) error {
+	// The stream is numbered before sub copies the count of unnamed
+	// objects so that names first seen in objs are numbered after it.
+	num := s.newObjNum()
+	var header, data bytes.Buffer
+	sub := s.with(&data)
+	defer sub.release()
+
+	// The objects are encrypted along with the rest of the stream, not
+	// individually.
+	sub.encrypt = nil
+	for i, obj := range objs {
+		id := s.names[obj.Name]
+		fmt.Fprintf(&header, "%v %v ", id.num, sub.offset())
+		s.packed[id.num] = packedObj{stream: num, index: i}
+
+		err := s.ctx.Err()
+		if err != nil {
+			return err
+		}
+		sub.obj = id
+		err = EncodeObject(sub, obj.Object)
+		if err != nil {
+			// Body objects are numbered in order, starting from one.
+			return atPath(err, fmt.Sprintf("Body[%v]", id.num-1))
+		}
+		err = sub.WriteByte('\n')
+		if err != nil {
+			return err
+		}
+	}
+	err := sub.Flush()
+	if err != nil {
+		return err
+	}
+
+	st := FlateBytes(append(header.Bytes(), data.Bytes()...))
+	st.Dict = Dict{
+		"Type":  Name("ObjStm"),
+		"N":     Integer(len(objs)),
+		"First": Integer(header.Len()),
+	}
+	return s.encodeUnnamed(num, st)
+}
+
+// encodeLengths writes the objects holding the indirect lengths of the
+// streams written so far.
+func (s *encodeState) encodeLengths() error {
+	for _, l := range s.file.lengths {
+		err := s.encodeUnnamed(l.num, Integer(l.n))
+		if err != nil {
+			return err
+		}
+		_, err = s.WriteString("\n")
+		if err != nil {
+			return err
+		}
+	}
+	s.file.lengths = s.file.lengths[:0]
+	return nil
+}
+
+// encodeUnnamed writes obj as the indirect object numbered num, which
+// must have been allocated by newObjNum.
+func (s *encodeState) encodeUnnamed(num int, obj Object) error {
+	s.offsets[num] = s.offset()
+
+	_, err := fmt.Fprintf(s, "%v 0 obj\n", num)
+	if err != nil {
+		return err
+	}
+
+	s.obj = objID{num: num}
+	err = obj.encode(s)
+	s.obj = objID{}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.WriteString("\nendobj\n")
+	return err
+}
+
+// encodeXrefStream writes a cross-reference stream covering every
+// object written so far as the last object in the file.
+func (s *encodeState) encodeXrefStream(p *PDF) error {
+	num := s.newObjNum()
+	xref := s.offset()
+	s.offsets[num] = xref
+
+	gens := make(map[int]int, len(s.names))
+	for _, id := range s.names {
+		gens[id.num] = id.gen
+	}
+
+	// The offsets get as large as that of the stream itself, which
+	// covers itself too, while the third column needs two bytes for
+	// the 65535 of the free entry at the head of the table.
+	var offWidth int
+	for v := xref; v > 0; v >>= 8 {
+		offWidth++
+	}
+	w := [3]int{1, max(offWidth, 1), 2}
+	row := w[0] + w[1] + w[2]
+
+	data := make([]byte, 0, row*(num+1))
+	put := func(typ int, field2 int64, field3 int) {
+		data = append(data, byte(typ))
+		for i := w[1] - 1; i >= 0; i-- {
+			data = append(data, byte(field2>>(8*i)))
+		}
+		data = append(data, byte(field3>>8), byte(field3))
+	}
+
+	put(0, 0, 65535)
+	for i := 1; i <= num; i++ {
+		if obj, ok := s.packed[i]; ok {
+			put(2, int64(obj.stream), obj.index)
+			continue
+		}
+		off, ok := s.offsets[i]
+		if !ok {
+			// Referenced objects that don't exist are treated as free.
+			put(0, 0, 0)
+			continue
+		}
+		put(1, off, gens[i])
+	}
+
+	dict := s.trailer(p, num+1)
+	dict["Type"] = Name("XRef")
+	dict["W"] = Array{Integer(w[0]), Integer(w[1]), Integer(w[2])}
+
+	// Cross-reference streams are never encrypted, as readers need
+	// them to find everything else, and neither is the trailer that
+	// they contain.
+	s.encrypt = nil
+
+	st := FlateBytes(data)
+	st.Dict = dict
+	return s.encodeUnnamed(num, st)
+}
+
+// trailer returns the entries of the trailer dictionary for p, given
+// the number of entries in the cross-reference table.
+func (s *encodeState) trailer(p *PDF, size int) Dict {
+	trailer := Dict{
+		"Size": Integer(size),
+		"Root": p.Root,
+	}
+	if p.Info != "" {
+		trailer["Info"] = p.Info
+	}
+	if p.ID[0] != nil {
+		trailer["ID"] = Array{HexString(p.ID[0]), HexString(p.ID[1])}
+	}
+	if s.crypt != nil {
+		trailer["Encrypt"] = ObjectRef{Number: s.crypt.num}
+	}
+	return trailer
+}
+
+// encodeTrailer writes the trailer dictionary for p, given the number
+// of entries in the cross-reference table.
+func (s *encodeState) encodeTrailer(p *PDF, size int) error {
+	_, err := s.WriteString("trailer\n")
+	if err != nil {
+		return err
+	}
+
+	err = s.trailer(p, size).encode(s)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.WriteString("\n")
+	return err
+}
+
+type encodeState struct {
+	*bufio.Writer
+	w countWriter
+
+	names   map[string]objID
+	offsets map[int]int64
+
+	// gens holds the generations of the Indirects given to
+	// EncodeObject, by name, so that References to them that come
+	// first are numbered with the right generation.
+	gens map[string]int
+
+	// reserved holds the numbers of the IndirectRefs given to
+	// EncodeObject, which objects numbered by name skip.
+	reserved map[int]bool
+
+	// unnamed is the number of objects, such as object streams, that
+	// have been numbered without being given names.
+	unnamed int
+
+	// packed holds the locations of objects stored in object streams,
+	// by object number.
+	packed map[int]packedObj
+
+	hexLine int
+
+	// scratch is reused for formatting numbers.
+	scratch []byte
+
+	// indent is written depth times at the start of each line of an
+	// array or dictionary if it isn't empty; see PDF.Indent.
+	indent string
+	depth  int
+
+	// dicts is the number of Dicts that the object being written is
+	// inside of. Indirect objects can't be.
+	dicts int
+
+	// crypt is the security handler of an encrypted document, which
+	// holds the encryption dictionary.
+	crypt *securityHandler
+
+	// encrypt, if it isn't nil, encrypts the data of the strings and
+	// streams of the object being written, identified by obj. Those
+	// outside of any object, such as in the trailer, and those of the
+	// encryption dictionary are left alone.
+	encrypt func(objNum, gen int, data []byte) []byte
+	obj     objID
+
+	// ctx is checked as objects and stream data are written so that
+	// encoding can be canceled.
+	ctx context.Context
+
+	// startxref is the offset of the last cross-reference section
+	// written.
+	startxref int64
+
+	// file is nil unless s writes directly to the file, rather than
+	// to a buffer that is copied into it later.
+	file *fileState
+}
+
+// fileState is the state of an encodeState that only makes sense when
+// writing directly to the file, where offsets are final.
+type fileState struct {
+	// signatures collects the placeholders of the signatures written.
+	signatures []SignaturePlaceholder
+
+	// indirectLength is set if streams may be written with indirect
+	// lengths. lengths holds the lengths that haven't been written yet.
+	indirectLength bool
+	lengths        []streamLength
+}
+
+// streamLength is the length of a stream written with an indirect
+// Length, along with the number of the object that holds it.
+type streamLength struct {
+	num int
+	n   int64
+}
+
+// packedObj is the location of an object within an object stream.
+type packedObj struct {
+	stream, index int
+}
+
+func newEncodeState(w io.Writer) *encodeState {
+	s := newObjectState(w)
+	s.names = make(map[string]objID)
+	s.offsets = make(map[int]int64)
+	s.packed = make(map[int]packedObj)
+	return s
+}
+
+// newObjectState returns an encodeState for writing a single object,
+// as for EncodeObject. The maps needed for writing a whole file are
+// left to be allocated if they turn out to be needed.
+func newObjectState(w io.Writer) *encodeState {
+	s := &encodeState{
+		hexLine: DefaultHexLineLength,
+		ctx:     context.Background(),
+	}
+	s.w.w = w
+	s.Writer = newWriter(&s.w)
+	return s
+}
+
+// writerPool holds the buffered writers of released encodeStates, as
+// one is needed for every call to EncodeObject that isn't nested in
+// another, such as for each operand written by a Content.
+var writerPool = sync.Pool{
+	New: func() any { return bufio.NewWriter(nil) },
+}
+
+// newWriter returns a buffered writer from writerPool that writes to
+// w.
+func newWriter(w io.Writer) *bufio.Writer {
+	bw := writerPool.Get().(*bufio.Writer)
+	bw.Reset(w)
+	return bw
+}
+
+// release returns the buffered writer of s to writerPool, discarding
+// anything that hasn't been flushed. s must not be used afterwards.
+func (s *encodeState) release() {
+	s.Writer.Reset(nil)
+	writerPool.Put(s.Writer)
+	s.Writer = nil
+}
+
+// objID identifies an indirect object by number and generation.
+type objID struct {
+	num, gen int
+}
+
+// objName returns the object ID assigned to name. If name hasn't been
+// seen yet, it is assigned the next available object number and the
+// generation gen.
+func (s *encodeState) objName(name string, gen int) objID {
+	if id, ok := s.names[name]; ok {
+		return id
+	}
+
+	if g, ok := s.gens[name]; ok {
+		gen = g
+	}
+	// Numbers taken by IndirectRefs are counted as unnamed objects.
+	for s.reserved[len(s.names)+s.unnamed+1] {
+		s.unnamed++
+	}
+	id := objID{num: len(s.names) + s.unnamed + 1, gen: gen}
+	if s.names == nil {
+		s.names = make(map[string]objID)
+	}
+	s.names[name] = id
+	return id
+}
+
+// newObjNum allocates an object number for an object without a name.
+func (s *encodeState) newObjNum() int {
+	s.unnamed++
+	return len(s.names) + s.unnamed
+}
+
+// with returns an encodeState that writes to w but otherwise shares
+// the state of s, so that references are numbered consistently. The
+// result isn't the file itself, so it has no fileState. It should be
+// released once it has been flushed.
+func (s *encodeState) with(w io.Writer) *encodeState {
+	sub := &encodeState{
+		names:    s.names,
+		offsets:  s.offsets,
+		gens:     s.gens,
+		reserved: s.reserved,
+		unnamed:  s.unnamed,
+		packed:   s.packed,
+
+		hexLine: s.hexLine,
+		indent:  s.indent,
+		crypt:   s.crypt,
+		encrypt: s.encrypt,
+		obj:     s.obj,
+		ctx:     s.ctx,
+	}
+	sub.w.w = w
+	sub.Writer = newWriter(&sub.w)
+	return sub
+}
+
+// newline starts a new line indented for the current depth.
+func (s *encodeState) newline() error {
+	err := s.WriteByte('\n')
+	if err != nil {
+		return err
+	}
+	for range s.depth {
+		_, err := s.WriteString(s.indent)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encrypting returns true if strings and streams written to s need to
+// be encrypted. The encryption dictionary itself never is.
+func (s *encodeState) encrypting() bool {
+	if (s.encrypt == nil) || (s.obj.num == 0) {
+		return false
+	}
+	return (s.crypt == nil) || (s.obj.num != s.crypt.num)
+}
+
+// encrypted returns data encrypted for the object being written if s
+// is encrypting, or as is otherwise.
+func (s *encodeState) encrypted(data []byte) []byte {
+	if !s.encrypting() {
+		return data
+	}
+	return s.encrypt(s.obj.num, s.obj.gen, data)
+}
+
+// result returns the EncodeResult for everything written to s.
+func (s *encodeState) result() EncodeResult {
+	return EncodeResult{
+		Size:       s.offset(),
+		StartXref:  s.startxref,
+		Signatures: s.file.signatures,
+	}
+}
+
+// offset returns the number of bytes written so far, including those
+// still sitting in the buffer.
+func (s *encodeState) offset() int64 {
+	return s.w.n + int64(s.Buffered())
+}
+
+// reader returns r, wrapped so that reading from it fails once the
+// context of s is done.
+func (s *encodeState) reader(r io.Reader) io.Reader {
+	if s.ctx.Done() == nil {
+		return r
+	}
+	return &contextReader{ctx: s.ctx, r: r}
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(buf []byte) (int, error) {
+	err := r.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+	return r.r.Read(buf)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(data []byte) (int, error) {
+	n, err := w.w.Write(data)
+	w.n += int64(n)
+	return n, err
+}
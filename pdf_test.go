@@ -0,0 +1,421 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func validate(t *testing.T, data []byte) {
+	t.Helper()
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	if err := api.Validate(bytes.NewReader(data), conf); err != nil {
+		t.Fatalf("pdfcpu validation: %v", err)
+	}
+}
+
+func TestXref(t *testing.T) {
+	p := &PDF{Body: []Indirect{
+		{Name: "a", Object: Dict{"Type": Name("Catalog"), "Pages": Reference("b")}},
+		{Name: "b", Object: Array{Integer(1), LiteralString("hi")}},
+		{Name: "c", Object: Reference("a")},
+	}, Root: "a"}
+	var buf bytes.Buffer
+	if _, err := Encode(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	sx, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[sx:], []byte("xref\n")) {
+		t.Fatal("bad startxref")
+	}
+	lines := strings.Split(string(out[sx:]), "\n")
+	if lines[1] != "0 4" || lines[2] != "0000000000 65535 f " {
+		t.Fatal(lines[:3])
+	}
+	for i := 1; i <= 3; i++ {
+		off, _ := strconv.Atoi(lines[2+i][:10])
+		if !bytes.HasPrefix(out[off:], []byte(fmt.Sprintf("%v 0 obj", i))) {
+			t.Fatalf("object %v offset %v wrong", i, off)
+		}
+		if len(lines[2+i]) != 19 {
+			t.Fatal("entry length")
+		}
+	}
+}
+
+func TestTrailer(t *testing.T) {
+	p := &PDF{Body: []Indirect{
+		{Name: "cat", Object: Dict{"Type": Name("Catalog")}},
+		{Name: "info", Object: Dict{"Title": LiteralString("x")}},
+	}, Root: "cat", Info: "info"}
+	var buf bytes.Buffer
+	if _, err := Encode(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`trailer\n(<<.*>>)\n`).FindSubmatch(buf.Bytes())
+	if m == nil {
+		t.Fatal(buf.String())
+	}
+	tr := string(m[1])
+	for _, want := range []string{"/Size 3 ", "/Root 1 0 R", "/Info 2 0 R"} {
+		if !bytes.Contains([]byte(tr), []byte(want)) {
+			t.Errorf("missing %q in %q", want, tr)
+		}
+	}
+	if _, err := Encode(&buf, &PDF{}); err == nil || err.Error() != "pdf: PDF.Root must be set" {
+		t.Fatal(err)
+	}
+}
+
+func TestObjectStreams(t *testing.T) {
+	var d Document
+	var c Content
+	c.Rectangle(1, 2, 3, 4)
+	c.Fill()
+	var dicts []Reference
+	for i := range 5 {
+		dicts = append(dicts, d.Add(Dict{"N": Integer(i), "S": LiteralString(fmt.Sprint("x", i))}))
+	}
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"Extra": Array{dicts[0], dicts[4]}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	d.Add(Reference("dangling"))
+	d.ObjectStreams = true
+	var out bytes.Buffer
+	if _, err := Encode(&out, &d.PDF); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if !bytes.Contains(data, []byte("/ObjStm")) || bytes.Contains(data, []byte("\n1 0 obj")) {
+		t.Fatal("no objstm")
+	}
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Body) != len(d.Body) {
+		t.Fatal(len(p.Body), len(d.Body))
+	}
+	for i := range 5 {
+		want := Dict{"N": Integer(i), "S": LiteralString(fmt.Sprint("x", i))}
+		if !reflect.DeepEqual(p.Body[i].Object, want) {
+			t.Fatal(p.Body[i])
+		}
+	}
+	// Many objects -> multiple streams.
+	var e Document
+	for i := range 250 {
+		e.Add(Integer(i))
+	}
+	cat, _, err := e.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Root = cat
+	e.ObjectStreams = true
+	out.Reset()
+	if _, err := e.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data = out.Bytes()
+	if bytes.Count(data, []byte("/ObjStm")) != 3 {
+		t.Fatal("streams")
+	}
+	q, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil || len(q.Body) != 253 || q.Body[249].Object != Integer(249) {
+		t.Fatal(err)
+	}
+	validate(t, data)
+}
+
+func TestObjectStreamDanglingReference(t *testing.T) {
+	var d Document
+	d.Root = d.Add(Dict{"Type": Name("Catalog"), "X": Reference("dangling")})
+	d.XrefStream = true
+	d.ObjectStreams = true
+	data, err := EncodeBytes(&d.PDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`(\d+) 0 obj\s*<<[^>]*/Type /ObjStm`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("no object stream")
+	}
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := p.Body[0].Object.(Dict)["X"].(Reference)
+	num, _, _ := strings.Cut(string(ref), " ")
+	if num == string(m[1]) {
+		t.Fatalf("dangling reference %v points at the object stream", ref)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	var d Document
+	d.Add(Integer(1))
+	d.Add(Integer(2))
+	d.Add(Dict{"Pages": Dict{"Kids": Array{Dict{"MediaBox": Array{Integer(0), Integer(0), Integer(1), Real(math.NaN())}}}}})
+	d.Root = d.Add(Dict{"Type": Name("Catalog")})
+	for _, objstm := range []bool{false, true} {
+		d.ObjectStreams = objstm
+		var out bytes.Buffer
+		_, err := Encode(&out, &d.PDF)
+		var e *EncodeError
+		if !errors.As(err, &e) {
+			t.Fatal(err)
+		}
+		if e.Path != "Body[2]/Pages/Kids[0]/MediaBox[3]" {
+			t.Fatal(e.Path)
+		}
+		if strings.Count(err.Error(), "pdf:") != 1 {
+			t.Fatal(err)
+		}
+	}
+}
+
+type endless struct {
+	ctxCancel func()
+	n         int
+}
+
+func (r *endless) Read(buf []byte) (int, error) {
+	r.n++
+	if r.n == 10 {
+		r.ctxCancel()
+	}
+	if r.n > 1000 {
+		return 0, errors.New("read too far")
+	}
+	return len(buf), nil
+}
+
+func TestEncodeContext(t *testing.T) {
+	var d Document
+	d.Root = d.Add(Dict{"Type": Name("Catalog")})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := EncodeContext(ctx, io.Discard, &d.PDF)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+
+	for _, lin := range []bool{false, true} {
+		ctx, cancel = context.WithCancel(context.Background())
+		r := &endless{ctxCancel: cancel}
+		var e Document
+		e.Add(Stream{Data: r})
+		e.Add(Stream{Data: r, Filters: []Filter{FlateFilter{}}})
+		e.Root, _, err = e.AddPages([]Page{{MediaBox: A4}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Linearize = lin
+		var out bytes.Buffer
+		_, err = EncodeContext(ctx, &out, &e.PDF)
+		if !errors.Is(err, context.Canceled) || r.n > 11 {
+			t.Fatal(err, r.n)
+		}
+	}
+	var f Document
+	f.Root = f.Add(Dict{"Type": Name("Catalog")})
+	f.ObjectStreams = true
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := EncodeContext(ctx, io.Discard, &f.PDF); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+}
+
+func TestEncodeResult(t *testing.T) {
+	for i, mode := range []func(*Document){
+		func(*Document) {},
+		func(d *Document) { d.XrefStream = true },
+		func(d *Document) { d.Linearize = true },
+	} {
+		var d Document
+		var err error
+		d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}, {MediaBox: A4}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mode(&d)
+		var out bytes.Buffer
+		r, err := d.Finish(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := out.Bytes()
+		if r.Size != int64(len(data)) {
+			t.Fatal(r.Size, len(data))
+		}
+		want := "xref"
+		if i == 1 {
+			want = itoa(len(d.Body)+1) + " 0 obj"
+		}
+		if !bytes.HasPrefix(data[r.StartXref:], []byte(want)) {
+			t.Fatal(i, string(data[r.StartXref:r.StartXref+20]))
+		}
+		if !bytes.HasSuffix(data, []byte("startxref\n"+itoa(int(r.StartXref))+"\n%%EOF\n")) {
+			t.Fatal(i)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil || !bytes.HasPrefix(out.Bytes(), []byte("%PDF-1.7\n")) {
+		t.Fatal(err)
+	}
+	d.Version = "1.4"
+	out.Reset()
+	if _, err := d.Finish(&out); err != nil || !bytes.HasPrefix(out.Bytes(), []byte("%PDF-1.4\n")) {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+	d.XrefStream = true
+	_, err = d.Finish(&out)
+	if err == nil || err.Error() != "pdf: XrefStream requires PDF 1.5 or later, not 1.4" {
+		t.Fatal(err)
+	}
+	d.XrefStream = false
+	d.Encryption = &Encryption{AES: true, KeyLength: 256}
+	d.Version = "1.7"
+	if _, err := d.Finish(&out); err == nil || !strings.Contains(err.Error(), "PDF 2.0") {
+		t.Fatal(err)
+	}
+	d.Version = ""
+	out.Reset()
+	if _, err := d.Finish(&out); err != nil || !bytes.HasPrefix(out.Bytes(), []byte("%PDF-2.0\n")) {
+		t.Fatal(err)
+	}
+	d.Version = "3.1"
+	if _, err := d.Finish(&out); err == nil {
+		t.Fatal("bad version")
+	}
+}
+
+func TestBinaryMarker(t *testing.T) {
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitN(out.Bytes(), []byte("\n"), 3)
+	if len(lines[1]) < 5 || lines[1][0] != '%' {
+		t.Fatalf("%q", lines[1])
+	}
+	for _, b := range lines[1][1:] {
+		if b < 128 {
+			t.Fatalf("%q", lines[1])
+		}
+	}
+	validate(t, out.Bytes())
+}
+
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(b []byte) (int, error) {
+	if len(b) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errors.New("full")
+	}
+	w.n -= len(b)
+	return len(b), nil
+}
+
+func TestWriteTo(t *testing.T) {
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Dests = Destinations{"x": Array{Integer(0)}}
+	var _ io.WriterTo = &d
+	var _ io.WriterTo = &d.PDF
+	var buf bytes.Buffer
+	n, err := d.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatal(n, buf.Len(), err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("/Dests")) {
+		t.Fatal("not finished")
+	}
+	var buf2 bytes.Buffer
+	n, err = d.PDF.WriteTo(&buf2)
+	if err != nil || n != int64(buf2.Len()) {
+		t.Fatal(n, err)
+	}
+	n, err = d.WriteTo(&failWriter{n: 100})
+	if err == nil || n != 100 {
+		t.Fatal(n, err)
+	}
+}
+
+func TestEncodeBytes(t *testing.T) {
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.ID = [2][]byte{[]byte("a"), []byte("a")}
+	var buf bytes.Buffer
+	if _, err := Encode(&buf, &d.PDF); err != nil {
+		t.Fatal(err)
+	}
+	b, err := EncodeBytes(&d.PDF)
+	if err != nil || !bytes.Equal(b, buf.Bytes()) {
+		t.Fatal(err)
+	}
+	if _, err := EncodeBytes(&PDF{}); err == nil {
+		t.Fatal("no error")
+	}
+
+	obj := Dict{"A": Array{Integer(1), Real(2.5), TextString("é")}, "B": Null{}}
+	buf.Reset()
+	EncodeObject(&buf, obj)
+	b, err = EncodeObjectBytes(obj)
+	if err != nil || !bytes.Equal(b, buf.Bytes()) {
+		t.Fatal(err)
+	}
+	if b, err = EncodeObjectBytes(nil); err != nil || string(b) != "null" {
+		t.Fatal(b, err)
+	}
+	if _, err := EncodeObjectBytes(Real(math.NaN())); err == nil {
+		t.Fatal("no error")
+	}
+}
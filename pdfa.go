@@ -0,0 +1,112 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// embeddedFontFiles are the font descriptor entries that hold embedded
+// font programs.
+var embeddedFontFiles = []Name{"FontFile", "FontFile2", "FontFile3"}
+
+// checkPDFA returns an error describing the first way found in which d
+// doesn't meet the requirements of PDF/A-1b; see Document.PDFA.
+func (d *Document) checkPDFA() error {
+	if d.Encryption != nil {
+		return errors.New("pdf: PDF/A documents can't be encrypted")
+	}
+	if d.XrefStream || d.ObjectStreams {
+		return errors.New("pdf: PDF/A-1 doesn't allow cross-reference or object streams")
+	}
+	if len(d.files) > 0 {
+		return errors.New("pdf: PDF/A-1 doesn't allow embedded files")
+	}
+
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+	if (d.metadata == "") || (catalog["Metadata"] != d.metadata) {
+		return errors.New("pdf: PDF/A requires metadata added by SetMetadata while PDFA is set")
+	}
+
+	objects := make(map[string]Object, len(d.Body))
+	for _, obj := range d.Body {
+		objects[obj.Name] = obj.Object
+	}
+	resolve := func(obj Object) Object {
+		if ref, ok := obj.(Reference); ok {
+			return objects[string(ref)]
+		}
+		return obj
+	}
+
+	if !hasPDFAIntent(catalog, resolve) {
+		return errors.New("pdf: PDF/A requires a GTS_PDFA1 output intent with an ICC profile")
+	}
+
+	for _, obj := range d.Body {
+		var err error
+		eachDict(obj.Object, func(dict Dict) {
+			if (err != nil) || (dict["Type"] != Name("Font")) {
+				return
+			}
+			switch dict["Subtype"] {
+			case Name("Type0"), Name("Type3"):
+				// Type 0 fonts are embedded by way of their descendants,
+				// which are checked separately, and Type 3 fonts are
+				// defined by content streams in the file.
+				return
+			}
+
+			descriptor, _ := resolve(dict["FontDescriptor"]).(Dict)
+			for _, key := range embeddedFontFiles {
+				if _, ok := descriptor[key]; ok {
+					return
+				}
+			}
+			err = fmt.Errorf("pdf: font %v in object %q is not embedded, as PDF/A requires", dict["BaseFont"], obj.Name)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasPDFAIntent reports whether catalog has a PDF/A output intent
+// with a destination profile, using resolve to follow references.
+func hasPDFAIntent(catalog Dict, resolve func(Object) Object) bool {
+	intents, _ := resolve(catalog["OutputIntents"]).(Array)
+	for _, intent := range intents {
+		intent, _ := resolve(intent).(Dict)
+		if (intent["S"] == OutputIntentPDFA) && (intent["DestOutputProfile"] != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// eachDict calls f with every dictionary in obj, including obj itself,
+// the dictionaries of streams, and the resources of forms and patterns,
+// but without following references.
+func eachDict(obj Object, f func(Dict)) {
+	switch obj := obj.(type) {
+	case Dict:
+		f(obj)
+		for _, v := range obj {
+			eachDict(v, f)
+		}
+	case Array:
+		for _, v := range obj {
+			eachDict(v, f)
+		}
+	case Stream:
+		eachDict(obj.Dict, f)
+	case FormXObject:
+		eachDict(obj.Resources, f)
+	case TilingPattern:
+		eachDict(obj.Resources, f)
+	}
+}
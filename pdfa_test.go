@@ -0,0 +1,74 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func pdfaDoc(t *testing.T, intent bool, std bool) *Document {
+	d := &Document{PDFA: true}
+	font, err := d.EmbedTrueType(goregular.TTF, []rune("Archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Content
+	c.BeginText()
+	c.SetFont("F1", 12)
+	c.ShowText(font.Encode("Archive"))
+	c.EndText()
+	res := Dict{"Font": Dict{"F1": font.Ref}}
+	if std {
+		res["Font"].(Dict)["F2"] = Helvetica.Dict()
+	}
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: res}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Title: "Archive"}
+	d.SetInfo(info)
+	if err := d.SetMetadata(info); err != nil {
+		t.Fatal(err)
+	}
+	if intent {
+		if err := d.AddOutputIntent(OutputIntent(OutputIntentPDFA, testProfile(), "sRGB")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return d
+}
+
+func TestPDFA(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := pdfaDoc(t, true, false).Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if !bytes.Contains(data, []byte("<pdfaid:part>1</pdfaid:part>")) || !bytes.Contains(data, []byte("/ID [")) {
+		t.Fatal("missing id")
+	}
+	validate(t, data)
+
+	_, err := pdfaDoc(t, false, false).Finish(&out)
+	if err == nil || !strings.Contains(err.Error(), "output intent") {
+		t.Fatal(err)
+	}
+	_, err = pdfaDoc(t, true, true).Finish(&out)
+	if err == nil || !strings.Contains(err.Error(), "not embedded") {
+		t.Fatal(err)
+	}
+	d := pdfaDoc(t, true, false)
+	d.Encryption = &Encryption{}
+	if _, err := d.Finish(&out); err == nil {
+		t.Fatal("encrypted")
+	}
+	d = pdfaDoc(t, true, false)
+	d.PDFA = false
+	d.SetMetadata(Info{})
+	d.PDFA = true
+	if _, err := d.Finish(&out); err == nil {
+		t.Fatal("metadata")
+	}
+}
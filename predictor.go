@@ -0,0 +1,269 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+)
+
+// PNG predictor values, as used in the Predictor decode parameter.
+const (
+	PredictorNone    = 10
+	PredictorSub     = 11
+	PredictorUp      = 12
+	PredictorAverage = 13
+	PredictorPaeth   = 14
+	PredictorOptimum = 15
+)
+
+// predictorParams returns the decode parameters describing a
+// predictor, leaving out any that have their default values.
+func predictorParams(predictor, colors, bpc, columns int) Dict {
+	params := Dict{"Predictor": Integer(predictor)}
+	if colors > 1 {
+		params["Colors"] = Integer(colors)
+	}
+	if (bpc > 0) && (bpc != 8) {
+		params["BitsPerComponent"] = Integer(bpc)
+	}
+	if columns > 1 {
+		params["Columns"] = Integer(columns)
+	}
+	return params
+}
+
+// predictorLayout returns the number of bytes per pixel, rounded up,
+// and per row for data with the given layout, filling in defaults.
+func predictorLayout(predictor, colors, bpc, columns int) (bpp, row int, err error) {
+	if (predictor < PredictorNone) || (predictor > PredictorOptimum) {
+		return 0, 0, fmt.Errorf("pdf: unsupported predictor: %v", predictor)
+	}
+
+	if colors <= 0 {
+		colors = 1
+	}
+	if bpc <= 0 {
+		bpc = 8
+	}
+	if columns <= 0 {
+		columns = 1
+	}
+
+	bpp = (colors*bpc + 7) / 8
+	row = (colors*bpc*columns + 7) / 8
+	return bpp, row, nil
+}
+
+// predictorWriter applies a PNG predictor to each row of data written
+// to it before passing it on to w.
+type predictorWriter struct {
+	w         io.WriteCloser
+	predictor int
+	bpp       int
+
+	prev, cur []byte
+	n         int
+	out       []byte
+}
+
+func newPredictorWriter(w io.WriteCloser, predictor, colors, bpc, columns int) (*predictorWriter, error) {
+	bpp, row, err := predictorLayout(predictor, colors, bpc, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &predictorWriter{
+		w:         w,
+		predictor: predictor,
+		bpp:       bpp,
+
+		prev: make([]byte, row),
+		cur:  make([]byte, row),
+		out:  make([]byte, row+1),
+	}, nil
+}
+
+func (w *predictorWriter) Write(data []byte) (int, error) {
+	var written int
+	for len(data) > 0 {
+		n := copy(w.cur[w.n:], data)
+		w.n += n
+		data = data[n:]
+		written += n
+
+		if w.n == len(w.cur) {
+			err := w.flush()
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// flush writes out the current row, which may be short if it's the
+// last one.
+func (w *predictorWriter) flush() error {
+	cur, prev := w.cur[:w.n], w.prev[:w.n]
+
+	predictor := w.predictor
+	if predictor == PredictorOptimum {
+		predictor = bestPredictor(cur, prev, w.bpp)
+	}
+
+	out := w.out[:w.n+1]
+	out[0] = byte(predictor - PredictorNone)
+	for i, c := range cur {
+		var left, upLeft byte
+		if i >= w.bpp {
+			left, upLeft = cur[i-w.bpp], prev[i-w.bpp]
+		}
+		out[i+1] = c - predict(predictor, left, prev[i], upLeft)
+	}
+
+	w.prev, w.cur = w.cur, w.prev
+	w.n = 0
+
+	_, err := w.w.Write(out)
+	return err
+}
+
+func (w *predictorWriter) Close() error {
+	if w.n > 0 {
+		err := w.flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.w.Close()
+}
+
+// bestPredictor picks a predictor for a row using the heuristic
+// suggested by the PNG specification, which is to minimize the sum of
+// the absolute values of the predicted bytes, taken as signed.
+func bestPredictor(cur, prev []byte, bpp int) int {
+	best, bestSum := PredictorNone, -1
+	for predictor := PredictorNone; predictor < PredictorOptimum; predictor++ {
+		var sum int
+		for i, c := range cur {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = cur[i-bpp], prev[i-bpp]
+			}
+			d := int(int8(c - predict(predictor, left, prev[i], upLeft)))
+			sum += max(d, -d)
+		}
+
+		if (bestSum < 0) || (sum < bestSum) {
+			best, bestSum = predictor, sum
+		}
+	}
+	return best
+}
+
+// predict returns the value that predictor predicts for a byte given
+// its neighbors.
+func predict(predictor int, left, up, upLeft byte) byte {
+	switch predictor {
+	case PredictorSub:
+		return left
+	case PredictorUp:
+		return up
+	case PredictorAverage:
+		return byte((int(left) + int(up)) / 2)
+	case PredictorPaeth:
+		p := int(left) + int(up) - int(upLeft)
+		pa, pb, pc := abs(p-int(left)), abs(p-int(up)), abs(p-int(upLeft))
+		switch {
+		case (pa <= pb) && (pa <= pc):
+			return left
+		case pb <= pc:
+			return up
+		default:
+			return upLeft
+		}
+	default:
+		return 0
+	}
+}
+
+func abs(v int) int {
+	return max(v, -v)
+}
+
+// predictorReader undoes the PNG predictors for the rows read from r.
+type predictorReader struct {
+	r   io.Reader
+	bpp int
+
+	prev, cur []byte
+	buf       []byte
+	eof       bool
+}
+
+func newPredictorReader(r io.Reader, predictor, colors, bpc, columns int) (*predictorReader, error) {
+	bpp, row, err := predictorLayout(predictor, colors, bpc, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &predictorReader{
+		r:   r,
+		bpp: bpp,
+
+		prev: make([]byte, row),
+		cur:  make([]byte, row+1),
+	}, nil
+}
+
+func (r *predictorReader) Read(data []byte) (int, error) {
+	for (len(r.buf) == 0) && !r.eof {
+		err := r.next()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(r.buf) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(data, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// next reads and decodes the next row into r.buf.
+func (r *predictorReader) next() error {
+	n, err := io.ReadFull(r.r, r.cur)
+	switch err {
+	case nil:
+	case io.EOF:
+		r.eof = true
+		return nil
+	case io.ErrUnexpectedEOF:
+		// The last row may be short.
+		r.eof = true
+	default:
+		return err
+	}
+
+	predictor := int(r.cur[0]) + PredictorNone
+	if predictor >= PredictorOptimum {
+		return fmt.Errorf("pdf: invalid PNG predictor in row: %v", r.cur[0])
+	}
+
+	row := r.cur[1:n]
+	for i := range row {
+		var left, upLeft byte
+		if i >= r.bpp {
+			left, upLeft = row[i-r.bpp], r.prev[i-r.bpp]
+		}
+		row[i] += predict(predictor, left, r.prev[i], upLeft)
+	}
+
+	copy(r.prev, row)
+	r.buf = row
+	return nil
+}
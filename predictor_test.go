@@ -0,0 +1,27 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPredictor(t *testing.T) {
+	const w, h = 64, 48
+	img := make([]byte, 0, w*h*3)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img = append(img, byte(x*4), byte(y*5), byte(x+y))
+		}
+	}
+	for _, p := range []int{0, PredictorNone, PredictorSub, PredictorUp, PredictorAverage, PredictorPaeth, PredictorOptimum} {
+		f := FlateFilter{Predictor: p, Colors: 3, Columns: w}
+		roundTrip(t, f, img)
+		roundTrip(t, f, img[:len(img)-5])
+	}
+	var buf bytes.Buffer
+	EncodeObject(&buf, Stream{Filters: []Filter{FlateFilter{Predictor: 12, Colors: 3, Columns: 64}}, Data: bytes.NewReader(img)})
+	if !strings.Contains(buf.String(), "/DecodeParms <</Colors 3 /Columns 64 /Predictor 12 >>") {
+		t.Fatal(buf.String()[:100])
+	}
+}
@@ -0,0 +1,40 @@
+package pdf
+
+// Rectangle is a PDF rectangle, given by the coordinates of two
+// opposite corners. It is always written with the lower left corner
+// first, no matter which corners are given.
+type Rectangle struct {
+	LLX, LLY, URX, URY float64
+}
+
+// Standard paper sizes, in points.
+var (
+	Letter = Rectangle{0, 0, 612, 792}
+	Legal  = Rectangle{0, 0, 612, 1008}
+	A3     = Rectangle{0, 0, 841.89, 1190.551}
+	A4     = Rectangle{0, 0, 595.276, 841.89}
+	A5     = Rectangle{0, 0, 419.528, 595.276}
+)
+
+// Normalize returns a copy of r with its corners arranged so that the
+// lower left corner is given by LLX and LLY and the upper right by URX
+// and URY.
+func (r Rectangle) Normalize() Rectangle {
+	return Rectangle{
+		LLX: min(r.LLX, r.URX),
+		LLY: min(r.LLY, r.URY),
+		URX: max(r.LLX, r.URX),
+		URY: max(r.LLY, r.URY),
+	}
+}
+
+// Contains reports whether o lies entirely within r.
+func (r Rectangle) Contains(o Rectangle) bool {
+	r, o = r.Normalize(), o.Normalize()
+	return (o.LLX >= r.LLX) && (o.LLY >= r.LLY) && (o.URX <= r.URX) && (o.URY <= r.URY)
+}
+
+func (r Rectangle) encode(s *encodeState) error {
+	r = r.Normalize()
+	return Array{Real(r.LLX), Real(r.LLY), Real(r.URX), Real(r.URY)}.encode(s)
+}
@@ -0,0 +1,19 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRectangle(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeObject(&buf, Rectangle{100, 200, 10, 20})
+	if buf.String() != "[10 20 100 200]" {
+		t.Fatal(buf.String())
+	}
+	buf.Reset()
+	EncodeObject(&buf, A4)
+	if buf.String() != "[0 0 595.276 841.89]" {
+		t.Fatal(buf.String())
+	}
+}
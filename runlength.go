@@ -0,0 +1,149 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// RunLengthFilter is the RunLengthDecode filter, which compresses runs
+// of identical bytes.
+type RunLengthFilter struct{}
+
+func (RunLengthFilter) Name() Name {
+	return "RunLengthDecode"
+}
+
+func (RunLengthFilter) Encode(w io.Writer) (io.WriteCloser, error) {
+	return &runLengthWriter{w: w}, nil
+}
+
+func (RunLengthFilter) Decode(r io.Reader) (io.Reader, error) {
+	return &runLengthReader{r: bufio.NewReader(r)}, nil
+}
+
+const (
+	// runLengthEOD is the length byte that marks the end of the data.
+	runLengthEOD = 128
+
+	// runLengthMax is the longest run or literal that a single length
+	// byte can describe.
+	runLengthMax = 128
+)
+
+// runLengthWriter collects all of the data written to it and encodes
+// it when closed.
+type runLengthWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (w *runLengthWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+func (w *runLengthWriter) Close() error {
+	data := w.buf.Bytes()
+	out := make([]byte, 0, len(data)+len(data)/runLengthMax+2)
+
+	lit := 0
+	flush := func(end int) {
+		for lit < end {
+			n := min(end-lit, runLengthMax)
+			out = append(out, byte(n-1))
+			out = append(out, data[lit:lit+n]...)
+			lit += n
+		}
+	}
+
+	for i := 0; i < len(data); {
+		run := 1
+		for (i+run < len(data)) && (run < runLengthMax) && (data[i+run] == data[i]) {
+			run++
+		}
+
+		// Runs of two are only worth encoding as runs if they don't
+		// interrupt a literal.
+		if (run >= 3) || ((run == 2) && (lit == i)) {
+			flush(i)
+			out = append(out, byte(257-run), data[i])
+			i += run
+			lit = i
+			continue
+		}
+
+		i += run
+	}
+	flush(len(data))
+	out = append(out, runLengthEOD)
+
+	_, err := w.w.Write(out)
+	return err
+}
+
+type runLengthReader struct {
+	r   *bufio.Reader
+	buf []byte
+	eod bool
+}
+
+func (r *runLengthReader) Read(data []byte) (int, error) {
+	for (len(r.buf) == 0) && !r.eod {
+		err := r.next()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(r.buf) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(data, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// next decodes the next run or literal into r.buf.
+func (r *runLengthReader) next() error {
+	length, err := r.r.ReadByte()
+	if err == io.EOF {
+		r.eod = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case length == runLengthEOD:
+		r.eod = true
+		return nil
+
+	case length < runLengthEOD:
+		r.buf = make([]byte, int(length)+1)
+		_, err := io.ReadFull(r.r, r.buf)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		return nil
+
+	default:
+		c, err := r.r.ReadByte()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		r.buf = bytes.Repeat([]byte{c}, 257-int(length))
+		return nil
+	}
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF, for use when
+// the data ends partway through something.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
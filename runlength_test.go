@@ -0,0 +1,28 @@
+package pdf
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestRunLength(t *testing.T) {
+	var in []byte
+	in = append(in, bytes.Repeat([]byte{7}, 300)...)
+	lit := make([]byte, 200)
+	rand.Read(lit)
+	in = append(in, lit...)
+	in = append(in, 1, 1, 2, 3, 3, 3, 4)
+	body := roundTrip(t, RunLengthFilter{}, in)
+	if body[len(body)-1] != 128 {
+		t.Fatal("EOD")
+	}
+	if len(body) > 230 {
+		t.Fatal(len(body))
+	}
+	if !bytes.HasPrefix(body, []byte{129, 7, 129, 7}) {
+		t.Fatal(body[:4])
+	}
+	roundTrip(t, RunLengthFilter{}, nil)
+	roundTrip(t, RunLengthFilter{}, []byte{5, 5})
+}
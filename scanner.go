@@ -0,0 +1,420 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TokenKind is the kind of a token produced by a Scanner.
+type TokenKind int
+
+// Token kinds. Keywords include the boolean and null objects as well
+// as operators, such as R and obj.
+const (
+	TokenInteger TokenKind = iota
+	TokenReal
+	TokenName
+	TokenString
+	TokenHexString
+	TokenArrayStart
+	TokenArrayEnd
+	TokenDictStart
+	TokenDictEnd
+	TokenProcStart
+	TokenProcEnd
+	TokenKeyword
+	TokenComment
+)
+
+var tokenKindNames = [...]string{
+	TokenInteger:    "integer",
+	TokenReal:       "real",
+	TokenName:       "name",
+	TokenString:     "string",
+	TokenHexString:  "hex string",
+	TokenArrayStart: "[",
+	TokenArrayEnd:   "]",
+	TokenDictStart:  "<<",
+	TokenDictEnd:    ">>",
+	TokenProcStart:  "{",
+	TokenProcEnd:    "}",
+	TokenKeyword:    "keyword",
+	TokenComment:    "comment",
+}
+
+func (k TokenKind) String() string {
+	if (k < 0) || (int(k) >= len(tokenKindNames)) {
+		return fmt.Sprintf("TokenKind(%d)", int(k))
+	}
+	return tokenKindNames[k]
+}
+
+// Token is a single token of PDF syntax.
+type Token struct {
+	Kind TokenKind
+
+	// Offset is the offset of the first byte of the token from the
+	// start of the input.
+	Offset int64
+
+	// Value is the decoded value of the token. For names, strings, and
+	// comments, this is the content of the token with delimiters,
+	// escapes, and the leading slash or percent sign removed. For
+	// numbers and keywords, it is the text of the token. It is empty
+	// for delimiters.
+	Value string
+}
+
+// SyntaxError is an error in the syntax of a PDF.
+type SyntaxError struct {
+	// Offset is the offset in the input at which the error was found.
+	Offset int64
+	Msg    string
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("pdf: syntax error at offset %v: %v", err.Offset, err.Msg)
+}
+
+// Scanner splits PDF syntax into tokens.
+type Scanner struct {
+	r   *bufio.Reader
+	off int64
+}
+
+// NewScanner returns a Scanner that reads from r.
+func NewScanner(r io.Reader) *Scanner {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Scanner{r: br}
+}
+
+// Offset returns the offset of the next byte that s will read.
+func (s *Scanner) Offset() int64 {
+	return s.off
+}
+
+func (s *Scanner) readByte() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	s.off++
+	return c, nil
+}
+
+func (s *Scanner) unreadByte() {
+	s.r.UnreadByte()
+	s.off--
+}
+
+func (s *Scanner) errorf(off int64, format string, args ...any) error {
+	return &SyntaxError{Offset: off, Msg: fmt.Sprintf(format, args...)}
+}
+
+// unexpectedEOF converts io.EOF into a syntax error at the current
+// offset, as a token was cut off.
+func (s *Scanner) unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return s.errorf(s.off, "unexpected EOF")
+	}
+	return err
+}
+
+// isDelimiter returns true if c is one of the PDF delimiter
+// characters.
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// Next returns the next token. It returns io.EOF when there are no
+// more tokens.
+func (s *Scanner) Next() (Token, error) {
+	var c byte
+	for {
+		var err error
+		c, err = s.readByte()
+		if err != nil {
+			return Token{}, err
+		}
+		if !isWhitespace(c) {
+			break
+		}
+	}
+
+	tok := Token{Offset: s.off - 1}
+	var err error
+	switch c {
+	case '[':
+		tok.Kind = TokenArrayStart
+	case ']':
+		tok.Kind = TokenArrayEnd
+	case '{':
+		tok.Kind = TokenProcStart
+	case '}':
+		tok.Kind = TokenProcEnd
+	case '/':
+		tok.Kind = TokenName
+		tok.Value, err = s.scanName()
+	case '(':
+		tok.Kind = TokenString
+		tok.Value, err = s.scanLiteralString()
+	case '%':
+		tok.Kind = TokenComment
+		tok.Value, err = s.scanComment()
+	case '<':
+		c, err = s.readByte()
+		if err != nil {
+			return tok, s.unexpectedEOF(err)
+		}
+		if c == '<' {
+			tok.Kind = TokenDictStart
+			break
+		}
+		s.unreadByte()
+		tok.Kind = TokenHexString
+		tok.Value, err = s.scanHexString()
+	case '>':
+		c, err = s.readByte()
+		if (err != nil) || (c != '>') {
+			return tok, s.errorf(tok.Offset, "unexpected '>'")
+		}
+		tok.Kind = TokenDictEnd
+	case ')':
+		return tok, s.errorf(tok.Offset, "unexpected ')'")
+	default:
+		s.unreadByte()
+		tok.Value, err = s.scanRegular()
+		tok.Kind = TokenKeyword
+		if isInteger(tok.Value) {
+			tok.Kind = TokenInteger
+		} else if isReal(tok.Value) {
+			tok.Kind = TokenReal
+		}
+	}
+
+	return tok, err
+}
+
+// scanRegular reads a run of regular characters.
+func (s *Scanner) scanRegular() (string, error) {
+	var buf []byte
+	for {
+		c, err := s.readByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if isWhitespace(c) || isDelimiter(c) {
+			s.unreadByte()
+			break
+		}
+		buf = append(buf, c)
+	}
+	return string(buf), nil
+}
+
+// isInteger returns true if str is a PDF integer.
+func isInteger(str string) bool {
+	if (str != "") && ((str[0] == '+') || (str[0] == '-')) {
+		str = str[1:]
+	}
+	if str == "" {
+		return false
+	}
+	for i := 0; i < len(str); i++ {
+		if (str[i] < '0') || (str[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isReal returns true if str is a PDF real number, which has a decimal
+// point but no exponent.
+func isReal(str string) bool {
+	if (str != "") && ((str[0] == '+') || (str[0] == '-')) {
+		str = str[1:]
+	}
+	var digits, points int
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; {
+		case c == '.':
+			points++
+		case (c >= '0') && (c <= '9'):
+			digits++
+		default:
+			return false
+		}
+	}
+	return (points == 1) && (digits > 0)
+}
+
+func (s *Scanner) scanName() (string, error) {
+	raw, err := s.scanRegular()
+	if err != nil {
+		return "", err
+	}
+
+	if strings.IndexByte(raw, '#') < 0 {
+		return raw, nil
+	}
+	buf := make([]byte, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		if (raw[i] == '#') && (i+2 < len(raw)) && isHexDigit(raw[i+1]) && isHexDigit(raw[i+2]) {
+			buf = append(buf, unhex(raw[i+1])<<4|unhex(raw[i+2]))
+			i += 2
+			continue
+		}
+		buf = append(buf, raw[i])
+	}
+	return string(buf), nil
+}
+
+func isHexDigit(c byte) bool {
+	return ((c >= '0') && (c <= '9')) || ((c >= 'a') && (c <= 'f')) || ((c >= 'A') && (c <= 'F'))
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	default:
+		return c - '0'
+	}
+}
+
+func (s *Scanner) scanComment() (string, error) {
+	var buf []byte
+	for {
+		c, err := s.readByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if (c == '\r') || (c == '\n') {
+			s.unreadByte()
+			break
+		}
+		buf = append(buf, c)
+	}
+	return string(buf), nil
+}
+
+func (s *Scanner) scanHexString() (string, error) {
+	var buf []byte
+	var digits int
+	for {
+		c, err := s.readByte()
+		if err != nil {
+			return "", s.unexpectedEOF(err)
+		}
+		switch {
+		case c == '>':
+			return string(buf), nil
+		case isWhitespace(c):
+			continue
+		case !isHexDigit(c):
+			return "", s.errorf(s.off-1, "invalid character in hex string: %q", c)
+		}
+
+		// A missing final digit is treated as zero, which conveniently
+		// is also what appending a digit to a zero byte does.
+		if digits%2 == 0 {
+			buf = append(buf, unhex(c)<<4)
+		} else {
+			buf[len(buf)-1] |= unhex(c)
+		}
+		digits++
+	}
+}
+
+func (s *Scanner) scanLiteralString() (string, error) {
+	var buf bytes.Buffer
+	depth := 1
+	for {
+		c, err := s.readByte()
+		if err != nil {
+			return "", s.unexpectedEOF(err)
+		}
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf.String(), nil
+			}
+		case '\r':
+			// Unescaped line endings of any kind are read as a single
+			// line feed.
+			c = '\n'
+			next, err := s.readByte()
+			if err != nil {
+				return "", s.unexpectedEOF(err)
+			}
+			if next != '\n' {
+				s.unreadByte()
+			}
+		case '\\':
+			c, err = s.readByte()
+			if err != nil {
+				return "", s.unexpectedEOF(err)
+			}
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				c = c - '0'
+				for range 2 {
+					next, err := s.readByte()
+					if err != nil {
+						return "", s.unexpectedEOF(err)
+					}
+					if (next < '0') || (next > '7') {
+						s.unreadByte()
+						break
+					}
+					c = c<<3 | (next - '0')
+				}
+			case '\r':
+				// An escaped line ending continues the string on the next
+				// line without including it.
+				next, err := s.readByte()
+				if err != nil {
+					return "", s.unexpectedEOF(err)
+				}
+				if next != '\n' {
+					s.unreadByte()
+				}
+				continue
+			case '\n':
+				continue
+			}
+			// Anything else, including delimiters and backslashes, is
+			// included as is with the backslash ignored.
+		}
+
+		buf.WriteByte(c)
+	}
+}
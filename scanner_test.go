@@ -0,0 +1,62 @@
+package pdf
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func scanAll(t *testing.T, in string) []Token {
+	t.Helper()
+	s := NewScanner(strings.NewReader(in))
+	var toks []Token
+	for {
+		tok, err := s.Next()
+		if err == io.EOF {
+			return toks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		toks = append(toks, tok)
+	}
+}
+
+func TestScanner(t *testing.T) {
+	in := "1 0 obj\n<</Type /A#42c /N -3.5 /S (a (nested) \\(x\\) \\101\\7\\0612 \\\nb\r\nc) /H <48 65 6C6C 6F7> /R 2 0 R>>\n[true false null .5 +7 -.25 4.] % hi\n{1 add}endobj"
+	toks := scanAll(t, in)
+	want := []struct {
+		k TokenKind
+		v string
+		o int64
+	}{
+		{TokenInteger, "1", 0}, {TokenInteger, "0", 2}, {TokenKeyword, "obj", 4},
+		{TokenDictStart, "", 8}, {TokenName, "Type", 10}, {TokenName, "ABc", 16},
+		{TokenName, "N", 23}, {TokenReal, "-3.5", 26},
+		{TokenName, "S", 31}, {TokenString, "a (nested) (x) A\a12 b\nc", 34},
+		{TokenName, "H", 0}, {TokenHexString, "Hello\x70", 0},
+		{TokenName, "R", 0}, {TokenInteger, "2", 0}, {TokenInteger, "0", 0}, {TokenKeyword, "R", 0},
+		{TokenDictEnd, "", 0}, {TokenArrayStart, "", 0},
+		{TokenKeyword, "true", 0}, {TokenKeyword, "false", 0}, {TokenKeyword, "null", 0},
+		{TokenReal, ".5", 0}, {TokenInteger, "+7", 0}, {TokenReal, "-.25", 0}, {TokenReal, "4.", 0},
+		{TokenArrayEnd, "", 0}, {TokenComment, " hi", 0},
+		{TokenProcStart, "", 0}, {TokenInteger, "1", 0}, {TokenKeyword, "add", 0}, {TokenProcEnd, "", 0},
+		{TokenKeyword, "endobj", int64(len(in) - 6)},
+	}
+	if len(toks) != len(want) {
+		t.Fatalf("%v tokens: %+v", len(toks), toks)
+	}
+	for i, w := range want {
+		tok := toks[i]
+		if tok.Kind != w.k || tok.Value != w.v || (w.o != 0 && tok.Offset != w.o) {
+			t.Errorf("%v: got %v %q @%v, want %v %q @%v", i, tok.Kind, tok.Value, tok.Offset, w.k, w.v, w.o)
+		}
+	}
+	for _, bad := range []string{"(abc", "<12x>", ")", "> x", "<ab"} {
+		s := NewScanner(strings.NewReader(bad))
+		_, err := s.Next()
+		if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("%q: %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package pdf
+
+// AxialShading returns a type 2 shading dictionary that blends colors
+// in the color space space along the line from (x0, y0) to (x1, y1),
+// with f, such as an ExponentialFunction, giving the color at each
+// point in terms of a parameter from 0 at the start of the line to 1
+// at the end. Set the result's Extend entry to continue the shading
+// past either end of the line.
+//
+// Shadings are named in a resource dictionary's Shading entry and
+// painted with Content.Shade.
+func AxialShading(space Object, x0, y0, x1, y1 float64, f Object) Dict {
+	return Dict{
+		"ShadingType": Integer(2),
+		"ColorSpace":  space,
+		"Coords":      realArray(x0, y0, x1, y1),
+		"Domain":      realArray(unitDomain[:]...),
+		"Function":    f,
+	}
+}
+
+// RadialShading returns a type 3 shading dictionary that blends colors
+// between the circle centered at (x0, y0) with radius r0 and the one
+// centered at (x1, y1) with radius r1. It is otherwise the same as
+// AxialShading.
+func RadialShading(space Object, x0, y0, r0, x1, y1, r1 float64, f Object) Dict {
+	return Dict{
+		"ShadingType": Integer(3),
+		"ColorSpace":  space,
+		"Coords":      realArray(x0, y0, r0, x1, y1, r1),
+		"Domain":      realArray(unitDomain[:]...),
+		"Function":    f,
+	}
+}
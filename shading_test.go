@@ -0,0 +1,55 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestShading(t *testing.T) {
+	var d Document
+	fn := d.Add(ExponentialFunction{C0: []float64{1, 0, 0}, C1: []float64{0, 0, 1}, N: 1})
+	axial := AxialShading(Name("DeviceRGB"), 0, 0, 200, 0, fn)
+	axial["Extend"] = Array{Boolean(true), Boolean(true)}
+	radial := RadialShading(Name("DeviceRGB"), 100, 100, 0, 100, 100, 50, StitchingFunction{
+		Functions: []Object{
+			ExponentialFunction{C0: []float64{1, 1, 1}, C1: []float64{1, 0, 0}, N: 1},
+			fn,
+		},
+		Bounds: []float64{0.5},
+	})
+	var c Content
+	c.Save()
+	c.Rectangle(0, 0, 200, 200)
+	c.Shade("Sh0")
+	c.Restore()
+	c.Shade("Sh1")
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"Shading": Dict{"Sh0": axial, "Sh1": radial}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	for _, want := range []string{
+		"<</C0 [1 0 0] /C1 [0 0 1] /Domain [0 1] /FunctionType 2 /N 1 >>",
+		"/ColorSpace /DeviceRGB /Coords [0 0 200 0] /Domain [0 1] /Extend [true true] /Function 1 0 R /ShadingType 2",
+		"/Bounds [0.5] /Domain [0 1] /Encode [0 1 0 1] /FunctionType 3 /Functions [",
+		"/Coords [100 100 0 100 100 50]",
+		"/Sh0 sh",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Fatal(want)
+		}
+	}
+	validate(t, data)
+	var buf bytes.Buffer
+	if err := EncodeObject(&buf, StitchingFunction{Functions: []Object{fn, fn}}); err == nil {
+		t.Fatal("bounds")
+	}
+	if err := EncodeObject(&buf, ExponentialFunction{C0: []float64{1}}); err == nil {
+		t.Fatal("c0")
+	}
+}
@@ -0,0 +1,195 @@
+package pdf
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultSignatureSize is the number of bytes reserved for a signature
+// if Signature.Size isn't set.
+const DefaultSignatureSize = 8192
+
+// Signature is a signature dictionary whose ByteRange and Contents
+// entries are placeholders, to be filled in once the file has been
+// written by calling Sign on the SignaturePlaceholder that the encoder
+// returns for it.
+//
+// Because the placeholders are overwritten in place, a Signature must
+// be written directly to the file. It can't be stored in an object
+// stream or written as part of a linearized file.
+type Signature struct {
+	// Size is the maximum size of the signature, in bytes. If it is
+	// not positive, DefaultSignatureSize is used.
+	Size int
+
+	// Filter and SubFilter identify the signature handler and the
+	// encoding of the signature. They default to Adobe.PPKLite and
+	// adbe.pkcs7.detached.
+	Filter, SubFilter Name
+
+	// Name, Reason, Location, and Time optionally describe who signed
+	// the document, why, where, and when.
+	Name     string
+	Reason   string
+	Location string
+	Time     time.Time
+}
+
+func (sig Signature) encode(s *encodeState) error {
+	if s.file == nil {
+		return errors.New("pdf: Signature must be written directly to a file")
+	}
+
+	size := sig.Size
+	if size <= 0 {
+		size = DefaultSignatureSize
+	}
+	filter := sig.Filter
+	if filter == "" {
+		filter = "Adobe.PPKLite"
+	}
+	subFilter := sig.SubFilter
+	if subFilter == "" {
+		subFilter = "adbe.pkcs7.detached"
+	}
+
+	p := SignaturePlaceholder{Length: int64(2*size + 2)}
+	dict := Dict{
+		"Type":      Name("Sig"),
+		"Filter":    filter,
+		"SubFilter": subFilter,
+		"ByteRange": byteRangePlaceholder{p: &p},
+		"Contents":  contentsPlaceholder{p: &p},
+	}
+	if sig.Name != "" {
+		dict["Name"] = TextString(sig.Name)
+	}
+	if sig.Reason != "" {
+		dict["Reason"] = TextString(sig.Reason)
+	}
+	if sig.Location != "" {
+		dict["Location"] = TextString(sig.Location)
+	}
+	if !sig.Time.IsZero() {
+		dict["M"] = Date(sig.Time)
+	}
+
+	err := dict.encode(s)
+	if err != nil {
+		return err
+	}
+	s.file.signatures = append(s.file.signatures, p)
+	return nil
+}
+
+// byteRangeLength is the space reserved for a ByteRange array, which
+// is enough for offsets of up to ten digits.
+const byteRangeLength = len("[0 0000000000 0000000000 0000000000]")
+
+type byteRangePlaceholder struct {
+	p *SignaturePlaceholder
+}
+
+func (b byteRangePlaceholder) encode(s *encodeState) error {
+	b.p.ByteRange = s.offset()
+	_, err := s.WriteString("[0 0000000000 0000000000 0000000000]")
+	return err
+}
+
+type contentsPlaceholder struct {
+	p *SignaturePlaceholder
+}
+
+func (c contentsPlaceholder) encode(s *encodeState) error {
+	// The contents of signatures are never encrypted, so this doesn't
+	// go through HexString.
+	c.p.Contents = s.offset()
+	err := s.WriteByte('<')
+	if err != nil {
+		return err
+	}
+	for range c.p.Length - 2 {
+		err := s.WriteByte('0')
+		if err != nil {
+			return err
+		}
+	}
+	return s.WriteByte('>')
+}
+
+// SignaturePlaceholder is the space reserved for a Signature in an
+// encoded file.
+type SignaturePlaceholder struct {
+	// ByteRange is the offset of the signature's ByteRange array.
+	ByteRange int64
+
+	// Contents is the offset of the hex string reserved for the
+	// signature itself, and Length is its length, including the angle
+	// brackets.
+	Contents, Length int64
+}
+
+// Range returns the byte range covered by the signature in a file of
+// the given size, as pairs of offsets and lengths. It covers the whole
+// file except for the Contents placeholder.
+func (p SignaturePlaceholder) Range(size int64) [4]int64 {
+	end := p.Contents + p.Length
+	return [4]int64{0, p.Contents, end, size - end}
+}
+
+// Sign fills in the placeholder in f, an encoded file of the given
+// size. It first writes the ByteRange, then passes the bytes that it
+// covers to sign, and finally writes the returned signature into the
+// Contents placeholder.
+func (p SignaturePlaceholder) Sign(f interface {
+	io.ReaderAt
+	io.WriterAt
+}, size int64, sign func(io.Reader) ([]byte, error)) error {
+	r := p.Range(size)
+	byteRange := []byte(fmt.Sprintf("[%v %v %v %v]", r[0], r[1], r[2], r[3]))
+	if len(byteRange) > byteRangeLength {
+		return errors.New("pdf: file is too large to sign")
+	}
+	for len(byteRange) < byteRangeLength {
+		byteRange = append(byteRange, ' ')
+	}
+	_, err := f.WriteAt(byteRange, p.ByteRange)
+	if err != nil {
+		return err
+	}
+
+	sig, err := sign(io.MultiReader(
+		io.NewSectionReader(f, r[0], r[1]),
+		io.NewSectionReader(f, r[2], r[3]),
+	))
+	if err != nil {
+		return err
+	}
+	if int64(2*len(sig)+2) > p.Length {
+		return fmt.Errorf("pdf: signature of %v bytes doesn't fit in %v reserved", len(sig), (p.Length-2)/2)
+	}
+
+	contents := make([]byte, p.Length-2)
+	n := hex.Encode(contents, sig)
+	for i := n; i < len(contents); i++ {
+		contents[i] = '0'
+	}
+	_, err = f.WriteAt(contents, p.Contents+1)
+	return err
+}
+
+// SignatureField returns a signature form field named name whose value
+// is sig, which should refer to a Signature, combined with the widget
+// annotation that displays it in rect, in page coordinates. The
+// signature is invisible if rect is empty. It is added to a document
+// in the same way as a TextField.
+func SignatureField(rect Rectangle, name string, sig Reference) Dict {
+	field := widget(rect)
+	field["FT"] = Name("Sig")
+	field["T"] = TextString(name)
+	field["V"] = sig
+	return field
+}
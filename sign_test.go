@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+type memFile struct{ data []byte }
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	return copy(f.data[off:], p), nil
+}
+
+func TestSignature(t *testing.T) {
+	for _, enc := range []*Encryption{nil, {UserPassword: "u", AES: true, KeyLength: 256}} {
+		var d Document
+		sig := d.Add(Signature{Size: 64, Reason: "testing"})
+		field := d.Add(SignatureField(Rectangle{}, "Sig1", sig))
+		var err error
+		d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Annots: []Object{field}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.SetAcroForm(); err != nil {
+			t.Fatal(err)
+		}
+		d.Encryption = enc
+		var out bytes.Buffer
+		r, err := d.Finish(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Signatures) != 1 {
+			t.Fatal(r.Signatures)
+		}
+		check := func(data []byte) {
+			t.Helper()
+			conf := model.NewDefaultConfiguration()
+			conf.ValidationMode = model.ValidationRelaxed
+			conf.UserPW = "u"
+			if err := api.Validate(bytes.NewReader(data), conf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		check(out.Bytes())
+		p := r.Signatures[0]
+		f := &memFile{data: out.Bytes()}
+		var signed []byte
+		err = p.Sign(f, r.Size, func(r io.Reader) ([]byte, error) {
+			signed, _ = io.ReadAll(r)
+			h := sha256.Sum256(signed)
+			return h[:], nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := f.data
+		br := p.Range(r.Size)
+		if data[br[1]] != '<' || data[br[2]-1] != '>' || br[2]+br[3] != int64(len(data)) {
+			t.Fatal(br)
+		}
+		if !bytes.Equal(signed, append(append([]byte(nil), data[:br[1]]...), data[br[2]:]...)) {
+			t.Fatal("signed bytes")
+		}
+		if !bytes.Contains(data, []byte("/ByteRange [0 "+itoa(int(br[1]))+" "+itoa(int(br[2]))+" "+itoa(int(br[3]))+"]")) {
+			t.Fatal("byterange")
+		}
+		h := sha256.Sum256(signed)
+		if !bytes.Contains(data, []byte(hex.EncodeToString(h[:]))) {
+			t.Fatal("contents")
+		}
+		if !bytes.Contains(data, []byte("/SigFlags 3")) {
+			t.Fatal("sigflags")
+		}
+		check(data)
+		err = p.Sign(f, r.Size, func(io.Reader) ([]byte, error) { return make([]byte, 65), nil })
+		if err == nil {
+			t.Fatal("too big")
+		}
+	}
+	var d Document
+	d.Add(Signature{})
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.ObjectStreams = true
+	if _, err := d.Finish(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	d.ObjectStreams = false
+	d.Linearize = true
+	if _, err := d.Finish(io.Discard); err == nil {
+		t.Fatal("linearized signature")
+	}
+	if err := EncodeObject(io.Discard, Signature{}); err == nil {
+		t.Fatal("bare")
+	}
+}
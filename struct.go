@@ -0,0 +1,141 @@
+package pdf
+
+import "fmt"
+
+// StructElem is an element of a document's logical structure, which
+// describes the document's content in reading order for accessibility
+// tools and reflowing viewers.
+type StructElem struct {
+	// Type is the structure type of the element, such as P, H1, or
+	// Figure.
+	Type Name
+
+	// Alt is an optional description of the element, such as of what
+	// a Figure depicts, to use in place of its content.
+	Alt string
+
+	// Content holds the marked-content sequences that make up the
+	// element's own content, which comes before its children.
+	Content []MarkedContent
+
+	Children []StructElem
+}
+
+// MarkedContent identifies a marked-content sequence on a page, such
+// as one begun by Content.BeginMarkedContent.
+type MarkedContent struct {
+	Page Reference
+	MCID int
+}
+
+// SetStructTree adds a structure tree with elems at its top level to
+// the document, refers to it from the catalog, and marks the document
+// as tagged. The pages containing the elements' marked content are
+// updated to refer to the tree. d.Root must already be set to the
+// catalog, such as one returned by AddPages.
+func (d *Document) SetStructTree(elems []StructElem) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	root := Dict{"Type": Name("StructTreeRoot")}
+	ref := d.addDistinct(root)
+
+	// The parent tree maps the marked content on each page back to
+	// the elements that it belongs to, indexed by MCID.
+	var pages []Reference
+	parents := make(map[Reference]Array)
+	var addElems func(parent Reference, elems []StructElem) (Array, error)
+	addElems = func(parent Reference, elems []StructElem) (Array, error) {
+		kids := make(Array, 0, len(elems))
+		for _, elem := range elems {
+			dict := Dict{
+				"Type": Name("StructElem"),
+				"S":    elem.Type,
+				"P":    parent,
+			}
+			if elem.Alt != "" {
+				dict["Alt"] = TextString(elem.Alt)
+			}
+			ref := d.addDistinct(dict)
+			kids = append(kids, ref)
+
+			var k Array
+			for _, mc := range elem.Content {
+				if mc.MCID < 0 {
+					return nil, fmt.Errorf("pdf: invalid MCID %v", mc.MCID)
+				}
+
+				if _, ok := parents[mc.Page]; !ok {
+					pages = append(pages, mc.Page)
+				}
+				mcids := parents[mc.Page]
+				for len(mcids) <= mc.MCID {
+					mcids = append(mcids, Null{})
+				}
+				if mcids[mc.MCID] != (Null{}) {
+					return nil, fmt.Errorf("pdf: MCID %v used more than once on page %q", mc.MCID, mc.Page)
+				}
+				mcids[mc.MCID] = ref
+				parents[mc.Page] = mcids
+
+				if _, ok := dict["Pg"]; !ok {
+					dict["Pg"] = mc.Page
+				}
+				if dict["Pg"] == mc.Page {
+					k = append(k, Integer(mc.MCID))
+					continue
+				}
+				k = append(k, Dict{
+					"Type": Name("MCR"),
+					"Pg":   mc.Page,
+					"MCID": Integer(mc.MCID),
+				})
+			}
+
+			children, err := addElems(ref, elem.Children)
+			if err != nil {
+				return nil, err
+			}
+			k = append(k, children...)
+			if len(k) > 0 {
+				dict["K"] = k
+			}
+		}
+		return kids, nil
+	}
+	kids, err := addElems(ref, elems)
+	if err != nil {
+		return err
+	}
+	root["K"] = kids
+
+	entries := make([]treeEntry, 0, len(pages))
+	for i, page := range pages {
+		dict, ok := d.pageDict(page)
+		if !ok {
+			return fmt.Errorf("pdf: marked content refers to %q, which is not a page", page)
+		}
+		dict["StructParents"] = Integer(i)
+		entries = append(entries, treeEntry{key: Integer(i), value: parents[page]})
+	}
+	root["ParentTree"] = d.addTree("Nums", entries)
+	root["ParentTreeNextKey"] = Integer(len(pages))
+
+	catalog["StructTreeRoot"] = ref
+	catalog["MarkInfo"] = Dict{"Marked": Boolean(true)}
+	return nil
+}
+
+// pageDict returns the page dictionary that page refers to.
+func (d *Document) pageDict(page Reference) (Dict, bool) {
+	for _, obj := range d.Body {
+		if obj.Name != string(page) {
+			continue
+		}
+		dict, ok := obj.Object.(Dict)
+		return dict, ok && (dict["Type"] == Name("Page"))
+	}
+	return nil, false
+}
@@ -0,0 +1,74 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStructTree(t *testing.T) {
+	var d Document
+	var c Content
+	c.BeginMarkedContent("H1", 0)
+	c.BeginText()
+	c.SetFont("F1", 24)
+	c.SetTextPosition(72, 720)
+	c.ShowText("Title")
+	c.EndText()
+	c.EndMarkedContent()
+	c.BeginMarkedContent("P", 1)
+	c.BeginText()
+	c.SetFont("F1", 12)
+	c.SetTextPosition(72, 690)
+	c.ShowText("Body text.")
+	c.EndText()
+	c.EndMarkedContent()
+	if !strings.HasPrefix(string(c.Bytes()), "/H1 <</MCID 0 >> BDC\n") {
+		t.Fatal(string(c.Bytes()))
+	}
+	var c2 Content
+	c2.BeginMarkedContent("P", 0)
+	c2.EndMarkedContent()
+	catalog, pages, err := d.AddPages([]Page{
+		{MediaBox: A4, Contents: c.Stream(), Resources: Dict{"Font": Dict{"F1": Helvetica.Dict()}}},
+		{MediaBox: A4, Contents: c2.Stream()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	err = d.SetStructTree([]StructElem{{Type: "Document", Children: []StructElem{
+		{Type: "H1", Content: []MarkedContent{{pages[0], 0}}},
+		{Type: "P", Content: []MarkedContent{{pages[0], 1}, {pages[1], 0}}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	for _, want := range []string{
+		"/MarkInfo <</Marked true >>",
+		"/K [1",
+		"/S /H1 /Type /StructElem",
+		"/K [0] /P",
+		"/K [1 <</MCID 0 /Pg ",
+		"/StructParents 0",
+		"/StructParents 1",
+		"/ParentTreeNextKey 2",
+		"/Nums [0 [",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Fatal(want)
+		}
+	}
+	validate(t, data)
+	if err := d.SetStructTree([]StructElem{{Type: "P", Content: []MarkedContent{{pages[0], 0}, {pages[0], 0}}}}); err == nil {
+		t.Fatal("dup")
+	}
+	if err := d.SetStructTree([]StructElem{{Type: "P", Content: []MarkedContent{{catalog, 0}}}}); err == nil {
+		t.Fatal("not page")
+	}
+}
@@ -0,0 +1,382 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math/bits"
+	"slices"
+	"sort"
+)
+
+// Composite glyph flags.
+const (
+	glyphArgsAreWords   = 0x0001
+	glyphHaveScale      = 0x0008
+	glyphMoreComponents = 0x0020
+	glyphHaveXYScale    = 0x0040
+	glyphHaveTwoByTwo   = 0x0080
+)
+
+// Subset returns a new font containing only the glyphs needed to show
+// runes, along with the missing glyph and any glyphs that those are
+// built from. Glyphs are renumbered in the new font, and its cmap only
+// covers runes.
+func (f *TrueType) Subset(runes []rune) (*TrueType, error) {
+	loca, err := f.loca()
+	if err != nil {
+		return nil, err
+	}
+	glyf := f.tables["glyf"]
+
+	glyph := func(gid uint16) []byte {
+		start, end := loca[gid], loca[gid+1]
+		if (start > end) || (end > uint32(len(glyf))) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+
+	old := []uint16{0}
+	renumber := map[uint16]uint16{0: 0}
+	add := func(gid uint16) {
+		if _, ok := renumber[gid]; !ok {
+			renumber[gid] = uint16(len(old))
+			old = append(old, gid)
+		}
+	}
+	for _, r := range runes {
+		if gid := f.GlyphIndex(r); gid != 0 {
+			add(gid)
+		}
+	}
+	for i := 0; i < len(old); i++ {
+		for _, c := range glyphComponents(glyph(old[i])) {
+			if int(c) < f.numGlyphs {
+				add(c)
+			}
+		}
+	}
+
+	var newGlyf []byte
+	newLoca := make([]byte, 0, 4*(len(old)+1))
+	for _, gid := range old {
+		newLoca = binary.BigEndian.AppendUint32(newLoca, uint32(len(newGlyf)))
+
+		start := len(newGlyf)
+		newGlyf = append(newGlyf, glyph(gid)...)
+		renumberComponents(newGlyf[start:], renumber)
+		for len(newGlyf)%4 != 0 {
+			newGlyf = append(newGlyf, 0)
+		}
+	}
+	newLoca = binary.BigEndian.AppendUint32(newLoca, uint32(len(newGlyf)))
+
+	hmtx := make([]byte, 0, 4*len(old))
+	for _, gid := range old {
+		hmtx = binary.BigEndian.AppendUint16(hmtx, f.advances[gid])
+		hmtx = binary.BigEndian.AppendUint16(hmtx, uint16(f.lsb(gid)))
+	}
+
+	cmap := make(map[rune]uint16, len(runes))
+	for _, r := range runes {
+		if gid := f.GlyphIndex(r); gid != 0 {
+			cmap[r] = renumber[gid]
+		}
+	}
+	cmapTable, err := buildCmap(cmap)
+	if err != nil {
+		return nil, err
+	}
+
+	head := slices.Clone(f.tables["head"])
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	hhea := slices.Clone(f.tables["hhea"])
+	binary.BigEndian.PutUint16(hhea[34:], uint16(len(old)))
+
+	maxp := slices.Clone(f.tables["maxp"])
+	binary.BigEndian.PutUint16(maxp[4:], uint16(len(old)))
+
+	tables := map[string][]byte{
+		"cmap": cmapTable,
+		"glyf": newGlyf,
+		"head": head,
+		"hhea": hhea,
+		"hmtx": hmtx,
+		"loca": newLoca,
+		"maxp": maxp,
+	}
+	// Hinting programs and descriptive tables don't depend on glyph
+	// numbering and can be carried over as-is. The post table loses its
+	// glyph names, which do.
+	for _, tag := range []string{"cvt ", "fpgm", "prep", "name", "OS/2"} {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	if post := f.tables["post"]; len(post) >= 32 {
+		post = slices.Clone(post[:32])
+		binary.BigEndian.PutUint32(post, 0x00030000)
+		tables["post"] = post
+	}
+
+	return ParseTrueType(buildSfnt(tables))
+}
+
+// loca returns the glyph offsets from f's loca table.
+func (f *TrueType) loca() ([]uint32, error) {
+	loca, ok := f.tables["loca"]
+	if !ok {
+		return nil, errors.New("pdf: TrueType font is missing required table \"loca\"")
+	}
+	if _, ok := f.tables["glyf"]; !ok {
+		return nil, errors.New("pdf: TrueType font is missing required table \"glyf\"")
+	}
+
+	offsets := make([]uint32, f.numGlyphs+1)
+	if f.indexToLocFormat == 0 {
+		if len(loca) < 2*len(offsets) {
+			return nil, errors.New("pdf: TrueType loca table is too short")
+		}
+		for i := range offsets {
+			offsets[i] = 2 * uint32(binary.BigEndian.Uint16(loca[2*i:]))
+		}
+		return offsets, nil
+	}
+
+	if len(loca) < 4*len(offsets) {
+		return nil, errors.New("pdf: TrueType loca table is too short")
+	}
+	for i := range offsets {
+		offsets[i] = binary.BigEndian.Uint32(loca[4*i:])
+	}
+	return offsets, nil
+}
+
+// lsb returns the left side bearing of the glyph gid in font units.
+func (f *TrueType) lsb(gid uint16) int16 {
+	hmtx := f.tables["hmtx"]
+	numHMetrics := int(binary.BigEndian.Uint16(f.tables["hhea"][34:]))
+
+	off := 4*int(gid) + 2
+	if int(gid) >= numHMetrics {
+		off = 4*numHMetrics + 2*(int(gid)-numHMetrics)
+	}
+	if off+2 > len(hmtx) {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(hmtx[off:]))
+}
+
+// forComponents calls yield with the offset of the glyph index of each
+// of the components of a composite glyph. It does nothing for simple
+// glyphs.
+func forComponents(glyph []byte, yield func(off int)) {
+	if (len(glyph) < 10) || (int16(binary.BigEndian.Uint16(glyph)) >= 0) {
+		return
+	}
+
+	off := 10
+	for off+4 <= len(glyph) {
+		flags := binary.BigEndian.Uint16(glyph[off:])
+		yield(off + 2)
+
+		off += 4
+		if flags&glyphArgsAreWords != 0 {
+			off += 4
+		} else {
+			off += 2
+		}
+		switch {
+		case flags&glyphHaveScale != 0:
+			off += 2
+		case flags&glyphHaveXYScale != 0:
+			off += 4
+		case flags&glyphHaveTwoByTwo != 0:
+			off += 8
+		}
+
+		if flags&glyphMoreComponents == 0 {
+			return
+		}
+	}
+}
+
+// glyphComponents returns the glyph indices of the components of a
+// composite glyph.
+func glyphComponents(glyph []byte) (gids []uint16) {
+	forComponents(glyph, func(off int) {
+		gids = append(gids, binary.BigEndian.Uint16(glyph[off:]))
+	})
+	return gids
+}
+
+// renumberComponents rewrites the component glyph indices of a
+// composite glyph in place.
+func renumberComponents(glyph []byte, renumber map[uint16]uint16) {
+	forComponents(glyph, func(off int) {
+		gid := binary.BigEndian.Uint16(glyph[off:])
+		binary.BigEndian.PutUint16(glyph[off:], renumber[gid])
+	})
+}
+
+// buildCmap builds a cmap table for m. It contains a format 4 subtable
+// for the runes in the Basic Multilingual Plane, and a format 12
+// subtable if there are any runes outside of it.
+func buildCmap(m map[rune]uint16) ([]byte, error) {
+	runes := make([]rune, 0, len(m))
+	for r := range m {
+		runes = append(runes, r)
+	}
+	slices.Sort(runes)
+
+	// Group runs of consecutive runes that map to consecutive glyphs.
+	type group struct {
+		start, end rune
+		gid        uint16
+	}
+	var groups []group
+	for _, r := range runes {
+		if n := len(groups); (n > 0) && (groups[n-1].end == r-1) && (groups[n-1].gid+uint16(r-groups[n-1].start) == m[r]) {
+			groups[n-1].end = r
+			continue
+		}
+		groups = append(groups, group{start: r, end: r, gid: m[r]})
+	}
+
+	bmp := groups[:sort.Search(len(groups), func(i int) bool { return groups[i].end > 0xFFFE })]
+
+	segs := len(bmp) + 1
+	length := 16 + 8*segs
+	if length > 0xFFFF {
+		return nil, errors.New("pdf: too many characters for a TrueType subset")
+	}
+	log := bits.Len(uint(segs)) - 1
+	format4 := make([]byte, 0, length)
+	format4 = binary.BigEndian.AppendUint16(format4, 4)
+	format4 = binary.BigEndian.AppendUint16(format4, uint16(length))
+	format4 = binary.BigEndian.AppendUint16(format4, 0)
+	format4 = binary.BigEndian.AppendUint16(format4, uint16(2*segs))
+	format4 = binary.BigEndian.AppendUint16(format4, uint16(2<<log))
+	format4 = binary.BigEndian.AppendUint16(format4, uint16(log))
+	format4 = binary.BigEndian.AppendUint16(format4, uint16(2*segs-2<<log))
+	for _, g := range bmp {
+		format4 = binary.BigEndian.AppendUint16(format4, uint16(g.end))
+	}
+	format4 = binary.BigEndian.AppendUint16(format4, 0xFFFF)
+	format4 = binary.BigEndian.AppendUint16(format4, 0)
+	for _, g := range bmp {
+		format4 = binary.BigEndian.AppendUint16(format4, uint16(g.start))
+	}
+	format4 = binary.BigEndian.AppendUint16(format4, 0xFFFF)
+	for _, g := range bmp {
+		format4 = binary.BigEndian.AppendUint16(format4, g.gid-uint16(g.start))
+	}
+	format4 = binary.BigEndian.AppendUint16(format4, 1)
+	format4 = append(format4, make([]byte, 2*segs)...)
+
+	subtables := [][]byte{format4}
+	if len(bmp) < len(groups) {
+		format12 := make([]byte, 0, 16+12*len(groups))
+		format12 = binary.BigEndian.AppendUint16(format12, 12)
+		format12 = binary.BigEndian.AppendUint16(format12, 0)
+		format12 = binary.BigEndian.AppendUint32(format12, uint32(16+12*len(groups)))
+		format12 = binary.BigEndian.AppendUint32(format12, 0)
+		format12 = binary.BigEndian.AppendUint32(format12, uint32(len(groups)))
+		for _, g := range groups {
+			format12 = binary.BigEndian.AppendUint32(format12, uint32(g.start))
+			format12 = binary.BigEndian.AppendUint32(format12, uint32(g.end))
+			format12 = binary.BigEndian.AppendUint32(format12, uint32(g.gid))
+		}
+		subtables = append(subtables, format12)
+	}
+
+	encodings := []uint16{1, 10}
+	cmap := make([]byte, 0, 4+8*len(subtables))
+	cmap = binary.BigEndian.AppendUint16(cmap, 0)
+	cmap = binary.BigEndian.AppendUint16(cmap, uint16(len(subtables)))
+	off := 4 + 8*len(subtables)
+	for i, sub := range subtables {
+		cmap = binary.BigEndian.AppendUint16(cmap, 3)
+		cmap = binary.BigEndian.AppendUint16(cmap, encodings[i])
+		cmap = binary.BigEndian.AppendUint32(cmap, uint32(off))
+		off += len(sub)
+	}
+	for _, sub := range subtables {
+		cmap = append(cmap, sub...)
+	}
+	return cmap, nil
+}
+
+// buildSfnt assembles a TrueType font file from tables, filling in the
+// checksums.
+func buildSfnt(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	log := bits.Len(uint(len(tags))) - 1
+	out := make([]byte, 0, 12+16*len(tags))
+	out = binary.BigEndian.AppendUint32(out, 0x00010000)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(tags)))
+	out = binary.BigEndian.AppendUint16(out, uint16(16<<log))
+	out = binary.BigEndian.AppendUint16(out, uint16(log))
+	out = binary.BigEndian.AppendUint16(out, uint16(16*len(tags)-16<<log))
+
+	off := 12 + 16*len(tags)
+	for _, tag := range tags {
+		table := tables[tag]
+		out = append(out, tag...)
+		out = binary.BigEndian.AppendUint32(out, sfntChecksum(table))
+		out = binary.BigEndian.AppendUint32(out, uint32(off))
+		out = binary.BigEndian.AppendUint32(out, uint32(len(table)))
+		off += (len(table) + 3) &^ 3
+	}
+
+	var head int
+	for _, tag := range tags {
+		if tag == "head" {
+			head = len(out)
+		}
+		out = append(out, tables[tag]...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+
+	binary.BigEndian.PutUint32(out[head+8:], 0xB1B0AFBA-sfntChecksum(out))
+	return out
+}
+
+// sfntChecksum computes the checksum of a table, treating it as though
+// it were padded with zeros to a multiple of four bytes.
+func sfntChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// subsetTag returns the six letter tag that identifies a font subset
+// containing the glyphs for runes.
+func subsetTag(runes []rune) string {
+	h := fnv.New32a()
+	for _, r := range runes {
+		binary.Write(h, binary.BigEndian, r)
+	}
+	sum := h.Sum32()
+
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
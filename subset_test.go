@@ -0,0 +1,153 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"slices"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+func TestSubset(t *testing.T) {
+	for _, ttf := range [][]byte{goregular.TTF, gomonobold.TTF} {
+		f, err := ParseTrueType(ttf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runes := []rune("AbC")
+		sub, err := f.Subset(runes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub.NumGlyphs() != 4 {
+			t.Fatal(sub.NumGlyphs())
+		}
+		sf, err := sfnt.Parse(sub.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sf.NumGlyphs() != 4 {
+			t.Fatal(sf.NumGlyphs())
+		}
+		var b sfnt.Buffer
+		orig, _ := sfnt.Parse(f.data)
+		for i, r := range runes {
+			gi, err := sf.GlyphIndex(&b, r)
+			if err != nil || int(gi) != i+1 || sub.GlyphIndex(r) != uint16(i+1) {
+				t.Fatal(r, gi, err)
+			}
+			segs, err := sf.LoadGlyph(&b, gi, 2048, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := len(segs)
+			ogi, _ := orig.GlyphIndex(&b, r)
+			osegs, _ := orig.LoadGlyph(&b, ogi, 2048, nil)
+			if n != len(osegs) || n == 0 {
+				t.Fatal("segments", n, len(osegs))
+			}
+			if sub.advance(uint16(i+1)) != f.advance(f.GlyphIndex(r)) {
+				t.Fatal("advance")
+			}
+		}
+		if sub.GlyphIndex('z') != 0 {
+			t.Fatal("z")
+		}
+		if sfntChecksum(sub.data) != 0xB1B0AFBA {
+			t.Fatalf("%x", sfntChecksum(sub.data))
+		}
+		// Composite glyphs, astral runes.
+		sub2, err := f.Subset([]rune("éÅ😀ǅ"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sf2, err := sfnt.Parse(sub2.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < sub2.NumGlyphs(); i++ {
+			if _, err := sf2.LoadGlyph(&b, sfnt.GlyphIndex(i), 2048, nil); err != nil {
+				t.Fatal(i, err)
+			}
+		}
+	}
+}
+
+func TestEmbedSubsetValid(t *testing.T) {
+	var d Document
+	font, err := d.EmbedTrueType(goregular.TTF, []rune("Héllo wörld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Content
+	c.BeginText()
+	c.SetFont("F1", 24)
+	c.SetTextPosition(72, 700)
+	c.ShowText(font.Encode("Héllo wörld"))
+	c.EndText()
+	cat, _, err := d.AddPages([]Page{{MediaBox: Letter, Contents: c.Stream(), Resources: Dict{"Font": Dict{"F1": font.Ref}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = cat
+	var buf bytes.Buffer
+	if _, err := d.Finish(&buf); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, buf.Bytes())
+}
+
+func TestCompositeSubset(t *testing.T) {
+	data, err := os.ReadFile("testdata/Roboto-Regular.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseTrueType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loca, _ := f.loca()
+	glyf := f.tables["glyf"]
+	var comp []rune
+	for r, gid := range f.cmap {
+		g := glyf[loca[gid]:loca[gid+1]]
+		if len(g) > 10 && int16(binary.BigEndian.Uint16(g)) < 0 {
+			comp = append(comp, r)
+		}
+	}
+	if len(comp) < 5 {
+		t.Fatal(len(comp))
+	}
+	slices.Sort(comp)
+	sub, err := f.Subset(comp[:5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := sfnt.Parse(sub.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig, _ := sfnt.Parse(f.data)
+	var b sfnt.Buffer
+	for _, r := range comp[:5] {
+		gi, _ := sf.GlyphIndex(&b, r)
+		segs, err := sf.LoadGlyph(&b, gi, 2048, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ogi, _ := orig.GlyphIndex(&b, r)
+		osegs, _ := orig.LoadGlyph(&b, ogi, 2048, nil)
+		if len(segs) != len(osegs) {
+			t.Fatal("mismatch")
+		}
+		for i := range segs {
+			if segs[i] != osegs[i] {
+				t.Fatal("segment mismatch")
+			}
+		}
+	}
+}
@@ -0,0 +1,528 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxFormDepth limits how deeply forms drawn by other forms are
+// followed when extracting text, in case they draw each other.
+const maxFormDepth = 8
+
+// ExtractText returns the text shown by the content of page, such as
+// one returned by PDF.Pages, in the order that it is shown. Character
+// codes are converted to text using the fonts' ToUnicode CMaps if they
+// have them, or their encodings otherwise. Text that starts on a new
+// line, or after a gap from the text before it, is separated from it
+// by a newline or a space. Text in forms that the page draws is
+// included as well.
+func ExtractText(page *Page) (string, error) {
+	var data []byte
+	var streams []Object
+	switch contents := page.Contents.(type) {
+	case Stream:
+		streams = []Object{contents}
+	case Array:
+		streams = contents
+	}
+	for _, st := range streams {
+		st, ok := st.(Stream)
+		if !ok {
+			continue
+		}
+		b, err := streamBytes(st)
+		if err != nil {
+			return "", err
+		}
+		// Content split across streams is joined as though they were
+		// one, with whitespace between them.
+		data = append(append(data, b...), '\n')
+	}
+
+	var e textExtractor
+	err := e.run(data, page.Resources, Identity, 0)
+	if err != nil {
+		return "", err
+	}
+	return e.buf.String(), nil
+}
+
+// streamBytes returns the decoded data of st. Data that can be read
+// at an offset, as it is in decoded streams, is read from the start
+// each time, so that the same stream can be read more than once.
+func streamBytes(st Stream) ([]byte, error) {
+	if r, ok := st.Data.(io.ReaderAt); ok && (st.Length > 0) {
+		st.Data = io.NewSectionReader(r, 0, st.Length)
+	}
+	r, err := decodeStream(st)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// textExtractor collects the text shown by content streams.
+type textExtractor struct {
+	buf strings.Builder
+
+	// x, y, and size are the position, in device space, at which the
+	// last text shown ended, and the size of its font, for deciding
+	// whether more text continues it. started is set once anything
+	// has been shown.
+	x, y, size float64
+	started    bool
+
+	fonts map[uintptr]*textFont
+}
+
+// textState is the part of the graphics state that affects text.
+type textState struct {
+	ctm              Matrix
+	font             *textFont
+	size             float64
+	charSpace        float64
+	wordSpace        float64
+	scale            float64
+	leading, rise    float64
+	textMatrix, line Matrix
+}
+
+// run extracts the text from the content stream data, which uses the
+// given resources, with ctm as the initial transformation matrix.
+func (e *textExtractor) run(data []byte, resources Dict, ctm Matrix, depth int) error {
+	ops, err := ParseContent(data)
+	if err != nil {
+		return err
+	}
+
+	fonts, _ := resources["Font"].(Dict)
+	xobjects, _ := resources["XObject"].(Dict)
+
+	ts := textState{ctm: ctm, scale: 1}
+	var stack []textState
+	for _, op := range ops {
+		nums := make([]float64, len(op.Operands))
+		for i, v := range op.Operands {
+			nums[i], _ = toNumber(v)
+		}
+
+		switch op.Operator {
+		case "q":
+			stack = append(stack, ts)
+		case "Q":
+			if len(stack) > 0 {
+				ts = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(nums) == 6 {
+				ts.ctm = Matrix(nums).Mul(ts.ctm)
+			}
+
+		case "BT":
+			ts.textMatrix, ts.line = Identity, Identity
+		case "Tf":
+			if len(op.Operands) == 2 {
+				name, _ := op.Operands[0].(Name)
+				dict, _ := fonts[name].(Dict)
+				ts.font = e.font(dict)
+				ts.size = nums[1]
+			}
+		case "Tc":
+			if len(nums) == 1 {
+				ts.charSpace = nums[0]
+			}
+		case "Tw":
+			if len(nums) == 1 {
+				ts.wordSpace = nums[0]
+			}
+		case "Tz":
+			if len(nums) == 1 {
+				ts.scale = nums[0] / 100
+			}
+		case "TL":
+			if len(nums) == 1 {
+				ts.leading = nums[0]
+			}
+		case "Ts":
+			if len(nums) == 1 {
+				ts.rise = nums[0]
+			}
+		case "Td":
+			if len(nums) == 2 {
+				ts.moveLine(nums[0], nums[1])
+			}
+		case "TD":
+			if len(nums) == 2 {
+				ts.leading = -nums[1]
+				ts.moveLine(nums[0], nums[1])
+			}
+		case "Tm":
+			if len(nums) == 6 {
+				ts.textMatrix, ts.line = Matrix(nums), Matrix(nums)
+			}
+		case "T*":
+			ts.moveLine(0, -ts.leading)
+
+		case "Tj":
+			if len(op.Operands) == 1 {
+				e.show(&ts, op.Operands[0])
+			}
+		case "'":
+			if len(op.Operands) == 1 {
+				ts.moveLine(0, -ts.leading)
+				e.show(&ts, op.Operands[0])
+			}
+		case "\"":
+			if len(op.Operands) == 3 {
+				ts.wordSpace, ts.charSpace = nums[0], nums[1]
+				ts.moveLine(0, -ts.leading)
+				e.show(&ts, op.Operands[2])
+			}
+		case "TJ":
+			if len(op.Operands) != 1 {
+				break
+			}
+			a, _ := op.Operands[0].(Array)
+			for _, v := range a {
+				if n, ok := toNumber(v); ok {
+					ts.advance(-n / 1000 * ts.size * ts.scale)
+					continue
+				}
+				e.show(&ts, v)
+			}
+
+		case "Do":
+			if (len(op.Operands) != 1) || (depth >= maxFormDepth) {
+				break
+			}
+			name, _ := op.Operands[0].(Name)
+			form, ok := xobjects[name].(Stream)
+			if !ok || (form.Dict["Subtype"] != Name("Form")) {
+				break
+			}
+			err := e.runForm(form, resources, ts.ctm, depth)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runForm extracts the text from form, drawn with the transformation
+// matrix ctm from a content stream with the given resources.
+func (e *textExtractor) runForm(form Stream, resources Dict, ctm Matrix, depth int) error {
+	data, err := streamBytes(form)
+	if err != nil {
+		return err
+	}
+	m := Identity
+	if a, ok := form.Dict["Matrix"].(Array); ok && (len(a) == 6) {
+		for i := range m {
+			m[i], _ = toNumber(a[i])
+		}
+	}
+	if res, ok := form.Dict["Resources"].(Dict); ok {
+		resources = res
+	}
+	return e.run(data, resources, m.Mul(ctm), depth+1)
+}
+
+// moveLine moves to the start of the next line, offset from the start
+// of the current one by (x, y).
+func (ts *textState) moveLine(x, y float64) {
+	ts.line = Translate(x, y).Mul(ts.line)
+	ts.textMatrix = ts.line
+}
+
+// advance moves the text position horizontally by tx in text space.
+func (ts *textState) advance(tx float64) {
+	ts.textMatrix = Translate(tx, 0).Mul(ts.textMatrix)
+}
+
+// show adds the text of the string str, shown with the state ts, and
+// advances the text position past it.
+func (e *textExtractor) show(ts *textState, str Object) {
+	codes := stringBytes(str)
+	if (ts.font == nil) || (codes == nil) {
+		return
+	}
+
+	m := ts.textMatrix.Mul(ts.ctm)
+	x, y := m.Apply(0, ts.rise)
+	size := ts.size * math.Sqrt(math.Abs(m[0]*m[3]-m[1]*m[2]))
+
+	var text strings.Builder
+	for _, g := range ts.font.glyphs(codes) {
+		text.WriteString(g.text)
+		tx := g.width/1000*ts.size + ts.charSpace
+		if g.space {
+			tx += ts.wordSpace
+		}
+		ts.advance(tx * ts.scale)
+	}
+	e.add(text.String(), x, y, size)
+
+	m = ts.textMatrix.Mul(ts.ctm)
+	e.x, e.y = m.Apply(0, ts.rise)
+}
+
+// add adds text, which starts at (x, y) in device space and is shown
+// at the given size, separating it from the text before it if it
+// doesn't simply continue it.
+func (e *textExtractor) add(text string, x, y, size float64) {
+	if text == "" {
+		return
+	}
+
+	if e.started {
+		lineHeight := max(size, e.size)
+		switch {
+		case math.Abs(y-e.y) > lineHeight/2:
+			e.buf.WriteByte('\n')
+		case (x-e.x > lineHeight/5) && !strings.HasPrefix(text, " ") && !strings.HasSuffix(e.buf.String(), " "):
+			e.buf.WriteByte(' ')
+		}
+	}
+	e.buf.WriteString(text)
+	e.started = true
+	e.size = size
+}
+
+// font returns the font described by dict, which is only parsed the
+// first time that it is used.
+func (e *textExtractor) font(dict Dict) *textFont {
+	if dict == nil {
+		return nil
+	}
+
+	// Dicts can't be compared, so they are identified by where their
+	// contents are, as in references.
+	key := reflect.ValueOf(dict).Pointer()
+	if f, ok := e.fonts[key]; ok {
+		return f
+	}
+	if e.fonts == nil {
+		e.fonts = make(map[uintptr]*textFont)
+	}
+	f := newTextFont(dict)
+	e.fonts[key] = f
+	return f
+}
+
+// textFont is what's needed of a font to extract the text shown with
+// it.
+type textFont struct {
+	// twoByte is set for Type0 fonts, which are assumed to use two
+	// byte codes, as with Identity-H.
+	twoByte bool
+
+	// toUnicode maps codes to text, if the font has a ToUnicode CMap.
+	toUnicode map[uint32]string
+
+	// widths maps codes to widths, in thousandths of a unit of text
+	// space, with defaultWidth used for any not listed.
+	widths       map[uint32]float64
+	defaultWidth float64
+}
+
+// glyph is a single character code shown in a font.
+type glyph struct {
+	text  string
+	width float64
+
+	// space is set for the single-byte code 32, to which word spacing
+	// is applied.
+	space bool
+}
+
+func newTextFont(dict Dict) *textFont {
+	f := &textFont{
+		twoByte: dict["Subtype"] == Name("Type0"),
+		widths:  make(map[uint32]float64),
+	}
+	if st, ok := dict["ToUnicode"].(Stream); ok {
+		data, err := streamBytes(st)
+		if err == nil {
+			f.toUnicode = parseToUnicode(data)
+		}
+	}
+
+	if f.twoByte {
+		descendants, _ := dict["DescendantFonts"].(Array)
+		var cid Dict
+		if len(descendants) > 0 {
+			cid, _ = descendants[0].(Dict)
+		}
+		f.defaultWidth = 1000
+		if dw, ok := toNumber(cid["DW"]); ok {
+			f.defaultWidth = dw
+		}
+		f.parseW(cid["W"])
+		return f
+	}
+
+	if widths, ok := dict["Widths"].(Array); ok {
+		first, _ := dict["FirstChar"].(Integer)
+		for i, w := range widths {
+			f.widths[uint32(first)+uint32(i)], _ = toNumber(w)
+		}
+		return f
+	}
+	base, _ := dict["BaseFont"].(Name)
+	for i, name := range standardFontNames {
+		if name == base {
+			for code, w := range standardFontWidths[i] {
+				f.widths[uint32(code)] = float64(w)
+			}
+			return f
+		}
+	}
+
+	// Without any widths, guess so that gaps can still be found.
+	f.defaultWidth = 500
+	return f
+}
+
+// parseW parses the W array of a CIDFont, which lists widths either
+// as a starting code followed by an array of widths for consecutive
+// codes, or as a range of codes followed by their common width.
+func (f *textFont) parseW(obj Object) {
+	w, _ := obj.(Array)
+	for i := 0; i+1 < len(w); {
+		start, _ := toNumber(w[i])
+		if a, ok := w[i+1].(Array); ok {
+			for j, v := range a {
+				f.widths[uint32(start)+uint32(j)], _ = toNumber(v)
+			}
+			i += 2
+			continue
+		}
+
+		if i+2 >= len(w) {
+			return
+		}
+		end, _ := toNumber(w[i+1])
+		width, _ := toNumber(w[i+2])
+		for c := uint32(start); c <= uint32(end); c++ {
+			f.widths[c] = width
+		}
+		i += 3
+	}
+}
+
+// glyphs splits codes into the glyphs that they show.
+func (f *textFont) glyphs(codes []byte) []glyph {
+	n := 1
+	if f.twoByte {
+		n = 2
+	}
+
+	glyphs := make([]glyph, 0, len(codes)/n)
+	for i := 0; i+n <= len(codes); i += n {
+		var code uint32
+		for _, c := range codes[i : i+n] {
+			code = code<<8 | uint32(c)
+		}
+
+		width, ok := f.widths[code]
+		if !ok {
+			width = f.defaultWidth
+		}
+		g := glyph{width: width, space: !f.twoByte && (code == ' ')}
+		switch text, ok := f.toUnicode[code]; {
+		case ok:
+			g.text = text
+		case !f.twoByte:
+			g.text = string(winAnsiRunes[code])
+		}
+		glyphs = append(glyphs, g)
+	}
+	return glyphs
+}
+
+// winAnsiRunes maps WinAnsiEncoding character codes to runes.
+var winAnsiRunes = func() (runes [256]rune) {
+	for r := range rune(0x10000) {
+		if code, ok := winAnsiCode(r); ok && (runes[code] == 0) {
+			runes[code] = r
+		}
+	}
+	return runes
+}()
+
+// parseToUnicode parses the mappings in the bfchar and bfrange blocks
+// of a ToUnicode CMap.
+func parseToUnicode(data []byte) map[uint32]string {
+	s := NewScanner(bytes.NewReader(data))
+	var toks []Token
+	for {
+		tok, err := s.Next()
+		if err != nil {
+			break
+		}
+		if tok.Kind != TokenComment {
+			toks = append(toks, tok)
+		}
+	}
+
+	code := func(tok Token) uint32 {
+		var c uint32
+		for _, b := range []byte(tok.Value) {
+			c = c<<8 | uint32(b)
+		}
+		return c
+	}
+	text := func(tok Token) string {
+		b := []byte(tok.Value)
+		u := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+
+	m := make(map[uint32]string)
+	for i := 0; i < len(toks); i++ {
+		if toks[i].Kind != TokenKeyword {
+			continue
+		}
+		switch toks[i].Value {
+		case "beginbfchar":
+			for i++; (i+1 < len(toks)) && (toks[i].Kind == TokenHexString); i += 2 {
+				m[code(toks[i])] = text(toks[i+1])
+			}
+		case "beginbfrange":
+			for i++; (i+2 < len(toks)) && (toks[i].Kind == TokenHexString); {
+				lo, hi := code(toks[i]), code(toks[i+1])
+				if toks[i+2].Kind == TokenArrayStart {
+					i += 3
+					for c := lo; (i < len(toks)) && (toks[i].Kind == TokenHexString); c++ {
+						if c <= hi {
+							m[c] = text(toks[i])
+						}
+						i++
+					}
+					i++ // ]
+					continue
+				}
+
+				// Consecutive codes map to text with the last UTF-16
+				// code unit incremented.
+				dst := []rune(text(toks[i+2]))
+				for c := lo; (c <= hi) && (len(dst) > 0) && (c-lo < 0x10000); c++ {
+					t := append([]rune(nil), dst...)
+					t[len(t)-1] += rune(c - lo)
+					m[c] = string(t)
+				}
+				i += 3
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,85 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestExtractText(t *testing.T) {
+	var d Document
+	font, err := d.EmbedTrueType(goregular.TTF, []rune("Héllo wörld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c Content
+	c.BeginText()
+	c.SetFont("F1", 12)
+	c.SetLeading(14)
+	c.SetTextPosition(72, 700)
+	c.ShowText("Hello, world")
+	c.NewLine()
+	c.ShowText(Helvetica.encode("Café"))
+	c.SetTextPosition(100, 0)
+	c.ShowText("line")
+	c.SetFont("F2", 10)
+	c.SetTextPosition(-100, -20)
+	c.ShowText(font.Encode("Héllo wörld"))
+	c.EndText()
+
+	var form Content
+	form.BeginText()
+	form.SetFont("F1", 12)
+	form.SetTextPosition(0, 0)
+	form.ShowText("in form")
+	form.EndText()
+	fx := d.Add(FormXObject{BBox: Rectangle{0, 0, 100, 100}, Resources: Dict{"Font": Dict{"F1": Helvetica.Dict()}}, Content: form.Stream()})
+	c.Save()
+	c.Concat(Translate(72, 100))
+	c.DrawXObject("X1")
+	c.Restore()
+
+	res := Dict{"Font": Dict{"F1": Helvetica.Dict(), "F2": font.Ref}, "XObject": Dict{"X1": fx}}
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4, Contents: Flate(bytes.NewReader(c.Bytes())), Resources: res}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Decode(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := p.Pages()
+	if err != nil || len(pages) != 1 {
+		t.Fatal(err)
+	}
+	want := "Hello, world\nCafé line\nHéllo wörld\nin form"
+	for range 2 {
+		got, err := ExtractText(&pages[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%q", got)
+		}
+	}
+	if pages[0].MediaBox != A4.Normalize() {
+		t.Fatal(pages[0].MediaBox)
+	}
+}
+
+func TestParseToUnicode(t *testing.T) {
+	cmap := []byte("2 beginbfchar <01> <0041> <02> <D83DDE00> endbfchar\n2 beginbfrange <10> <12> <0061> <20> <21> [<0078> <00790079>] endbfrange")
+	m := parseToUnicode(cmap)
+	want := map[uint32]string{1: "A", 2: "😀", 0x10: "a", 0x11: "b", 0x12: "c", 0x20: "x", 0x21: "yy"}
+	for k, v := range want {
+		if m[k] != v {
+			t.Fatal(k, m[k])
+		}
+	}
+}
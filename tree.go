@@ -0,0 +1,68 @@
+package pdf
+
+// treeFanout is the maximum number of entries in each leaf of a name
+// or number tree, and the maximum number of children of each of its
+// intermediate nodes.
+const treeFanout = 64
+
+// treeEntry is a key and value in a name or number tree.
+type treeEntry struct {
+	key   Object
+	value Object
+}
+
+// addTree adds a name or number tree containing entries, which must
+// already be sorted by key, to d and returns a reference to its root.
+// kind is the name of the entry holding the key-value pairs in leaf
+// nodes, either Names or Nums.
+func (d *Document) addTree(kind Name, entries []treeEntry) Reference {
+	type node struct {
+		ref    Reference
+		lo, hi Object
+	}
+
+	var level []node
+	for len(entries) > 0 {
+		n := min(len(entries), treeFanout)
+		kv := make(Array, 0, 2*n)
+		for _, e := range entries[:n] {
+			kv = append(kv, e.key, e.value)
+		}
+
+		lo, hi := entries[0].key, entries[n-1].key
+		ref := d.Add(Dict{
+			kind:     kv,
+			"Limits": Array{lo, hi},
+		})
+		level = append(level, node{ref: ref, lo: lo, hi: hi})
+		entries = entries[n:]
+	}
+
+	// The root may not have Limits, so it is always an intermediate node
+	// even when there is only a single leaf.
+	for len(level) > treeFanout {
+		next := make([]node, 0, (len(level)+treeFanout-1)/treeFanout)
+		for len(level) > 0 {
+			n := min(len(level), treeFanout)
+			kids := make(Array, 0, n)
+			for _, kid := range level[:n] {
+				kids = append(kids, kid.ref)
+			}
+
+			lo, hi := level[0].lo, level[n-1].hi
+			ref := d.Add(Dict{
+				"Kids":   kids,
+				"Limits": Array{lo, hi},
+			})
+			next = append(next, node{ref: ref, lo: lo, hi: hi})
+			level = level[n:]
+		}
+		level = next
+	}
+
+	kids := make(Array, 0, len(level))
+	for _, kid := range level {
+		kids = append(kids, kid.ref)
+	}
+	return d.Add(Dict{"Kids": kids})
+}
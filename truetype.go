@@ -0,0 +1,380 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// TrueType is a parsed TrueType font, ready to be embedded into a
+// document.
+type TrueType struct {
+	data   []byte
+	tables map[string][]byte
+
+	unitsPerEm       int
+	numGlyphs        int
+	indexToLocFormat int
+	macStyle         int
+	bbox             [4]int
+
+	ascent, descent int
+	capHeight       int
+	weight          int
+	italicAngle     float64
+	fixedPitch      bool
+	familyClass     int
+
+	advances []uint16
+	cmap     map[rune]uint16
+
+	postScriptName string
+}
+
+// ParseTrueType parses the TrueType font in data. Fonts with
+// PostScript outlines, as well as font collections, are not
+// supported.
+func ParseTrueType(data []byte) (*TrueType, error) {
+	if len(data) < 12 {
+		return nil, errors.New("pdf: TrueType font is too short")
+	}
+	switch tag := string(data[:4]); tag {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		return nil, errors.New("pdf: fonts with PostScript outlines are not supported")
+	case "ttcf":
+		return nil, errors.New("pdf: TrueType collections are not supported")
+	default:
+		return nil, fmt.Errorf("pdf: not a TrueType font: %q", tag)
+	}
+
+	f := &TrueType{
+		data:   data,
+		tables: make(map[string][]byte),
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, errors.New("pdf: TrueType table directory is truncated")
+	}
+	for i := range numTables {
+		entry := data[12+16*i:]
+		tag := string(entry[:4])
+		off := binary.BigEndian.Uint32(entry[8:])
+		length := binary.BigEndian.Uint32(entry[12:])
+		if (uint64(off) + uint64(length)) > uint64(len(data)) {
+			return nil, fmt.Errorf("pdf: TrueType table %q is truncated", tag)
+		}
+		f.tables[tag] = data[off : off+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if _, ok := f.tables[tag]; !ok {
+			return nil, fmt.Errorf("pdf: TrueType font is missing required table %q", tag)
+		}
+	}
+
+	err := f.parseHead()
+	if err != nil {
+		return nil, err
+	}
+	err = f.parseMetrics()
+	if err != nil {
+		return nil, err
+	}
+	err = f.parseCmap()
+	if err != nil {
+		return nil, err
+	}
+	f.parseOS2()
+	f.parsePost()
+	f.parseName()
+
+	return f, nil
+}
+
+func (f *TrueType) parseHead() error {
+	head := f.tables["head"]
+	if len(head) < 54 {
+		return errors.New("pdf: TrueType head table is too short")
+	}
+
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return errors.New("pdf: TrueType font has zero units per em")
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.macStyle = int(binary.BigEndian.Uint16(head[44:]))
+	f.indexToLocFormat = int(int16(binary.BigEndian.Uint16(head[50:])))
+	return nil
+}
+
+func (f *TrueType) parseMetrics() error {
+	maxp := f.tables["maxp"]
+	if len(maxp) < 6 {
+		return errors.New("pdf: TrueType maxp table is too short")
+	}
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	hhea := f.tables["hhea"]
+	if len(hhea) < 36 {
+		return errors.New("pdf: TrueType hhea table is too short")
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	hmtx := f.tables["hmtx"]
+	if (numHMetrics == 0) || (len(hmtx) < 4*numHMetrics) {
+		return errors.New("pdf: TrueType hmtx table is too short")
+	}
+	f.advances = make([]uint16, f.numGlyphs)
+	for i := range f.advances {
+		// Glyphs past the end of the metrics share the last advance.
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[4*min(i, numHMetrics-1):])
+	}
+	return nil
+}
+
+func (f *TrueType) parseCmap() error {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return errors.New("pdf: TrueType cmap table is too short")
+	}
+
+	// Prefer full Unicode subtables over BMP-only ones.
+	var best []byte
+	var bestRank int
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := range numTables {
+		if len(cmap) < 4+8*(i+1) {
+			break
+		}
+		rec := cmap[4+8*i:]
+		platform := binary.BigEndian.Uint16(rec)
+		encoding := binary.BigEndian.Uint16(rec[2:])
+		off := binary.BigEndian.Uint32(rec[4:])
+		if uint64(off)+2 > uint64(len(cmap)) {
+			continue
+		}
+		sub := cmap[off:]
+		format := binary.BigEndian.Uint16(sub)
+
+		var rank int
+		switch {
+		case (format == 12) && ((platform == 0) || ((platform == 3) && (encoding == 10))):
+			rank = 3
+		case (format == 4) && ((platform == 0) || ((platform == 3) && (encoding == 1))):
+			rank = 2
+		case (format == 4) && (platform == 3) && (encoding == 0):
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = sub, rank
+		}
+	}
+
+	f.cmap = make(map[rune]uint16)
+	switch {
+	case best == nil:
+		return errors.New("pdf: TrueType font has no usable Unicode cmap")
+	case binary.BigEndian.Uint16(best) == 12:
+		return f.parseCmap12(best)
+	default:
+		return f.parseCmap4(best, bestRank == 1)
+	}
+}
+
+func (f *TrueType) parseCmap4(sub []byte, symbol bool) error {
+	if len(sub) < 14 {
+		return errors.New("pdf: TrueType cmap subtable is too short")
+	}
+	segs := int(binary.BigEndian.Uint16(sub[6:])) / 2
+	if len(sub) < 16+8*segs {
+		return errors.New("pdf: TrueType cmap subtable is too short")
+	}
+
+	ends := sub[14:]
+	starts := sub[16+2*segs:]
+	deltas := sub[16+4*segs:]
+	ranges := sub[16+6*segs:]
+	for i := range segs {
+		end := int(binary.BigEndian.Uint16(ends[2*i:]))
+		start := int(binary.BigEndian.Uint16(starts[2*i:]))
+		delta := binary.BigEndian.Uint16(deltas[2*i:])
+		rangeOff := int(binary.BigEndian.Uint16(ranges[2*i:]))
+
+		for c := start; (c <= end) && (c != 0xFFFF); c++ {
+			var gid uint16
+			if rangeOff == 0 {
+				gid = uint16(c) + delta
+			} else {
+				off := 16 + 6*segs + 2*i + rangeOff + 2*(c-start)
+				if off+2 > len(sub) {
+					break
+				}
+				gid = binary.BigEndian.Uint16(sub[off:])
+				if gid != 0 {
+					gid += delta
+				}
+			}
+
+			if (gid == 0) || (int(gid) >= f.numGlyphs) {
+				continue
+			}
+
+			r := rune(c)
+			if symbol && (r >= 0xF000) && (r <= 0xF0FF) {
+				// Symbol fonts map single bytes into the private use
+				// area.
+				r -= 0xF000
+			}
+			f.cmap[r] = gid
+		}
+	}
+
+	return nil
+}
+
+func (f *TrueType) parseCmap12(sub []byte) error {
+	if len(sub) < 16 {
+		return errors.New("pdf: TrueType cmap subtable is too short")
+	}
+	groups := int(binary.BigEndian.Uint32(sub[12:]))
+	if (groups < 0) || (len(sub) < 16+12*groups) {
+		return errors.New("pdf: TrueType cmap subtable is too short")
+	}
+
+	for i := range groups {
+		g := sub[16+12*i:]
+		start := binary.BigEndian.Uint32(g)
+		end := binary.BigEndian.Uint32(g[4:])
+		gid := binary.BigEndian.Uint32(g[8:])
+		if (end < start) || (end > 0x10FFFF) {
+			continue
+		}
+
+		for c := start; c <= end; c++ {
+			if int(gid) >= f.numGlyphs {
+				break
+			}
+			if gid != 0 {
+				f.cmap[rune(c)] = uint16(gid)
+			}
+			gid++
+		}
+	}
+
+	return nil
+}
+
+func (f *TrueType) parseOS2() {
+	f.capHeight = f.ascent
+	f.weight = 400
+
+	os2 := f.tables["OS/2"]
+	if len(os2) < 78 {
+		return
+	}
+	f.weight = int(binary.BigEndian.Uint16(os2[4:]))
+	f.familyClass = int(binary.BigEndian.Uint16(os2[30:]) >> 8)
+	if version := binary.BigEndian.Uint16(os2); (version >= 2) && (len(os2) >= 90) {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+}
+
+func (f *TrueType) parsePost() {
+	post := f.tables["post"]
+	if len(post) < 16 {
+		return
+	}
+	f.italicAngle = float64(int32(binary.BigEndian.Uint32(post[4:]))) / 65536
+	f.fixedPitch = binary.BigEndian.Uint32(post[12:]) != 0
+}
+
+func (f *TrueType) parseName() {
+	f.postScriptName = "Unknown"
+
+	name := f.tables["name"]
+	if len(name) < 6 {
+		return
+	}
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	storage := int(binary.BigEndian.Uint16(name[4:]))
+	for i := range count {
+		if len(name) < 6+12*(i+1) {
+			break
+		}
+		rec := name[6+12*i:]
+		platform := binary.BigEndian.Uint16(rec)
+		id := binary.BigEndian.Uint16(rec[6:])
+		length := int(binary.BigEndian.Uint16(rec[8:]))
+		off := storage + int(binary.BigEndian.Uint16(rec[10:]))
+		if (id != 6) || (off+length > len(name)) {
+			continue
+		}
+
+		raw := name[off : off+length]
+		var str string
+		switch platform {
+		case 0, 3:
+			u := make([]uint16, len(raw)/2)
+			for i := range u {
+				u[i] = binary.BigEndian.Uint16(raw[2*i:])
+			}
+			str = string(utf16.Decode(u))
+		default:
+			str = string(raw)
+		}
+
+		if str = sanitizeFontName(str); str != "" {
+			f.postScriptName = str
+			return
+		}
+	}
+}
+
+// sanitizeFontName removes characters that aren't allowed in
+// PostScript font names.
+func sanitizeFontName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r < '!') || (r > '~') || strings.ContainsRune("[](){}<>/%", r) {
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// PostScriptName returns the PostScript name of the font.
+func (f *TrueType) PostScriptName() string {
+	return f.postScriptName
+}
+
+// NumGlyphs returns the number of glyphs in the font.
+func (f *TrueType) NumGlyphs() int {
+	return f.numGlyphs
+}
+
+// GlyphIndex returns the index of the glyph for r, or 0, the index of
+// the missing glyph, if the font has none.
+func (f *TrueType) GlyphIndex(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// scale converts a value in font units into thousandths of an em.
+func (f *TrueType) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// advance returns the advance width of the glyph gid in thousandths
+// of an em.
+func (f *TrueType) advance(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return f.scale(int(f.advances[gid]))
+}
@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestParseTrueType(t *testing.T) {
+	f, err := ParseTrueType(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.GlyphIndex('A') == 0 || f.PostScriptName() != "GoRegular" {
+		t.Fatal("bad")
+	}
+	if _, err := ParseTrueType([]byte("not a font")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEmbedTrueType(t *testing.T) {
+	var d Document
+	font, err := d.EmbedTrueType(gobold.TTF, []rune("Hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Content
+	c.BeginText()
+	c.SetFont("F1", 12)
+	c.ShowText(font.Encode("Hello"))
+	c.EndText()
+	cat, _, err := d.AddPages([]Page{{MediaBox: Letter, Contents: c.Stream(), Resources: Dict{"Font": Dict{"F1": font.Ref}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = cat
+	f0 := lookup(&d, font.Ref).(Dict)
+	if f0["Subtype"] != Name("Type0") {
+		t.Fatal(f0)
+	}
+	desc := lookup(&d, f0["DescendantFonts"].(Array)[0].(Reference)).(Dict)
+	if desc["Subtype"] != Name("CIDFontType2") {
+		t.Fatal(desc)
+	}
+	fd := lookup(&d, desc["FontDescriptor"].(Reference)).(Dict)
+	if _, ok := lookup(&d, fd["FontFile2"].(Reference)).(Stream); !ok {
+		t.Fatal("no fontfile2")
+	}
+	var buf bytes.Buffer
+	if _, err := d.Finish(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "/Length1 ") {
+		t.Fatal("length1")
+	}
+	validate(t, buf.Bytes())
+}
@@ -0,0 +1,254 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// AppendUpdate writes the PDF file of the given size read from
+// original to w, followed by an incremental update containing the
+// objects in changed. The original bytes are copied unmodified, which
+// keeps things such as signatures intact.
+//
+// The objects in the original file are named as described by
+// ObjectName. An object in changed with the same name as one of them
+// replaces it, while an object with any other name is added as a new
+// object. An object with a nil Object frees the original object of
+// that name instead, so that it is no longer part of the document.
+//
+// The update's cross-reference section is written in the same form as
+// the newest section of the original file, either as a table or as a
+// stream. The result covers the whole file, including the original.
+//
+// Encrypted files can't be updated, as the key needed to encrypt the
+// changed objects can't be found without the password, and updates
+// without any changes are rejected.
+func AppendUpdate(w io.Writer, original io.ReaderAt, size int64, changed []Indirect) (EncodeResult, error) {
+	if len(changed) == 0 {
+		return EncodeResult{}, errors.New("pdf: update has no changed objects")
+	}
+
+	d := newDecoder(original, size)
+	trailer, err := d.readXrefs()
+	if err != nil {
+		return EncodeResult{}, err
+	}
+	if _, ok := trailer["Encrypt"]; ok {
+		return EncodeResult{}, errors.New("pdf: cannot update an encrypted file")
+	}
+
+	s := newEncodeState(w)
+	defer s.release()
+	s.file = new(fileState)
+	_, err = io.Copy(s, io.NewSectionReader(original, 0, size))
+	if err != nil {
+		return EncodeResult{}, err
+	}
+	if size > 0 {
+		var last [1]byte
+		_, err = original.ReadAt(last[:], size-1)
+		if (err != nil) && (err != io.EOF) {
+			return EncodeResult{}, err
+		}
+		if (last[0] != '\n') && (last[0] != '\r') {
+			err = s.WriteByte('\n')
+			if err != nil {
+				return EncodeResult{}, err
+			}
+		}
+	}
+
+	// Give the original objects their existing numbers, and make sure
+	// that new ones are numbered after anything the original used.
+	next := 1
+	if v, ok := trailer["Size"].(Integer); ok {
+		next = max(next, int(v))
+	}
+	for num, e := range d.entries {
+		if !e.free {
+			s.names[ObjectName(num, e.gen)] = objID{num: num, gen: e.gen}
+		}
+		next = max(next, num+1)
+	}
+	s.unnamed = next - 1 - len(s.names)
+
+	var rows []xrefRow
+	var freed []int
+	seen := make(map[string]bool, len(changed))
+	for _, obj := range changed {
+		if seen[obj.Name] {
+			return EncodeResult{}, fmt.Errorf("pdf: duplicate object name %q", obj.Name)
+		}
+		seen[obj.Name] = true
+
+		if obj.Object == nil {
+			id, ok := s.names[obj.Name]
+			if !ok {
+				return EncodeResult{}, fmt.Errorf("pdf: cannot free nonexistent object %q", obj.Name)
+			}
+			rows = append(rows, xrefRow{num: id.num, gen: id.gen + 1, free: true})
+			freed = append(freed, id.num)
+			continue
+		}
+
+		err := obj.encode(s)
+		if err != nil {
+			return EncodeResult{}, err
+		}
+		_, err = s.WriteString("\n")
+		if err != nil {
+			return EncodeResult{}, err
+		}
+
+		id := s.names[obj.Name]
+		rows = append(rows, xrefRow{num: id.num, gen: id.gen, off: s.offsets[id.num]})
+	}
+
+	// Freed objects are linked into a list starting at object zero.
+	if len(freed) > 0 {
+		slices.Sort(freed)
+		rows = append(rows, xrefRow{num: 0, gen: 65535, free: true})
+		for i, row := range rows {
+			if !row.free {
+				continue
+			}
+			j, _ := slices.BinarySearch(freed, row.num+1)
+			if j < len(freed) {
+				rows[i].off = int64(freed[j])
+			}
+		}
+	}
+	slices.SortFunc(rows, func(a, b xrefRow) int { return a.num - b.num })
+
+	update := Dict{"Prev": Integer(d.startxref)}
+	for _, key := range []Name{"Root", "Info", "ID"} {
+		if v, ok := trailer[key]; ok {
+			update[key] = v
+		}
+	}
+
+	s.startxref = s.offset()
+	if trailer["Type"] == Name("XRef") {
+		err = s.encodeXrefStreamUpdate(rows, update)
+	} else {
+		err = s.encodeXrefUpdate(rows, update)
+	}
+	if err != nil {
+		return EncodeResult{}, err
+	}
+
+	_, err = fmt.Fprintf(s, "startxref\n%v\n%%%%EOF\n", s.startxref)
+	if err != nil {
+		return EncodeResult{}, err
+	}
+
+	err = s.Flush()
+	if err != nil {
+		return EncodeResult{}, err
+	}
+	return s.result(), nil
+}
+
+// xrefRow is a single entry of a cross-reference section. For free
+// entries, off is the number of the next free object.
+type xrefRow struct {
+	num  int
+	off  int64
+	gen  int
+	free bool
+}
+
+// xrefSubsections splits rows, which must be sorted, into runs of
+// consecutive object numbers.
+func xrefSubsections(rows []xrefRow) [][]xrefRow {
+	var subs [][]xrefRow
+	for len(rows) > 0 {
+		n := 1
+		for (n < len(rows)) && (rows[n].num == rows[0].num+n) {
+			n++
+		}
+		subs = append(subs, rows[:n])
+		rows = rows[n:]
+	}
+	return subs
+}
+
+// encodeXrefUpdate writes rows as a cross-reference table followed by
+// a trailer containing the entries in trailer.
+func (s *encodeState) encodeXrefUpdate(rows []xrefRow, trailer Dict) error {
+	_, err := s.WriteString("xref\n")
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range xrefSubsections(rows) {
+		_, err := fmt.Fprintf(s, "%v %v\n", sub[0].num, len(sub))
+		if err != nil {
+			return err
+		}
+		for _, row := range sub {
+			typ := 'n'
+			if row.free {
+				typ = 'f'
+			}
+			_, err := fmt.Fprintf(s, "%010d %05d %c \n", row.off, row.gen, typ)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	trailer["Size"] = Integer(len(s.names) + s.unnamed + 1)
+	_, err = s.WriteString("trailer\n")
+	if err != nil {
+		return err
+	}
+	err = trailer.encode(s)
+	if err != nil {
+		return err
+	}
+	_, err = s.WriteString("\n")
+	return err
+}
+
+// encodeXrefStreamUpdate writes rows as a cross-reference stream whose
+// dictionary also contains the entries in trailer.
+func (s *encodeState) encodeXrefStreamUpdate(rows []xrefRow, trailer Dict) error {
+	num := s.newObjNum()
+	xref := s.offset()
+	rows = append(rows, xrefRow{num: num, off: xref})
+
+	var offWidth int
+	for v := xref; v > 0; v >>= 8 {
+		offWidth++
+	}
+	w := [3]int{1, max(offWidth, 1), 2}
+
+	var index Array
+	data := make([]byte, 0, (w[0]+w[1]+w[2])*len(rows))
+	for _, sub := range xrefSubsections(rows) {
+		index = append(index, Integer(sub[0].num), Integer(len(sub)))
+		for _, row := range sub {
+			typ := byte(1)
+			if row.free {
+				typ = 0
+			}
+			data = append(data, typ)
+			for i := w[1] - 1; i >= 0; i-- {
+				data = append(data, byte(row.off>>(8*i)))
+			}
+			data = append(data, byte(row.gen>>8), byte(row.gen))
+		}
+	}
+
+	trailer["Type"] = Name("XRef")
+	trailer["Size"] = Integer(num + 1)
+	trailer["W"] = Array{Integer(w[0]), Integer(w[1]), Integer(w[2])}
+	trailer["Index"] = index
+
+	st := FlateBytes(data)
+	st.Dict = trailer
+	return s.encodeUnnamed(num, st)
+}
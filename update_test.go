@@ -0,0 +1,144 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testUpdate(t *testing.T, xrefStream bool) {
+	var d Document
+	var c Content
+	c.Rectangle(1, 2, 3, 4)
+	c.Fill()
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream()}, {MediaBox: Letter}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	d.SetInfo(Info{Title: "T"})
+	p := &PDF{Body: d.Body, Root: d.Root, Info: d.Info, XrefStream: xrefStream}
+	var out bytes.Buffer
+	if _, err := Encode(&out, p); err != nil {
+		t.Fatal(err)
+	}
+	orig := out.Bytes()
+
+	old, err := Decode(bytes.NewReader(orig), int64(len(orig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Find page 2's decoded name.
+	var pageName string
+	var pageDict Dict
+	for _, obj := range old.Body {
+		if dd, ok := obj.Object.(Dict); ok && dd["Type"] == Name("Page") && dd["MediaBox"] != nil {
+			if _, ok := dd["Contents"]; !ok {
+				pageName = obj.Name
+				pageDict = dd
+			}
+		}
+	}
+	if pageName == "" {
+		t.Fatal("no page")
+	}
+	var contentName string
+	for _, obj := range old.Body {
+		if _, ok := obj.Object.(Stream); ok {
+			contentName = obj.Name
+		}
+	}
+
+	nd := Dict{}
+	for k, v := range pageDict {
+		nd[k] = v
+	}
+	nd["MediaBox"] = A5
+	nd["Contents"] = Reference("newcontent")
+	var c2 Content
+	c2.Rectangle(5, 5, 5, 5)
+	c2.Stroke()
+
+	var upd bytes.Buffer
+	_, err = AppendUpdate(&upd, bytes.NewReader(orig), int64(len(orig)), []Indirect{
+		{Name: pageName, Object: nd},
+		{Name: "newcontent", Object: c2.Stream()},
+		{Name: contentName},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := upd.Bytes()
+	if !bytes.HasPrefix(data, orig) {
+		t.Fatal("original not preserved")
+	}
+	validate(t, data)
+
+	q, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found, foundNew, foundFreed bool
+	for _, obj := range q.Body {
+		if obj.Name == pageName {
+			found = true
+			if obj.Object.(Dict)["Contents"] == nil {
+				t.Fatal("no contents")
+			}
+		}
+		if obj.Name == contentName {
+			foundFreed = true
+		}
+		if _, ok := obj.Object.(Stream); ok && obj.Name != contentName {
+			foundNew = true
+		}
+	}
+	if !found || !foundNew || foundFreed {
+		t.Fatal(found, foundNew, foundFreed)
+	}
+	// Old version still reachable through Prev.
+	dd := newDecoder(bytes.NewReader(data), int64(len(data)))
+	tr, err := dd.readXrefs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := int64(tr["Prev"].(Integer))
+	if prev >= int64(len(orig)) {
+		t.Fatal(prev)
+	}
+	oldd := newDecoder(bytes.NewReader(data), int64(len(data)))
+	ent := map[int]xrefEntry{}
+	if _, err := oldd.readXref(prev, ent); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppendUpdate(t *testing.T)       { testUpdate(t, false) }
+func TestAppendUpdateStream(t *testing.T) { testUpdate(t, true) }
+
+func TestAppendUpdateRejects(t *testing.T) {
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Encryption = &Encryption{UserPassword: "u"}
+	orig, err := EncodeBytes(&d.PDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upd bytes.Buffer
+	_, err = AppendUpdate(&upd, bytes.NewReader(orig), int64(len(orig)), []Indirect{{Name: "new", Object: LiteralString("secret")}})
+	if err == nil || upd.Len() != 0 {
+		t.Fatal("encrypted file updated")
+	}
+
+	d.Encryption = nil
+	orig, err = EncodeBytes(&d.PDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AppendUpdate(&upd, bytes.NewReader(orig), int64(len(orig)), nil); err == nil || upd.Len() != 0 {
+		t.Fatal("empty update written")
+	}
+}
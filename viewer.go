@@ -0,0 +1,113 @@
+package pdf
+
+// ViewerPreferences controls how viewers present a document. False
+// and empty fields are omitted, leaving them up to the viewer.
+type ViewerPreferences struct {
+	HideToolbar  bool
+	HideMenubar  bool
+	HideWindowUI bool
+
+	// FitWindow resizes the window to fit the first page.
+	FitWindow bool
+
+	CenterWindow bool
+
+	// DisplayDocTitle shows the title from the document information
+	// dictionary in the window's title bar instead of the file name.
+	DisplayDocTitle bool
+
+	// Duplex is the paper handling to use when printing.
+	Duplex Duplex
+}
+
+// Duplex is a paper handling option for printing.
+type Duplex Name
+
+// Paper handling options.
+const (
+	DuplexSimplex       Duplex = "Simplex"
+	DuplexFlipShortEdge Duplex = "DuplexFlipShortEdge"
+	DuplexFlipLongEdge  Duplex = "DuplexFlipLongEdge"
+)
+
+func (prefs ViewerPreferences) encode(s *encodeState) error {
+	dict := make(Dict, 7)
+	for k, v := range map[Name]bool{
+		"HideToolbar":     prefs.HideToolbar,
+		"HideMenubar":     prefs.HideMenubar,
+		"HideWindowUI":    prefs.HideWindowUI,
+		"FitWindow":       prefs.FitWindow,
+		"CenterWindow":    prefs.CenterWindow,
+		"DisplayDocTitle": prefs.DisplayDocTitle,
+	} {
+		if v {
+			dict[k] = Boolean(true)
+		}
+	}
+	if prefs.Duplex != "" {
+		dict["Duplex"] = Name(prefs.Duplex)
+	}
+
+	return dict.encode(s)
+}
+
+// SetViewerPreferences sets the viewer preferences in the catalog.
+// d.Root must already be set to the catalog, such as one returned by
+// AddPages.
+func (d *Document) SetViewerPreferences(prefs ViewerPreferences) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	catalog["ViewerPreferences"] = prefs
+	return nil
+}
+
+// PageLayout is the arrangement of pages that a viewer uses when a
+// document is opened.
+type PageLayout Name
+
+// Page layouts.
+const (
+	PageLayoutSinglePage     PageLayout = "SinglePage"
+	PageLayoutOneColumn      PageLayout = "OneColumn"
+	PageLayoutTwoColumnLeft  PageLayout = "TwoColumnLeft"
+	PageLayoutTwoColumnRight PageLayout = "TwoColumnRight"
+	PageLayoutTwoPageLeft    PageLayout = "TwoPageLeft"
+	PageLayoutTwoPageRight   PageLayout = "TwoPageRight"
+)
+
+// PageMode determines what, such as the outline, a viewer shows
+// alongside the pages when a document is opened.
+type PageMode Name
+
+// Page modes.
+const (
+	PageModeUseNone        PageMode = "UseNone"
+	PageModeUseOutlines    PageMode = "UseOutlines"
+	PageModeUseThumbs      PageMode = "UseThumbs"
+	PageModeFullScreen     PageMode = "FullScreen"
+	PageModeUseOC          PageMode = "UseOC"
+	PageModeUseAttachments PageMode = "UseAttachments"
+)
+
+// SetPageLayout sets the page layout and page mode in the catalog.
+// Either may be empty to leave it up to the viewer. d.Root must
+// already be set to the catalog, such as one returned by AddPages.
+func (d *Document) SetPageLayout(layout PageLayout, mode PageMode) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	delete(catalog, "PageLayout")
+	if layout != "" {
+		catalog["PageLayout"] = Name(layout)
+	}
+	delete(catalog, "PageMode")
+	if mode != "" {
+		catalog["PageMode"] = Name(mode)
+	}
+	return nil
+}
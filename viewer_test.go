@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestViewerPreferences(t *testing.T) {
+	var d Document
+	var err error
+	d.Root, _, err = d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetInfo(Info{Title: "Doc"})
+	if err := d.SetViewerPreferences(ViewerPreferences{DisplayDocTitle: true, FitWindow: true, Duplex: DuplexFlipLongEdge}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetPageLayout(PageLayoutTwoColumnLeft, PageModeUseOutlines); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if !bytes.Contains(data, []byte("/PageLayout /TwoColumnLeft /PageMode /UseOutlines /Pages 2 0 R /Type /Catalog /ViewerPreferences <</DisplayDocTitle true /Duplex /DuplexFlipLongEdge /FitWindow true >>")) {
+		t.Fatal(string(data))
+	}
+	validate(t, data)
+}
+
+func TestOpenAction(t *testing.T) {
+	var d Document
+	var pages []Reference
+	var err error
+	d.Root, pages, err = d.AddPages([]Page{{MediaBox: A4}, {MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetOpenAction(OpenAtPage(pages[1], A4.URY)); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("/OpenAction [2 0 R /XYZ null 841.89 null]")) {
+		t.Fatal(out.String())
+	}
+	validate(t, out.Bytes())
+	d.SetOpenAction(OpenActionURI("https://example.com"))
+	out.Reset()
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("/OpenAction <</S /URI /URI (https://example.com) >>")) {
+		t.Fatal(out.String())
+	}
+	validate(t, out.Bytes())
+}
@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+)
+
+// XMP returns an XMP metadata stream describing the same information
+// as info. The stream is left uncompressed so that the packet is
+// visible to tools that scan files for metadata.
+func XMP(info Info) Stream {
+	return xmp(info, false)
+}
+
+// xmp is like XMP, but if pdfa is true the metadata also identifies the
+// document as conforming to PDF/A-1b.
+func xmp(info Info, pdfa bool) Stream {
+	var buf bytes.Buffer
+	buf.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	buf.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about=""
+ xmlns:dc="http://purl.org/dc/elements/1.1/"
+ xmlns:pdf="http://ns.adobe.com/pdf/1.3/"
+ xmlns:xmp="http://ns.adobe.com/xap/1.0/"`)
+	if pdfa {
+		buf.WriteString("\n xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\"")
+	}
+	buf.WriteString(">\n")
+
+	prop := func(name, value string) {
+		if value == "" {
+			return
+		}
+		buf.WriteString("<" + name + ">")
+		xml.EscapeText(&buf, []byte(value))
+		buf.WriteString("</" + name + ">\n")
+	}
+	container := func(name, kind, value string) {
+		if value == "" {
+			return
+		}
+		attr := ""
+		if kind == "Alt" {
+			attr = ` xml:lang="x-default"`
+		}
+		buf.WriteString("<" + name + "><rdf:" + kind + "><rdf:li" + attr + ">")
+		xml.EscapeText(&buf, []byte(value))
+		buf.WriteString("</rdf:li></rdf:" + kind + "></" + name + ">\n")
+	}
+	date := func(name string, t time.Time) {
+		if !t.IsZero() {
+			prop(name, t.Format(time.RFC3339))
+		}
+	}
+
+	container("dc:title", "Alt", info.Title)
+	container("dc:creator", "Seq", info.Author)
+	container("dc:description", "Alt", info.Subject)
+	prop("pdf:Keywords", info.Keywords)
+	prop("pdf:Producer", info.Producer)
+	prop("xmp:CreatorTool", info.Creator)
+	date("xmp:CreateDate", info.CreationDate)
+	date("xmp:ModifyDate", info.ModDate)
+	if pdfa {
+		prop("pdfaid:part", "1")
+		prop("pdfaid:conformance", "B")
+	}
+
+	buf.WriteString(`</rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`)
+
+	return Stream{
+		Dict: Dict{
+			"Type":    Name("Metadata"),
+			"Subtype": Name("XML"),
+		},
+		Length: int64(buf.Len()),
+		Data:   &buf,
+	}
+}
+
+// SetMetadata adds an XMP metadata stream describing info to the
+// document and refers to it from the catalog. d.Root must already be
+// set to the catalog, such as one returned by AddPages. If d.PDFA is
+// set, the metadata identifies the document as PDF/A.
+func (d *Document) SetMetadata(info Info) error {
+	catalog, err := d.catalog()
+	if err != nil {
+		return err
+	}
+
+	ref := d.Add(xmp(info, d.PDFA))
+	if d.PDFA {
+		d.metadata = ref
+	}
+	catalog["Metadata"] = ref
+	return nil
+}
@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	var d Document
+	if err := d.SetMetadata(Info{}); err == nil {
+		t.Fatal("no catalog")
+	}
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	info := Info{Title: "A <b> & c", Author: "Me", CreationDate: time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600))}
+	if err := d.SetMetadata(info); err != nil {
+		t.Fatal(err)
+	}
+	cat := lookup(&d, catalog).(Dict)
+	ref, ok := cat["Metadata"].(Reference)
+	if !ok {
+		t.Fatal(cat)
+	}
+	st := lookup(&d, ref).(Stream)
+	data, _ := io.ReadAll(st.Data)
+	if !bytes.HasPrefix(data, []byte("<?xpacket begin=\"\xEF\xBB\xBF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>")) {
+		t.Fatal("header")
+	}
+	if err := xml.Unmarshal(data, new(struct{})); err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(data)) != st.Length {
+		t.Fatal("length")
+	}
+	st.Data = bytes.NewReader(data)
+	for i := range d.Body {
+		if d.Body[i].Name == string(ref) {
+			d.Body[i].Object = st
+		}
+	}
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.Bytes())
+}
@@ -0,0 +1,196 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// readXrefStream reads a cross-reference stream using p, adding any
+// entries in it for objects that aren't already in entries, and
+// returns the stream's dictionary, which doubles as the trailer.
+func (d *decoder) readXrefStream(p *parser, entries map[int]xrefEntry) (Dict, error) {
+	off := p.s.Offset()
+	obj, err := p.parseObject()
+	if err != nil {
+		return nil, err
+	}
+	ind, _ := obj.(Indirect)
+	st, ok := ind.Object.(Stream)
+	if !ok || (st.Dict["Type"] != Name("XRef")) {
+		return nil, p.s.errorf(off, "expected xref or a cross-reference stream")
+	}
+
+	var w [3]int
+	wa, _ := st.Dict["W"].(Array)
+	if len(wa) != len(w) {
+		return nil, p.s.errorf(off, "cross-reference stream has invalid W")
+	}
+	var width int
+	for i := range w {
+		v, ok := wa[i].(Integer)
+		if !ok || (v < 0) || (v > 8) {
+			return nil, p.s.errorf(off, "cross-reference stream has invalid W")
+		}
+		w[i] = int(v)
+		width += w[i]
+	}
+
+	size, _ := st.Dict["Size"].(Integer)
+	index, _ := st.Dict["Index"].(Array)
+	if index == nil {
+		index = Array{Integer(0), size}
+	}
+	if len(index)%2 != 0 {
+		return nil, p.s.errorf(off, "cross-reference stream has invalid Index")
+	}
+
+	r, err := decodeStream(st)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(index); i += 2 {
+		start, ok1 := index[i].(Integer)
+		count, ok2 := index[i+1].(Integer)
+		if !ok1 || !ok2 || (start < 0) || (count < 0) {
+			return nil, p.s.errorf(off, "cross-reference stream has invalid Index")
+		}
+
+		for num := int(start); num < int(start+count); num++ {
+			if len(data) < width {
+				return nil, p.s.errorf(off, "cross-reference stream is truncated")
+			}
+			var fields [3]int64
+			for j := range fields {
+				for _, c := range data[:w[j]] {
+					fields[j] = fields[j]<<8 | int64(c)
+				}
+				data = data[w[j]:]
+			}
+			if w[0] == 0 {
+				// The type defaults to an in use object.
+				fields[0] = 1
+			}
+
+			if _, ok := entries[num]; ok {
+				continue
+			}
+			switch fields[0] {
+			case 0:
+				entries[num] = xrefEntry{gen: int(fields[2]), free: true}
+			case 1:
+				entries[num] = xrefEntry{offset: fields[1], gen: int(fields[2])}
+			case 2:
+				entries[num] = xrefEntry{stream: int(fields[1]), index: int(fields[2])}
+			}
+			// Other types are reserved, and are to be ignored.
+		}
+	}
+
+	return st.Dict, nil
+}
+
+// objStm is the decoded content of an object stream.
+type objStm struct {
+	data    []byte
+	first   int64
+	nums    []int
+	offsets []int64
+}
+
+// objStm returns the contents of the object stream numbered num.
+func (d *decoder) objStm(num int) (*objStm, error) {
+	if stm, ok := d.objStms[num]; ok {
+		return stm, nil
+	}
+
+	e, ok := d.entries[num]
+	if !ok || e.free || (e.stream > 0) {
+		return nil, fmt.Errorf("pdf: object stream %v not found", num)
+	}
+	ind, err := d.readObject(objID{num: num, gen: e.gen}, e.offset)
+	if err != nil {
+		return nil, err
+	}
+	st, ok := ind.Object.(Stream)
+	if !ok || (st.Dict["Type"] != Name("ObjStm")) {
+		return nil, fmt.Errorf("pdf: object %v is not an object stream", num)
+	}
+
+	n, _ := st.Dict["N"].(Integer)
+	first, _ := st.Dict["First"].(Integer)
+	r, err := decodeStream(st)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if (n < 0) || (first < 0) || (int64(first) > int64(len(data))) {
+		return nil, fmt.Errorf("pdf: object stream %v has an invalid header", num)
+	}
+
+	stm := &objStm{
+		data:    data,
+		first:   int64(first),
+		nums:    make([]int, 0, n),
+		offsets: make([]int64, 0, n),
+	}
+	p := newParser(bytes.NewReader(data[:first]))
+	for range n {
+		var pair [2]int64
+		for i := range pair {
+			tok, err := p.next()
+			if (err != nil) || (tok.Kind != TokenInteger) {
+				return nil, fmt.Errorf("pdf: object stream %v has an invalid header", num)
+			}
+			pair[i], err = strconv.ParseInt(tok.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("pdf: object stream %v has an invalid header", num)
+			}
+		}
+		stm.nums = append(stm.nums, int(pair[0]))
+		stm.offsets = append(stm.offsets, pair[1])
+	}
+
+	d.objStms[num] = stm
+	return stm, nil
+}
+
+// readCompressed reads the object numbered num, which is stored at
+// index within the object stream numbered stream.
+func (d *decoder) readCompressed(num, stream, index int) (Indirect, error) {
+	stm, err := d.objStm(stream)
+	if err != nil {
+		return Indirect{}, err
+	}
+	if (index >= len(stm.nums)) || (stm.nums[index] != num) {
+		return Indirect{}, fmt.Errorf("pdf: object %v not found in object stream %v", num, stream)
+	}
+
+	// Objects are stored in order, so each one ends where the next
+	// begins.
+	off, end := stm.first+stm.offsets[index], int64(len(stm.data))
+	if index+1 < len(stm.offsets) {
+		end = min(end, stm.first+stm.offsets[index+1])
+	}
+	if (off < stm.first) || (off > end) {
+		return Indirect{}, fmt.Errorf("pdf: object %v has an invalid offset in object stream %v", num, stream)
+	}
+	p := newParser(bytes.NewReader(stm.data[off:end]))
+	obj, err := p.parseObject()
+	if err == io.EOF {
+		err = fmt.Errorf("pdf: object %v in object stream %v is empty", num, stream)
+	}
+	if err != nil {
+		return Indirect{}, err
+	}
+	return Indirect{Name: ObjectName(num, 0), Object: obj}, nil
+}
@@ -0,0 +1,164 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func TestDecodeXrefStream(t *testing.T) {
+	var d Document
+	var c Content
+	c.Rectangle(1, 2, 3, 4)
+	c.Fill()
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream()}, {MediaBox: Letter}, {MediaBox: A5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	d.SetInfo(Info{Title: "Hello"})
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	conf := model.NewDefaultConfiguration()
+	conf.WriteObjectStream = true
+	conf.WriteXRefStream = true
+	var opt bytes.Buffer
+	if err := api.Optimize(bytes.NewReader(out.Bytes()), &opt, conf); err != nil {
+		t.Fatal(err)
+	}
+	data := opt.Bytes()
+	if !bytes.Contains(data, []byte("/XRef")) || !bytes.Contains(data, []byte("/ObjStm")) {
+		t.Fatalf("%q", data)
+	}
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := map[string]Object{}
+	for _, o := range p.Body {
+		objs[o.Name] = o.Object
+	}
+	cat := objs[string(p.Root)].(Dict)
+	pages := objs[string(cat["Pages"].(Reference))].(Dict)
+	if pages["Count"] != Integer(3) {
+		t.Fatal(pages)
+	}
+	kids := pages["Kids"].(Array)
+	first := objs[string(kids[0].(Reference))].(Dict)
+	st := objs[string(first["Contents"].(Reference))].(Stream)
+	r, err := decodeStream(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(r)
+	if string(body) != string(c.Bytes()) {
+		t.Fatalf("%q", body)
+	}
+	info := objs[string(p.Info)].(Dict)
+	if info["Title"] != LiteralString("Hello") {
+		t.Fatal(info)
+	}
+	for _, o := range p.Body {
+		if st, ok := o.Object.(Stream); ok && (st.Dict["Type"] == Name("XRef") || st.Dict["Type"] == Name("ObjStm")) {
+			t.Fatal("structural stream in body")
+		}
+	}
+}
+
+func TestDecodeHybrid(t *testing.T) {
+	var d Document
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	d.Add(LiteralString("old"))
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	prev := bytes.LastIndex(data, []byte("\nxref\n")) + 1
+	// object 4 is "old"; override it via a stream-only entry.
+	off := len(data)
+	data = append(data, "4 0 obj\n(new)\nendobj\n"...)
+	stmOff := len(data)
+	entry := []byte{1, 0, 0, byte(off >> 8), byte(off), 0}
+	var stm bytes.Buffer
+	EncodeObject(&stm, Indirect{Name: "x", Object: Stream{Dict: Dict{"Type": Name("XRef"), "W": Array{Integer(1), Integer(4), Integer(1)}, "Index": Array{Integer(4), Integer(1)}, "Size": Integer(6)}, Data: bytes.NewReader(entry)}})
+	data = append(data, bytes.Replace(stm.Bytes(), []byte("1 0 obj"), []byte("5 0 obj"), 1)...)
+	data = append(data, '\n')
+	xref := len(data)
+	data = append(data, "xref\n0 0\ntrailer\n<</Size 6 /Root 3 0 R /Prev "+itoa(prev)+" /XRefStm "+itoa(stmOff)+">>\nstartxref\n"+itoa(xref)+"\n%%EOF\n"...)
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range p.Body {
+		if o.Name == "4 0" && o.Object != LiteralString("new") {
+			t.Fatal(o)
+		}
+	}
+	if len(p.Body) != 4 {
+		t.Fatal(p.Body)
+	}
+}
+
+func TestWriteXrefStream(t *testing.T) {
+	var d Document
+	var c Content
+	c.Rectangle(1, 2, 3, 4)
+	c.Fill()
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4, Contents: c.Stream()}, {MediaBox: Letter}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	d.SetInfo(Info{Title: "T"})
+	d.XrefStream = true
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if bytes.Contains(data, []byte("trailer")) {
+		t.Fatal("trailer")
+	}
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Body) != len(d.Body) || p.Info == "" || p.Root == "" {
+		t.Fatal(len(p.Body), p.Info, p.Root)
+	}
+	validate(t, data)
+}
+
+func TestWriteXrefStreamLarge(t *testing.T) {
+	var d Document
+	d.Add(Stream{Data: bytes.NewReader(make([]byte, 1<<17))})
+	catalog, _, err := d.AddPages([]Page{{MediaBox: A4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Root = catalog
+	d.XrefStream = true
+	var out bytes.Buffer
+	if _, err := d.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	if !bytes.Contains(data, []byte("/W [1 3 2]")) {
+		t.Fatal("W")
+	}
+	p, err := Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil || len(p.Body) != 4 {
+		t.Fatal(err)
+	}
+	validate(t, data)
+}